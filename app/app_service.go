@@ -1,6 +1,7 @@
 package app
 
 import (
+	"github.com/CameronHonis/chess-arbitrator/grpc_service"
 	"github.com/CameronHonis/chess-arbitrator/router_service"
 	. "github.com/CameronHonis/marker"
 	"github.com/CameronHonis/service"
@@ -16,6 +17,7 @@ type AppService struct {
 
 	__dependencies__ Marker
 	RouterService    router_service.RouterServiceI
+	GrpcService      grpc_service.GrpcServiceI
 
 	__state__ Marker
 }
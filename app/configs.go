@@ -53,5 +53,15 @@ func GetClientsManagerConfig() *cm.ClientsManagerConfig {
 	configBuilder.WithMessageHandler(models.CONTENT_TYPE_ACCEPT_CHALLENGE, cm.HandleAcceptChallengeMessage)
 	configBuilder.WithMessageHandler(models.CONTENT_TYPE_DECLINE_CHALLENGE, cm.HandleDeclineChallengeMessage)
 	configBuilder.WithMessageHandler(models.CONTENT_TYPE_REVOKE_CHALLENGE, cm.HandleRevokeChallengeMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_SET_CHALLENGE_POLICY, cm.HandleSetChallengePolicyMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_INVITE_CHALLENGE_REQUEST, cm.HandleInviteChallengeMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_ACCEPT_INVITE_CHALLENGE, cm.HandleAcceptInviteChallengeMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_REVOKE_INVITE_CHALLENGE, cm.HandleRevokeInviteChallengeMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_SET_AVOID_LIST, cm.HandleSetAvoidListMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_CONFIRM_MATCH, cm.HandleConfirmMatchMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_GET_PENALTIES, cm.HandleGetPenaltiesMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_CREATE_PARTY, cm.HandleCreatePartyMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_LEAVE_PARTY, cm.HandleLeavePartyMessage)
+	configBuilder.WithMessageHandler(models.CONTENT_TYPE_HELLO, cm.HandleHelloMessage)
 	return configBuilder.Build()
 }
@@ -3,8 +3,10 @@ package app
 import (
 	"github.com/CameronHonis/chess-arbitrator/auth"
 	"github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/grpc_service"
 	"github.com/CameronHonis/chess-arbitrator/matcher"
 	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/penalty"
 	"github.com/CameronHonis/chess-arbitrator/router_service"
 	"github.com/CameronHonis/chess-arbitrator/secrets_manager"
 	"github.com/CameronHonis/chess-arbitrator/sub_service"
@@ -19,11 +21,13 @@ func BuildServices(configs ...service.ConfigI) *AppService {
 	appConfig := GetAppConfig()
 	loggerConfig := GetLoggerConfig()
 	routerConfig := GetRouterConfig()
+	grpcConfig := grpc_service.NewGrpcServiceConfig()
 	clientsManagerConfig := GetClientsManagerConfig()
 	subServiceConfig := sub_service.NewSubscriptionServiceConfig()
 	authServiceConfig := auth.NewAuthServiceConfig()
 	matchmakingServiceConfig := matchmaking.NewMatchmakingConfig()
 	matcherServiceConfig := matcher.NewMatcherServiceConfig()
+	penaltyServiceConfig := penalty.NewPenaltyServiceConfig()
 	for _, config := range configs {
 		if _appConfig, ok := config.(*AppServiceConfig); ok {
 			appConfig = _appConfig
@@ -31,6 +35,8 @@ func BuildServices(configs ...service.ConfigI) *AppService {
 			loggerConfig = _loggerConfig
 		} else if _routerConfig, ok := config.(*router_service.RouterServiceConfig); ok {
 			routerConfig = _routerConfig
+		} else if _grpcConfig, ok := config.(*grpc_service.GrpcServiceConfig); ok {
+			grpcConfig = _grpcConfig
 		} else if _clientsManagerConfig, ok := config.(*clients_manager.ClientsManagerConfig); ok {
 			clientsManagerConfig = _clientsManagerConfig
 		} else if _subServiceConfig, ok := config.(*sub_service.SubscriptionServiceConfig); ok {
@@ -41,6 +47,8 @@ func BuildServices(configs ...service.ConfigI) *AppService {
 			matchmakingServiceConfig = _matchmakingServiceConfig
 		} else if _matcherServiceConfig, ok := config.(*matcher.MatcherServiceConfig); ok {
 			matcherServiceConfig = _matcherServiceConfig
+		} else if _penaltyServiceConfig, ok := config.(*penalty.PenaltyServiceConfig); ok {
+			penaltyServiceConfig = _penaltyServiceConfig
 		}
 	}
 
@@ -48,30 +56,44 @@ func BuildServices(configs ...service.ConfigI) *AppService {
 	appService = NewAppService(appConfig)
 	loggerService := NewLoggerService(loggerConfig)
 	routerService := router_service.NewRouterService(routerConfig)
+	grpcService := grpc_service.NewGrpcService(grpcConfig)
 	clientsManager := clients_manager.NewClientsManager(clientsManagerConfig)
 	subService := sub_service.NewSubscriptionService(subServiceConfig)
 	authService := auth.NewAuthenticationService(authServiceConfig)
 	secretsManager := secrets_manager.NewSecretsManager()
 	matchmakingService := matchmaking.NewMatchmakingService(matchmakingServiceConfig)
 	matcherService := matcher.NewMatcherService(matcherServiceConfig)
+	penaltyService := penalty.NewPenaltyService(penaltyServiceConfig)
 
 	// inject dependencies
+	appService.AddDependency(grpcService)
 	appService.AddDependency(routerService)
+	grpcService.AddDependency(clientsManager)
+	grpcService.AddDependency(loggerService)
+	grpcService.AddDependency(matcherService)
+	grpcService.AddDependency(matchmakingService)
+	grpcService.AddDependency(authService)
 	routerService.AddDependency(clientsManager)
 	routerService.AddDependency(loggerService)
+	routerService.AddDependency(matcherService)
+	routerService.AddDependency(matchmakingService)
+	routerService.AddDependency(authService)
 	clientsManager.AddDependency(loggerService)
 	clientsManager.AddDependency(subService)
 	clientsManager.AddDependency(authService)
 	clientsManager.AddDependency(matcherService)
 	clientsManager.AddDependency(matchmakingService)
+	clientsManager.AddDependency(penaltyService)
 	matchmakingService.AddDependency(loggerService)
 	matchmakingService.AddDependency(matcherService)
+	matchmakingService.AddDependency(penaltyService)
 	matcherService.AddDependency(loggerService)
 	matcherService.AddDependency(authService)
 	matcherService.AddDependency(subService)
 	subService.AddDependency(authService)
 	subService.AddDependency(loggerService)
 	authService.AddDependency(secretsManager)
+	penaltyService.AddDependency(loggerService)
 
 	appService.Build()
 
@@ -1,151 +1,119 @@
 package main_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/CameronHonis/chess"
 	"github.com/CameronHonis/chess-arbitrator/app"
 	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/client"
 	"github.com/CameronHonis/chess-arbitrator/models"
 	"github.com/gorilla/websocket"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"net/http"
+	"net/url"
 	"os"
-	"sync"
 	"testing"
 	"time"
 )
 
-const PRINT_INBOUND_MSGS = false
-const PRINT_OUTBOUND_MSGS = false
+const ARBITRATOR_URL = "ws://localhost:8080"
+const ARBITRATOR_API_URL = "http://localhost:8080/api"
 
 func TestArbitrator(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Arbitrator Suite")
 }
 
-type MsgQueue struct {
-	dump []*models.Message
-	ptr  int
-	mu   sync.Mutex
-}
-
-func newMsgQueue() *MsgQueue {
-	return &MsgQueue{
-		dump: make([]*models.Message, 0),
-	}
-}
-
-func (m *MsgQueue) push(msg *models.Message) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.dump = append(m.dump, msg)
-}
-
-func (m *MsgQueue) pop() (msg *models.Message, ok bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if m.ptr >= len(m.dump) {
-		return nil, false
-	}
-	msg = m.dump[m.ptr]
-	m.ptr++
-	return msg, true
-}
-
-func (m *MsgQueue) at(idx int) (msg *models.Message, ok bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if m.ptr+idx >= len(m.dump) {
-		return nil, false
-	}
-	return m.dump[m.ptr+idx], true
-}
-
-func (m *MsgQueue) flush() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.ptr = 0
-	m.dump = make([]*models.Message, 0)
-}
-
-func (m *MsgQueue) toSlice() []*models.Message {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	return m.dump[m.ptr:]
-}
-
-func connectClient(msgQueue *MsgQueue, clientName string, shouldRequestAuth bool) *websocket.Conn {
-	var clientConn *websocket.Conn
+// connectClient connects a client with the given creds, or fresh creds when nil, retrying while the server spins up
+func connectClient(auth *models.AuthMessageContent) *client.Client {
+	config := client.NewClientConfigBuilder(ARBITRATOR_URL).
+		WithAuth(auth).
+		WithReconnect(0, 0, 0).
+		Build()
+	var c *client.Client
+	var err error
 	for i := 0; i < 10; i++ {
-		clientConn, _, _ = websocket.DefaultDialer.Dial("ws://localhost:8080", nil)
-		if clientConn != nil {
-			break
+		c, err = client.Connect(config)
+		if err == nil {
+			return c
 		}
 		time.Sleep(time.Millisecond * 200)
 	}
+	panic(fmt.Sprintf("could not connect client: %s", err))
+}
 
-	if shouldRequestAuth {
-		refreshAuthMsg := &models.Message{
-			ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-			Content:     &models.RefreshAuthMessageContent{ExistingAuth: nil},
+func listenForMsgType(c *client.Client, contentType models.ContentType) *models.Message {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-c.Messages():
+			if msg.ContentType == contentType {
+				return msg
+			}
+		case <-timeout:
+			panic(fmt.Sprintf("timed out waiting for msg of type %s", contentType))
 		}
-		sendMsg(clientName, clientConn, "", "", refreshAuthMsg)
 	}
+}
 
-	go func() {
-		for {
-			_, msgBytes, readErr := clientConn.ReadMessage()
-			if readErr != nil {
-				return
-			}
-			if PRINT_INBOUND_MSGS {
-				fmt.Printf("[client %s] << %s\n", clientName, string(msgBytes))
-			}
-
-			msg, unmarshalErr := models.UnmarshalToMessage(msgBytes)
-			if unmarshalErr != nil {
-				panic(unmarshalErr)
-			}
-
-			msgQueue.push(msg)
+func flush(c *client.Client) {
+	for {
+		select {
+		case <-c.Messages():
+		default:
+			return
 		}
-	}()
-
-	return clientConn
+	}
 }
 
-func sendMsg(clientName string, conn *websocket.Conn, pubKey, privKey models.Key, msg *models.Message) {
-	msg.SenderKey = pubKey
-	msg.PrivateKey = privKey
-	msgBytes, marshalErr := msg.Marshal()
-	if marshalErr != nil {
-		panic(marshalErr)
+// callApi sends the request to the REST API, signed with the creds when given, and decodes the response into out
+func callApi(method string, path string, auth *models.AuthMessageContent, body interface{}, out interface{}) int {
+	var bodyReader *bytes.Reader
+	if body == nil {
+		bodyReader = bytes.NewReader(nil)
+	} else {
+		bodyJson, _ := json.Marshal(body)
+		bodyReader = bytes.NewReader(bodyJson)
 	}
-	if PRINT_OUTBOUND_MSGS {
-		fmt.Printf("[client %s] >> %s\n", clientName, string(msgBytes))
+	req, reqErr := http.NewRequest(method, ARBITRATOR_API_URL+path, bodyReader)
+	Expect(reqErr).ToNot(HaveOccurred())
+	if auth != nil {
+		req.Header.Set("X-Client-Key", string(auth.PublicKey))
+		req.Header.Set("Authorization", "Bearer "+string(auth.PrivateKey))
 	}
-	if writeErr := conn.WriteMessage(websocket.TextMessage, msgBytes); writeErr != nil {
-		panic(writeErr)
+	resp, respErr := http.DefaultClient.Do(req)
+	Expect(respErr).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+	if out != nil {
+		Expect(json.NewDecoder(resp.Body).Decode(out)).To(Succeed())
 	}
+	return resp.StatusCode
 }
 
-func listenForMsgType(msgQueue *MsgQueue, contentType models.ContentType) *models.Message {
-	for i := 0; i < 100; i++ {
-		msgs := msgQueue.toSlice()
-		for _, msg := range msgs {
-			if msg.ContentType == contentType {
-				return msg
+// startMatchOverApi has client A challenge client B, with A as white, and B accept, returning the match once it's live
+func startMatchOverApi(clientA *client.Client, clientB *client.Client) *models.Match {
+	challenge := builders.NewChallenge("", clientB.PublicKey(), true, false, builders.NewBlitzTimeControl(), "", true)
+	Expect(callApi(http.MethodPost, "/challenges", clientA.Auth(), challenge, nil)).To(Equal(http.StatusAccepted))
+	accept := &models.AcceptChallengeMessageContent{ChallengerClientKey: clientA.PublicKey()}
+	Expect(callApi(http.MethodPost, "/challenges/accept", clientB.Auth(), accept, nil)).To(Equal(http.StatusAccepted))
+
+	var match *models.Match
+	Eventually(func() *models.Match {
+		var liveMatches []*models.Match
+		callApi(http.MethodGet, "/matches/live", nil, nil, &liveMatches)
+		for _, liveMatch := range liveMatches {
+			if liveMatch.WhiteClientKey == clientA.PublicKey() {
+				match = liveMatch
 			}
 		}
-		time.Sleep(10 * time.Millisecond)
-	}
-	panic(fmt.Sprintf("timed out waiting for msg of type %s", contentType))
+		return match
+	}).ShouldNot(BeNil())
+	return match
 }
 
 var botClientSecret string
@@ -161,19 +129,13 @@ var _ = BeforeSuite(func() {
 })
 
 var _ = AfterSuite(func() {
-	appService.Stop()
-
 	_ = os.Setenv(string(models.SECRET_BOT_CLIENT_SECRET), prevBotClientSecret)
 })
 
 var _ = Describe("integration tests", func() {
 	Describe("auth", func() {
 		var prevAuthKeyMinsToStale string
-		var conn *websocket.Conn
-		var msgQueue *MsgQueue
 		BeforeEach(func() {
-			msgQueue = newMsgQueue()
-			conn = connectClient(msgQueue, "A", false)
 			prevAuthKeyMinsToStale = os.Getenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE))
 			_ = os.Setenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE), "1")
 		})
@@ -182,233 +144,147 @@ var _ = Describe("integration tests", func() {
 		})
 		Describe("request refresh auth", func() {
 			When("no prior creds exist", func() {
-				BeforeEach(func() {
-					refreshAuthMsg := &models.Message{
-						ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-						Content: &models.RefreshAuthMessageContent{
-							ExistingAuth: nil,
-						},
-					}
-					sendMsg("A", conn, "", "", refreshAuthMsg)
-				})
 				It("replies with fresh creds", func() {
-					listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
+					c := connectClient(nil)
+					defer c.Close()
+					Expect(c.PublicKey()).ToNot(BeEmpty())
+					Expect(c.Auth().PrivateKey).ToNot(BeEmpty())
 				})
 			})
 			When("prior creds do exist", func() {
 				var pubKey models.Key
 				var priKey models.Key
 				BeforeEach(func() {
-					refreshAuthMsg := &models.Message{
-						ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-						Content: &models.RefreshAuthMessageContent{
-							ExistingAuth: nil,
-						},
-					}
-					sendMsg("A", conn, "", "", refreshAuthMsg)
-					msg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-					authMsgContent := msg.Content.(*models.AuthMessageContent)
-					pubKey = authMsgContent.PublicKey
-					priKey = authMsgContent.PrivateKey
-					msgQueue.flush()
-					Expect(conn.Close()).ToNot(HaveOccurred())
-
-					conn = connectClient(msgQueue, "A", false)
+					c := connectClient(nil)
+					pubKey = c.Auth().PublicKey
+					priKey = c.Auth().PrivateKey
+					Expect(c.Close()).ToNot(HaveOccurred())
 				})
 				When("the auth is valid", func() {
 					When("the auth is fresh", func() {
-						BeforeEach(func() {
-							msgQueue.flush()
-							refreshAuthMsg := &models.Message{
-								ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-								Content: &models.RefreshAuthMessageContent{
-									ExistingAuth: &models.AuthMessageContent{
-										PublicKey:  pubKey,
-										PrivateKey: priKey,
-									},
-								},
-							}
-							sendMsg("A", conn, pubKey, priKey, refreshAuthMsg)
-						})
 						It("mirrors the creds back", func() {
-							authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-							authMsgContent := authMsg.Content.(*models.AuthMessageContent)
-							Expect(authMsgContent.PublicKey).To(Equal(pubKey))
-							Expect(authMsgContent.PrivateKey).To(Equal(priKey))
+							c := connectClient(&models.AuthMessageContent{
+								PublicKey:  pubKey,
+								PrivateKey: priKey,
+							})
+							defer c.Close()
+							Expect(c.Auth().PublicKey).To(Equal(pubKey))
+							Expect(c.Auth().PrivateKey).To(Equal(priKey))
 						})
 					})
 					When("the auth is stale", func() {
 						BeforeEach(func() {
-							msgQueue.flush()
-
 							prevAuthKeyMinsToStale = os.Getenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE))
 							_ = os.Setenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE), "0.0001")
 
 							time.Sleep(time.Millisecond * 100)
-
-							refreshAuthMsg := &models.Message{
-								ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-								Content: &models.RefreshAuthMessageContent{
-									ExistingAuth: &models.AuthMessageContent{
-										PublicKey:  pubKey,
-										PrivateKey: priKey,
-									},
-								},
-							}
-							sendMsg("A", conn, pubKey, priKey, refreshAuthMsg)
 						})
 						AfterEach(func() {
 							_ = os.Setenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE), prevAuthKeyMinsToStale)
 						})
 						It("replies with the updated private key", func() {
-							authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-							authMsgContent := authMsg.Content.(*models.AuthMessageContent)
-							Expect(authMsgContent.PublicKey).To(Equal(pubKey))
-							Expect(authMsgContent.PrivateKey).ToNot(Equal(priKey))
+							c := connectClient(&models.AuthMessageContent{
+								PublicKey:  pubKey,
+								PrivateKey: priKey,
+							})
+							defer c.Close()
+							Expect(c.Auth().PublicKey).To(Equal(pubKey))
+							Expect(c.Auth().PrivateKey).ToNot(Equal(priKey))
 						})
 					})
 				})
 				When("the auth is invalid", func() {
-					BeforeEach(func() {
-						msgQueue.flush()
-						refreshAuthMsg := &models.Message{
-							ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-							Content: &models.RefreshAuthMessageContent{
-								ExistingAuth: &models.AuthMessageContent{
-									PublicKey:  pubKey,
-									PrivateKey: "invalid",
-								},
-							},
-						}
-						sendMsg("A", conn, pubKey, priKey, refreshAuthMsg)
-					})
 					It("replies with a new client creds", func() {
-						authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-						authMsgContent := authMsg.Content.(*models.AuthMessageContent)
-						Expect(authMsgContent.PublicKey).ToNot(Equal(pubKey))
-						Expect(authMsgContent.PrivateKey).ToNot(Equal(priKey))
+						c := connectClient(&models.AuthMessageContent{
+							PublicKey:  pubKey,
+							PrivateKey: "invalid",
+						})
+						defer c.Close()
+						Expect(c.Auth().PublicKey).ToNot(Equal(pubKey))
+						Expect(c.Auth().PrivateKey).ToNot(Equal(priKey))
 					})
 				})
 			})
 			When("the client is currently in a match", func() {
-				var pubKey models.Key
-				var priKey models.Key
+				var auth *models.AuthMessageContent
 				BeforeEach(func() {
-					refreshAuthMsg := &models.Message{
-						ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-						Content: &models.RefreshAuthMessageContent{
-							ExistingAuth: nil,
-						},
-					}
-					sendMsg("A", conn, "", "", refreshAuthMsg)
-					authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-					authMsgContent := authMsg.Content.(*models.AuthMessageContent)
-					pubKey = authMsgContent.PublicKey
-					priKey = authMsgContent.PrivateKey
-
-					msgQueueB := newMsgQueue()
-					connB := connectClient(msgQueueB, "B", true)
-					authBMsg := listenForMsgType(msgQueueB, models.CONTENT_TYPE_AUTH)
-					authMsgContent = authBMsg.Content.(*models.AuthMessageContent)
-					pubKeyB := authMsgContent.PublicKey
-					priKeyB := authMsgContent.PrivateKey
-					msgQueueB.flush()
-
-					sendMsg("A", conn, pubKey, priKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_CHALLENGE_REQUEST,
-						Content: &models.ChallengeRequestMessageContent{
-							Challenge: builders.NewChallenge(pubKey, pubKeyB, true, false, builders.NewBlitzTimeControl(), "", true),
-						},
-					})
-
-					_ = listenForMsgType(msgQueueB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-
-					msgQueue.flush()
-					msgQueueB.flush()
-					sendMsg("B", connB, pubKeyB, priKeyB, &models.Message{
-						ContentType: models.CONTENT_TYPE_ACCEPT_CHALLENGE,
-						Content: &models.AcceptChallengeMessageContent{
-							ChallengerClientKey: pubKey,
-						},
-					})
+					clientA := connectClient(nil)
+					auth = clientA.Auth()
+					clientB := connectClient(nil)
+					DeferCleanup(clientB.Close)
 
-					_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_MATCH_UPDATED)
-					_ = listenForMsgType(msgQueueB, models.CONTENT_TYPE_MATCH_UPDATED)
-					msgQueue.flush()
-					msgQueueB.flush()
+					Expect(clientA.Challenge(builders.NewChallenge(clientA.PublicKey(), clientB.PublicKey(), true, false, builders.NewBlitzTimeControl(), "", true))).To(Succeed())
+					_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 
-					Expect(conn.Close()).ToNot(HaveOccurred())
+					Expect(clientB.AcceptChallenge(clientA.PublicKey())).To(Succeed())
+					_ = listenForMsgType(clientA, models.CONTENT_TYPE_MATCH_UPDATED)
+					_ = listenForMsgType(clientB, models.CONTENT_TYPE_MATCH_UPDATED)
 
-					conn = connectClient(msgQueue, "A", false)
+					Expect(clientA.Close()).ToNot(HaveOccurred())
 				})
 				It("responds with the match update", func() {
-					refreshAuthMsg := &models.Message{
-						ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
-						Content: &models.RefreshAuthMessageContent{
-							ExistingAuth: &models.AuthMessageContent{
-								PublicKey:  pubKey,
-								PrivateKey: priKey,
-							},
-						},
-					}
-					sendMsg("A", conn, pubKey, priKey, refreshAuthMsg)
+					c := connectClient(auth)
+					defer c.Close()
 
-					listenForMsgType(msgQueue, models.CONTENT_TYPE_MATCH_UPDATED)
+					listenForMsgType(c, models.CONTENT_TYPE_MATCH_UPDATED)
 				})
 			})
 		})
 	})
 
 	Describe("auth upgrade", func() {
-		var conn *websocket.Conn
-		var msgQueue *MsgQueue
-		BeforeEach(func() {
-			msgQueue = newMsgQueue()
-			conn = connectClient(msgQueue, "A", true)
-		})
 		Describe("request auth upgrade", func() {
 			When("the request includes valid auth keys", func() {
-				var pubKey models.Key
-				var privKey models.Key
+				var c *client.Client
+				var upgradeErr error
 				BeforeEach(func() {
-					authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-					msgQueue.flush()
-
-					pubKey = authMsg.Content.(*models.AuthMessageContent).PublicKey
-					privKey = authMsg.Content.(*models.AuthMessageContent).PrivateKey
-
-					sendMsg("A", conn, pubKey, privKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_UPGRADE_AUTH_REQUEST,
-						Content: &models.UpgradeAuthRequestMessageContent{
-							Role:   models.BOT,
-							Secret: botClientSecret,
-						},
-					})
-
+					c = connectClient(nil)
+					flush(c)
+					upgradeErr = c.UpgradeAuth(models.BOT, botClientSecret)
+				})
+				AfterEach(func() {
+					_ = c.Close()
 				})
 				It("responds with an Upgrade Auth Msg", func() {
-					authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
+					Expect(upgradeErr).ToNot(HaveOccurred())
+					authMsg := listenForMsgType(c, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
 					Expect(authMsg).To(PointTo(HaveField(
 						"Content", PointTo(MatchAllFields(Fields{
 							"UpgradedToRole": BeEquivalentTo(models.BOT),
 						})),
 					)))
 				})
-				AfterEach(func() {
-					_ = conn.Close()
-				})
 			})
 			When("the request doesn't include auth keys", func() {
+				// NOTE: the client always signs its requests, so this talks to the socket directly
 				It("responds with an invalid auth msg", func() {
-					sendMsg("A", conn, "", "", &models.Message{
+					conn, _, dialErr := websocket.DefaultDialer.Dial(ARBITRATOR_URL, nil)
+					Expect(dialErr).ToNot(HaveOccurred())
+					defer conn.Close()
+
+					refreshAuthBytes, _ := (&models.Message{
+						ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
+						Content:     &models.RefreshAuthMessageContent{ExistingAuth: nil},
+					}).Marshal()
+					Expect(conn.WriteMessage(websocket.TextMessage, refreshAuthBytes)).To(Succeed())
+
+					msgBytes, _ := (&models.Message{
 						ContentType: models.CONTENT_TYPE_UPGRADE_AUTH_REQUEST,
 						Content: &models.UpgradeAuthRequestMessageContent{
 							Role:   models.BOT,
 							Secret: "secret",
 						},
-					})
-
-					listenForMsgType(msgQueue, models.CONTENT_TYPE_INVALID_AUTH)
+					}).Marshal()
+					Expect(conn.WriteMessage(websocket.TextMessage, msgBytes)).To(Succeed())
+
+					Expect(conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+					for {
+						_, rawMsg, readErr := conn.ReadMessage()
+						Expect(readErr).ToNot(HaveOccurred())
+						if msg, _ := models.UnmarshalToMessage(rawMsg); msg != nil && msg.ContentType == models.CONTENT_TYPE_INVALID_AUTH {
+							return
+						}
+					}
 				})
 			})
 		})
@@ -416,50 +292,32 @@ var _ = Describe("integration tests", func() {
 	})
 
 	Describe("challenges", func() {
-		var conn *websocket.Conn
-		var msgQueue *MsgQueue
+		var clientA *client.Client
 		BeforeEach(func() {
-			msgQueue = newMsgQueue()
-			conn = connectClient(msgQueue, "A", true)
+			clientA = connectClient(nil)
+			DeferCleanup(clientA.Close)
 		})
 		When("client A sends client B a challenge request", func() {
-			var clientAPubKey models.Key
-			var clientAPrivKey models.Key
-			var clientBConn *websocket.Conn
-			var clientBMsgQueue *MsgQueue
-			var clientBPubKey models.Key
-			var clientBPrivKey models.Key
+			var clientB *client.Client
 			var challengeAtoB *models.Challenge
 			BeforeEach(func() {
-				clientBMsgQueue = newMsgQueue()
-				clientBConn = connectClient(clientBMsgQueue, "B", true)
-
-				authMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-				msgQueue.flush()
-				challengedAuthMsg := listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_AUTH)
-				clientBMsgQueue.flush()
-
-				clientAPubKey = authMsg.Content.(*models.AuthMessageContent).PublicKey
-				clientAPrivKey = authMsg.Content.(*models.AuthMessageContent).PrivateKey
-				clientBPubKey = challengedAuthMsg.Content.(*models.AuthMessageContent).PublicKey
-				clientBPrivKey = challengedAuthMsg.Content.(*models.AuthMessageContent).PrivateKey
+				clientB = connectClient(nil)
+				DeferCleanup(clientB.Close)
+				flush(clientA)
+				flush(clientB)
+
 				challengeAtoB = builders.NewChallenge(
-					clientAPubKey,
-					clientBPubKey,
+					clientA.PublicKey(),
+					clientB.PublicKey(),
 					true,
 					false,
 					builders.NewBlitzTimeControl(),
 					"",
 					true)
-				sendMsg("A", conn, clientAPubKey, clientAPrivKey, &models.Message{
-					ContentType: models.CONTENT_TYPE_CHALLENGE_REQUEST,
-					Content: &models.ChallengeRequestMessageContent{
-						Challenge: challengeAtoB,
-					},
-				})
+				Expect(clientA.Challenge(challengeAtoB)).To(Succeed())
 			})
 			It("sends clients A & B the new challenge", func() {
-				challengeUpdatedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				challengeUpdatedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 				Expect(challengeUpdatedMsgToA).To(PointTo(HaveField(
 					"Content", PointTo(HaveField(
 						"Challenge", PointTo(MatchAllFields(Fields{
@@ -470,31 +328,34 @@ var _ = Describe("integration tests", func() {
 							"IsChallengerBlack": Equal(challengeAtoB.IsChallengerBlack),
 							"TimeControl":       Equal(challengeAtoB.TimeControl),
 							"BotName":           BeEmpty(),
+							"IsRated":           Equal(challengeAtoB.IsRated),
+							"InviteToken":       BeEmpty(),
+							"StartingFEN":       Equal(challengeAtoB.StartingFEN),
+							"Variant":           Equal(challengeAtoB.Variant),
 							"TimeCreated":       PointTo(BeTemporally(">=", time.Now().Add(-1*time.Second))),
 							"IsActive":          BeTrue(),
 						}))),
 					),
 				)))
 
-				challengeUpdateMsgToB := listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				challengeUpdateMsgToB := listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 				Expect(challengeUpdateMsgToB).To(Equal(challengeUpdatedMsgToA))
 			})
+			It("publishes the new challenge to both clients", func() {
+				Eventually(clientA.ChallengeUpdates()).Should(Receive(HaveField("IsActive", BeTrue())))
+				Eventually(clientB.ChallengeUpdates()).Should(Receive(HaveField("IsActive", BeTrue())))
+			})
 			Describe("and the challenger client revokes the challenge", func() {
 				BeforeEach(func() {
-					_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					msgQueue.flush()
-					_ = listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					clientBMsgQueue.flush()
-
-					sendMsg("A", conn, clientAPubKey, clientAPrivKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_REVOKE_CHALLENGE,
-						Content: &models.RevokeChallengeMessageContent{
-							ChallengedClientKey: challengeAtoB.ChallengedKey,
-						},
-					})
+					_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientA)
+					_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientB)
+
+					Expect(clientA.RevokeChallenge(challengeAtoB.ChallengedKey)).To(Succeed())
 				})
 				It("responds to clients A & B with a challenge update msg", func() {
-					challengeUpdatedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeUpdatedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeUpdatedMsgToA).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Challenge", PointTo(MatchAllFields(Fields{
@@ -505,13 +366,17 @@ var _ = Describe("integration tests", func() {
 								"IsChallengerBlack": Equal(challengeAtoB.IsChallengerBlack),
 								"TimeControl":       Equal(challengeAtoB.TimeControl),
 								"BotName":           BeEmpty(),
+								"IsRated":           Equal(challengeAtoB.IsRated),
+								"InviteToken":       BeEmpty(),
+								"StartingFEN":       Equal(challengeAtoB.StartingFEN),
+								"Variant":           Equal(challengeAtoB.Variant),
 								"TimeCreated":       PointTo(BeTemporally(">=", time.Now().Add(-2*time.Second))),
 								"IsActive":          BeFalse(),
 							})),
 						)),
 					)))
 
-					challengeUpdateMsgToB := listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeUpdateMsgToB := listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeUpdateMsgToB).To(Equal(challengeUpdatedMsgToA))
 				})
 			})
@@ -524,20 +389,15 @@ var _ = Describe("integration tests", func() {
 			})
 			Describe("and client B accepts", func() {
 				BeforeEach(func() {
-					_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					msgQueue.flush()
-					_ = listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					clientBMsgQueue.flush()
-
-					sendMsg("B", clientBConn, clientBPubKey, clientBPrivKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_ACCEPT_CHALLENGE,
-						Content: &models.AcceptChallengeMessageContent{
-							ChallengerClientKey: challengeAtoB.ChallengerKey,
-						},
-					})
+					_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientA)
+					_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientB)
+
+					Expect(clientB.AcceptChallenge(challengeAtoB.ChallengerKey)).To(Succeed())
 				})
 				It("responds to clients A & B with an inactive challenge msg", func() {
-					challengeAcceptedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeAcceptedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeAcceptedMsgToA).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Challenge", PointTo(MatchAllFields(Fields{
@@ -548,17 +408,21 @@ var _ = Describe("integration tests", func() {
 								"IsChallengerBlack": Equal(challengeAtoB.IsChallengerBlack),
 								"TimeControl":       Equal(challengeAtoB.TimeControl),
 								"BotName":           BeEmpty(),
+								"IsRated":           Equal(challengeAtoB.IsRated),
+								"InviteToken":       BeEmpty(),
+								"StartingFEN":       Equal(challengeAtoB.StartingFEN),
+								"Variant":           Equal(challengeAtoB.Variant),
 								"TimeCreated":       PointTo(BeTemporally(">=", time.Now().Add(-2*time.Second))),
 								"IsActive":          BeFalse(),
 							})),
 						)),
 					)))
 
-					challengeAcceptedMsgToB := listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeAcceptedMsgToB := listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeAcceptedMsgToB).To(Equal(challengeAcceptedMsgToA))
 				})
 				It("responds to clients A & B with a match created msg", func() {
-					matchCreatedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_MATCH_UPDATED)
+					matchCreatedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_MATCH_UPDATED)
 					Expect(matchCreatedMsgToA).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Match", PointTo(MatchAllFields(Fields{
@@ -570,33 +434,42 @@ var _ = Describe("integration tests", func() {
 								"BlackTimeRemainingSec": Equal(300.0),
 								"TimeControl":           Equal(challengeAtoB.TimeControl),
 								"BotName":               Equal(""),
+								"StartingFEN":           Ignore(),
+								"IsRated":               Equal(challengeAtoB.IsRated),
+								"Variant":               Ignore(),
+								"Chess960Position":      Ignore(),
+								"CurrentFEN":            Ignore(),
+								"WhiteCheckCount":       BeZero(),
+								"BlackCheckCount":       BeZero(),
 								"LastMoveTime":          Ignore(),
 								"LastMove":              BeNil(),
+								"MoveHistory":           BeEmpty(),
+								"PlyCount":              BeZero(),
 								"Result":                Equal(models.MATCH_RESULT_IN_PROGRESS),
 							})),
 						)),
 					)))
 
-					matchCreatedMsgToB := listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_MATCH_UPDATED)
+					matchCreatedMsgToB := listenForMsgType(clientB, models.CONTENT_TYPE_MATCH_UPDATED)
 					Expect(matchCreatedMsgToB).To(Equal(matchCreatedMsgToA))
 				})
+				It("publishes the new match to both clients", func() {
+					var matchToA *models.Match
+					Eventually(clientA.MatchUpdates()).Should(Receive(&matchToA))
+					Eventually(clientB.MatchUpdates()).Should(Receive(Equal(matchToA)))
+				})
 			})
 			Describe("and the challenged declines", func() {
 				BeforeEach(func() {
-					_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					msgQueue.flush()
-					_ = listenForMsgType(clientBMsgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-					clientBMsgQueue.flush()
-
-					sendMsg("B", clientBConn, clientBPubKey, clientBPrivKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_DECLINE_CHALLENGE,
-						Content: &models.DeclineChallengeMessageContent{
-							ChallengerClientKey: challengeAtoB.ChallengerKey,
-						},
-					})
+					_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientA)
+					_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					flush(clientB)
+
+					Expect(clientB.DeclineChallenge(challengeAtoB.ChallengerKey)).To(Succeed())
 				})
 				It("responds to both clients with an inactive challenge update", func() {
-					challengeUpdatedMsg := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeUpdatedMsg := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeUpdatedMsg).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Challenge", PointTo(HaveField(
@@ -609,117 +482,83 @@ var _ = Describe("integration tests", func() {
 		})
 
 		When("clients A & B are in a match", func() {
-			var pubKeyA models.Key
-			var privKeyA models.Key
-			var msgQueueB *MsgQueue
-			var connB *websocket.Conn
-			var pubKeyB models.Key
-			var privKeyB models.Key
+			var clientB *client.Client
 			BeforeEach(func() {
-				msgQueueB = newMsgQueue()
-				connB = connectClient(msgQueueB, "B", true)
-
-				authMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH)
-				pubKeyA = authMsgToA.Content.(*models.AuthMessageContent).PublicKey
-				privKeyA = authMsgToA.Content.(*models.AuthMessageContent).PrivateKey
-
-				authMsgToB := listenForMsgType(msgQueueB, models.CONTENT_TYPE_AUTH)
-				pubKeyB = authMsgToB.Content.(*models.AuthMessageContent).PublicKey
-				privKeyB = authMsgToB.Content.(*models.AuthMessageContent).PrivateKey
-				_ = authMsgToB.Content.(*models.AuthMessageContent).PrivateKey
-
-				sendMsg("A", conn, pubKeyA, privKeyA, &models.Message{
-					ContentType: models.CONTENT_TYPE_CHALLENGE_REQUEST,
-					Content: &models.ChallengeRequestMessageContent{
-						Challenge: builders.NewChallenge(
-							pubKeyA,
-							pubKeyB,
-							true,
-							false,
-							builders.NewBlitzTimeControl(),
-							"",
-							true),
-					},
-				})
+				clientB = connectClient(nil)
+				DeferCleanup(clientB.Close)
 
-				_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-				msgQueue.flush()
-				_ = listenForMsgType(msgQueueB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-				msgQueueB.flush()
+				Expect(clientA.Challenge(builders.NewChallenge(
+					clientA.PublicKey(),
+					clientB.PublicKey(),
+					true,
+					false,
+					builders.NewBlitzTimeControl(),
+					"",
+					true))).To(Succeed())
 
-				sendMsg("B", connB, pubKeyB, privKeyB, &models.Message{
-					ContentType: models.CONTENT_TYPE_ACCEPT_CHALLENGE,
-					Content: &models.AcceptChallengeMessageContent{
-						ChallengerClientKey: pubKeyA,
-					},
-				})
+				_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				flush(clientA)
+				_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				flush(clientB)
 
-				_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-				msgQueue.flush()
-				_ = listenForMsgType(msgQueueB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-				msgQueueB.flush()
+				Expect(clientB.AcceptChallenge(clientA.PublicKey())).To(Succeed())
+
+				_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				flush(clientA)
+				_ = listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+				flush(clientB)
 			})
 
 			Describe("and a third client challenges client A", func() {
-				var msgQueueC *MsgQueue
-				var pubKeyC models.Key
+				var clientC *client.Client
 				BeforeEach(func() {
-					msgQueueC = newMsgQueue()
-					thirdClientConn := connectClient(msgQueueC, "C", true)
-					thirdAuthMsg := listenForMsgType(msgQueueC, models.CONTENT_TYPE_AUTH)
-					pubKeyC = thirdAuthMsg.Content.(*models.AuthMessageContent).PublicKey
-					thirdClientPrivKey := thirdAuthMsg.Content.(*models.AuthMessageContent).PrivateKey
-
-					sendMsg("C", thirdClientConn, pubKeyC, thirdClientPrivKey, &models.Message{
-						ContentType: models.CONTENT_TYPE_CHALLENGE_REQUEST,
-						Content: &models.ChallengeRequestMessageContent{
-							Challenge: builders.NewChallenge(
-								pubKeyC,
-								pubKeyA,
-								true,
-								false,
-								builders.NewBlitzTimeControl(),
-								"",
-								true),
-						},
-					})
+					clientC = connectClient(nil)
+					DeferCleanup(clientC.Close)
+
+					Expect(clientC.Challenge(builders.NewChallenge(
+						clientC.PublicKey(),
+						clientA.PublicKey(),
+						true,
+						false,
+						builders.NewBlitzTimeControl(),
+						"",
+						true))).To(Succeed())
 				})
 				It("responds to client C with a challenge update msg", func() {
-					challengeUpdatedMsgToClientC := listenForMsgType(msgQueueC, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeUpdatedMsgToClientC := listenForMsgType(clientC, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeUpdatedMsgToClientC).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Challenge", PointTo(MatchAllFields(Fields{
 								"Uuid":              Not(BeNil()),
-								"ChallengerKey":     Equal(pubKeyC),
-								"ChallengedKey":     Equal(pubKeyA),
+								"ChallengerKey":     Equal(clientC.PublicKey()),
+								"ChallengedKey":     Equal(clientA.PublicKey()),
 								"IsChallengerWhite": Equal(true),
 								"IsChallengerBlack": Equal(false),
 								"TimeControl":       Equal(builders.NewBlitzTimeControl()),
 								"BotName":           BeEmpty(),
+								"IsRated":           BeFalse(),
+								"InviteToken":       BeEmpty(),
+								"StartingFEN":       Ignore(),
+								"Variant":           Ignore(),
 								"TimeCreated":       PointTo(BeTemporally("~", time.Now(), time.Second)),
 								"IsActive":          BeTrue(),
 							}))),
 						))))
 
-					challengeUpdatedMsgToClientA := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+					challengeUpdatedMsgToClientA := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 					Expect(challengeUpdatedMsgToClientA).To(Equal(challengeUpdatedMsgToClientC))
 				})
 				Describe("and client A accepts", func() {
 					BeforeEach(func() {
-						_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-						msgQueue.flush()
-						_ = listenForMsgType(msgQueueC, models.CONTENT_TYPE_CHALLENGE_UPDATED)
-						msgQueueC.flush()
-
-						sendMsg("A", conn, pubKeyA, privKeyA, &models.Message{
-							ContentType: models.CONTENT_TYPE_ACCEPT_CHALLENGE,
-							Content: &models.AcceptChallengeMessageContent{
-								ChallengerClientKey: pubKeyC,
-							},
-						})
+						_ = listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+						flush(clientA)
+						_ = listenForMsgType(clientC, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+						flush(clientC)
+
+						_ = clientA.AcceptChallenge(clientC.PublicKey())
 					})
 					It("responds to clients A & C with an inactive challenge update msg", func() {
-						challengeUpdatedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_CHALLENGE_UPDATED)
+						challengeUpdatedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_CHALLENGE_UPDATED)
 						Expect(challengeUpdatedMsgToA).To(PointTo(HaveField(
 							"Content", PointTo(HaveField(
 								"Challenge", PointTo(MatchFields(IgnoreExtras, Fields{
@@ -728,36 +567,31 @@ var _ = Describe("integration tests", func() {
 						)))
 					})
 					It("responds to clients A & C with a match creation failed msg", func() {
-						matchCreationFailedMsgToA := listenForMsgType(msgQueue, models.CONTENT_TYPE_MATCH_CREATION_FAILED)
+						matchCreationFailedMsgToA := listenForMsgType(clientA, models.CONTENT_TYPE_MATCH_CREATION_FAILED)
 						Expect(matchCreationFailedMsgToA).To(PointTo(HaveField(
 							"Content", PointTo(HaveField(
 								"Reason", Not(BeEmpty()),
 							)),
 						)))
 
-						matchCreationFailedMsgToC := listenForMsgType(msgQueueC, models.CONTENT_TYPE_MATCH_CREATION_FAILED)
+						matchCreationFailedMsgToC := listenForMsgType(clientC, models.CONTENT_TYPE_MATCH_CREATION_FAILED)
 						Expect(matchCreationFailedMsgToC).To(Equal(matchCreationFailedMsgToA))
 					})
 				})
 			})
 			Describe("and client B challenges client A", func() {
 				BeforeEach(func() {
-					sendMsg("B", connB, pubKeyB, privKeyB, &models.Message{
-						ContentType: models.CONTENT_TYPE_CHALLENGE_REQUEST,
-						Content: &models.ChallengeRequestMessageContent{
-							Challenge: builders.NewChallenge(
-								pubKeyB,
-								pubKeyA,
-								true,
-								false,
-								builders.NewBlitzTimeControl(),
-								"",
-								true),
-						},
-					})
+					_ = clientB.Challenge(builders.NewChallenge(
+						clientB.PublicKey(),
+						clientA.PublicKey(),
+						true,
+						false,
+						builders.NewBlitzTimeControl(),
+						"",
+						true))
 				})
 				It("responds to client B with a challenge request failed msg", func() {
-					challengeReqFailedMsg := listenForMsgType(msgQueueB, models.CONTENT_TYPE_CHALLENGE_REQUEST_FAILED)
+					challengeReqFailedMsg := listenForMsgType(clientB, models.CONTENT_TYPE_CHALLENGE_REQUEST_FAILED)
 					Expect(challengeReqFailedMsg).To(PointTo(HaveField(
 						"Content", PointTo(HaveField(
 							"Reason", Not(BeEmpty()),
@@ -768,43 +602,123 @@ var _ = Describe("integration tests", func() {
 		})
 	})
 
+	Describe("rest api", func() {
+		It("lists the matchmaking pools", func() {
+			c := connectClient(nil)
+			DeferCleanup(c.Close)
+			timeControl := builders.NewRapidTimeControl()
+			Expect(c.JoinMatchmaking(&models.FindMatchMessageContent{
+				TimeControl: timeControl,
+				Variant:     models.VARIANT_HORDE,
+			})).To(Succeed())
+			DeferCleanup(c.LeaveMatchmaking)
+
+			Eventually(func() []*models.PoolSize {
+				var poolSizes []*models.PoolSize
+				Expect(callApi(http.MethodGet, "/pools", nil, nil, &poolSizes)).To(Equal(http.StatusOK))
+				return poolSizes
+			}).Should(ContainElement(Equal(&models.PoolSize{
+				TimeControl: timeControl,
+				Variant:     models.VARIANT_HORDE,
+				ClientCount: 1,
+			})))
+		})
+		It("rejects unauthenticated challenges", func() {
+			challenge := builders.NewChallenge("", "some-client-key", true, false, builders.NewBlitzTimeControl(), "", true)
+			Expect(callApi(http.MethodPost, "/challenges", nil, challenge, nil)).To(Equal(http.StatusUnauthorized))
+		})
+		It("plays a match from challenge to resignation", func() {
+			clientA := connectClient(nil)
+			DeferCleanup(clientA.Close)
+			clientB := connectClient(nil)
+			DeferCleanup(clientB.Close)
+
+			match := startMatchOverApi(clientA, clientB)
+			Expect(match.BlackClientKey).To(Equal(clientB.PublicKey()))
+
+			Expect(callApi(http.MethodPost, "/matches/"+match.Uuid+"/resign", clientB.Auth(), nil, nil)).To(Equal(http.StatusNoContent))
+			Eventually(func() models.MatchResult {
+				endedMatch := &models.Match{}
+				callApi(http.MethodGet, "/matches/"+match.Uuid, nil, nil, endedMatch)
+				return endedMatch.Result
+			}).Should(Equal(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION))
+
+			var recentMatches []*models.Match
+			Expect(callApi(http.MethodGet, "/players/"+string(clientA.PublicKey())+"/matches", nil, nil, &recentMatches)).To(Equal(http.StatusOK))
+			Expect(recentMatches).To(HaveLen(1))
+			Expect(recentMatches[0].Uuid).To(Equal(match.Uuid))
+		})
+	})
+	Describe("event stream", func() {
+		It("streams match updates to an unauthenticated follower", func() {
+			clientA := connectClient(nil)
+			DeferCleanup(clientA.Close)
+			clientB := connectClient(nil)
+			DeferCleanup(clientB.Close)
+			match := startMatchOverApi(clientA, clientB)
+
+			resp, respErr := http.Get(fmt.Sprintf("http://localhost:8080/events?topic=%s", match.Topic()))
+			Expect(respErr).ToNot(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+			lines := make(chan string, 16)
+			go func() {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+
+			Expect(callApi(http.MethodPost, "/matches/"+match.Uuid+"/resign", clientA.Auth(), nil, nil)).To(Equal(http.StatusNoContent))
+			Eventually(lines).Should(Receive(HavePrefix("id: ")))
+			Eventually(lines).Should(Receive(Equal("event: MATCH_UPDATED")))
+			Eventually(lines).Should(Receive(And(HavePrefix("data: "), ContainSubstring(string(models.MATCH_RESULT_BLACK_WINS_BY_RESIGNATION)))))
+		})
+		It("requires creds to follow a private topic", func() {
+			resp, respErr := http.Get("http://localhost:8080/events?topic=challenge-some-uuid")
+			Expect(respErr).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+		It("accepts creds in the query, since an EventSource can't set headers", func() {
+			c := connectClient(nil)
+			DeferCleanup(c.Close)
+			auth := c.Auth()
+			query := url.Values{"topic": {"challenge-some-uuid"}, "clientKey": {string(auth.PublicKey)}, "token": {string(auth.PrivateKey)}}
+			resp, respErr := http.Get("http://localhost:8080/events?" + query.Encode())
+			Expect(respErr).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+		It("accepts creds in the query nowhere else", func() {
+			c := connectClient(nil)
+			DeferCleanup(c.Close)
+			auth := c.Auth()
+			query := url.Values{"clientKey": {string(auth.PublicKey)}, "token": {string(auth.PrivateKey)}}
+			challenge := builders.NewChallenge("", "some-client-key", true, false, builders.NewBlitzTimeControl(), "", true)
+			Expect(callApi(http.MethodPost, "/challenges?"+query.Encode(), nil, challenge, nil)).To(Equal(http.StatusUnauthorized))
+		})
+	})
 	// test "journeys" below
 	Describe("journeys", func() {
 		It("allows a bot to join and rejoin", func() {
-			msgQueue := newMsgQueue()
-			conn := connectClient(msgQueue, "A", true)
-			authMsgContent := listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH).Content.(*models.AuthMessageContent)
-			pubKey := authMsgContent.PublicKey
-			privKey := authMsgContent.PrivateKey
-
-			msgQueue.flush()
-			sendMsg("A", conn, pubKey, privKey, &models.Message{
-				ContentType: models.CONTENT_TYPE_UPGRADE_AUTH_REQUEST,
-				Content: &models.UpgradeAuthRequestMessageContent{
-					Role:   models.BOT,
-					Secret: botClientSecret,
-				},
-			})
+			c := connectClient(nil)
+			Expect(c.UpgradeAuth(models.BOT, botClientSecret)).To(Succeed())
+			_ = listenForMsgType(c, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
 
-			_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
-			msgQueue.flush()
+			Expect(c.Close()).To(Succeed())
 
-			Expect(conn.Close()).To(Succeed())
-
-			conn = connectClient(msgQueue, "A", true)
-			authMsgContent = listenForMsgType(msgQueue, models.CONTENT_TYPE_AUTH).Content.(*models.AuthMessageContent)
-			msgQueue.flush()
-			pubKey = authMsgContent.PublicKey
-			privKey = authMsgContent.PrivateKey
-
-			sendMsg("A", conn, pubKey, privKey, &models.Message{
-				ContentType: models.CONTENT_TYPE_UPGRADE_AUTH_REQUEST,
-				Content: &models.UpgradeAuthRequestMessageContent{
-					Role:   models.BOT,
-					Secret: botClientSecret,
-				},
-			})
-			_ = listenForMsgType(msgQueue, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
+			c = connectClient(nil)
+			defer c.Close()
+			Expect(c.UpgradeAuth(models.BOT, botClientSecret)).To(Succeed())
+			_ = listenForMsgType(c, models.CONTENT_TYPE_UPGRADE_AUTH_GRANTED)
+		})
+		It("negotiates the protocol and subscribes", func() {
+			c := connectClient(nil)
+			defer c.Close()
+			Expect(c.Subscribe("some-topic")).To(Succeed())
+			Expect(c.Welcome().ProtocolVersion).To(Equal(models.CURRENT_PROTOCOL_VERSION))
 		})
 	})
 })
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	. "github.com/CameronHonis/chess-arbitrator/models"
 	"github.com/google/uuid"
+	"strings"
 )
 
 func GenerateKeyset() (publicKey Key, privateKey Key) {
@@ -21,3 +22,26 @@ func ValidatePrivateKey(publicKey Key, privateKey Key) bool {
 	publicKeyFromPrivateKey := sha256.Sum256([]byte(privateKey))
 	return hex.EncodeToString(publicKeyFromPrivateKey[:]) == string(publicKey)
 }
+
+// BearerToken reads the private key out of an Authorization header, or "" when the header holds no bearer token
+func BearerToken(authorization string) Key {
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return ""
+	}
+	return Key(strings.TrimPrefix(authorization, "Bearer "))
+}
+
+// VetCreds vets creds sent alongside a request rather than inside a message, as the REST and gRPC APIs take them
+func VetCreds(authService AuthenticationServiceI, clientKey Key, privateKey Key) error {
+	if clientKey == "" || privateKey == "" {
+		return NewProtocolError(ERROR_CODE_UNAUTHORIZED, "missing client key or bearer token")
+	}
+	creds := &Message{
+		SenderKey:  clientKey,
+		PrivateKey: privateKey,
+	}
+	if err := authService.VetAuthInMessage(creds); err != nil {
+		return NewProtocolError(ERROR_CODE_UNAUTHORIZED, "invalid auth")
+	}
+	return nil
+}
@@ -101,6 +101,10 @@ func (am *AuthenticationService) SwitchRole(clientKey models.Key, roleName model
 		if am.SecretsManager.ValidateSecret(models.SECRET_BOT_CLIENT_SECRET, secret) != nil {
 			return fmt.Errorf("invalid secret")
 		}
+	case models.ADMIN:
+		if am.SecretsManager.ValidateSecret(models.SECRET_ADMIN_CLIENT_SECRET, secret) != nil {
+			return fmt.Errorf("invalid secret")
+		}
 	}
 
 	// assumed that role switch is permitted after this point
@@ -146,8 +150,9 @@ func (am *AuthenticationService) RefreshPrivateKey(clientKey models.Key, priKey
 }
 
 func (am *AuthenticationService) VetAuthInMessage(msg *models.Message) error {
-	isValidAuth := ValidatePrivateKey(msg.SenderKey, msg.PrivateKey)
-	if !isValidAuth {
+	// NOTE: vetted against the creds on record rather than the public key hash, since a refreshed private key no longer
+	// hashes to the public key and the key it replaced must stop working
+	if vetErr := am.VetPrivateKey(msg.SenderKey, msg.PrivateKey); vetErr != nil {
 		return fmt.Errorf("invalid auth")
 	}
 	return nil
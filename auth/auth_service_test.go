@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/auth"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/chess-arbitrator/secrets_manager"
+	. "github.com/CameronHonis/service/test_helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+)
+
+var _ = Describe("AuthenticationService", func() {
+	var authService *auth.AuthenticationService
+	var eventCatcher *EventCatcher
+	var creds *models.AuthCreds
+	BeforeEach(func() {
+		authService = auth.NewAuthenticationService(auth.NewAuthServiceConfig())
+		authService.AddDependency(secrets_manager.NewSecretsManager())
+		eventCatcher = NewEventCatcher()
+		eventCatcher.AddDependency(authService)
+		creds = authService.CreateNewClient()
+	})
+	Describe("::VetAuthInMessage", func() {
+		signedMsg := func(clientKey models.Key, priKey models.Key) *models.Message {
+			return &models.Message{
+				SenderKey:   clientKey,
+				PrivateKey:  priKey,
+				ContentType: models.CONTENT_TYPE_ECHO,
+				Content:     &models.EchoMessageContent{Message: "hi"},
+			}
+		}
+		It("accepts the issued private key", func() {
+			Expect(authService.VetAuthInMessage(signedMsg(creds.ClientKey, creds.PrivateKey))).To(Succeed())
+		})
+		It("rejects a private key that was never issued to the client", func() {
+			_, otherPriKey := auth.GenerateKeyset()
+			Expect(authService.VetAuthInMessage(signedMsg(creds.ClientKey, otherPriKey))).ToNot(Succeed())
+		})
+		It("rejects a keyset the arbitrator never issued", func() {
+			forgedKey, forgedPriKey := auth.GenerateKeyset()
+			Expect(auth.ValidatePrivateKey(forgedKey, forgedPriKey)).To(BeTrue())
+			Expect(authService.VetAuthInMessage(signedMsg(forgedKey, forgedPriKey))).ToNot(Succeed())
+		})
+		When("the private key was refreshed", func() {
+			var refreshedPriKey models.Key
+			BeforeEach(func() {
+				Expect(os.Setenv(string(models.SECRET_AUTH_KEY_MINS_TO_STALE), "0")).To(Succeed())
+				DeferCleanup(os.Unsetenv, string(models.SECRET_AUTH_KEY_MINS_TO_STALE))
+				// NOTE: events are dispatched concurrently, so the refresh waits on the client's creation to be caught first
+				Eventually(func() int {
+					return eventCatcher.EventsByVariantCount(auth.CREDS_CHANGED)
+				}).Should(Equal(1))
+				Expect(authService.RefreshPrivateKey(creds.ClientKey, creds.PrivateKey)).To(Succeed())
+				Eventually(func() int {
+					return eventCatcher.EventsByVariantCount(auth.CREDS_CHANGED)
+				}).Should(Equal(2))
+				payload := eventCatcher.LastEventByVariant(auth.CREDS_CHANGED).Payload().(*auth.CredsChangedPayload)
+				refreshedPriKey = payload.NewCreds.PrivateKey
+				Expect(refreshedPriKey).ToNot(Equal(creds.PrivateKey))
+			})
+			It("accepts the refreshed private key", func() {
+				Expect(authService.VetAuthInMessage(signedMsg(creds.ClientKey, refreshedPriKey))).To(Succeed())
+			})
+			It("rejects the private key it replaced", func() {
+				Expect(authService.VetAuthInMessage(signedMsg(creds.ClientKey, creds.PrivateKey))).ToNot(Succeed())
+			})
+		})
+	})
+})
@@ -75,6 +75,26 @@ func (b *ChallengeBuilder) WithBotName(botName string) *ChallengeBuilder {
 	return b
 }
 
+func (b *ChallengeBuilder) WithIsRated(isRated bool) *ChallengeBuilder {
+	b.challenge.IsRated = isRated
+	return b
+}
+
+func (b *ChallengeBuilder) WithVariant(variant models.Variant) *ChallengeBuilder {
+	b.challenge.Variant = variant
+	return b
+}
+
+func (b *ChallengeBuilder) WithStartingFEN(fen string) *ChallengeBuilder {
+	b.challenge.StartingFEN = fen
+	return b
+}
+
+func (b *ChallengeBuilder) WithRandomInviteToken() *ChallengeBuilder {
+	b.challenge.InviteToken = uuid.New().String()
+	return b
+}
+
 func (b *ChallengeBuilder) WithTimeCreated(timeCreated *time.Time) *ChallengeBuilder {
 	b.challenge.TimeCreated = timeCreated
 	return b
@@ -1,6 +1,7 @@
 package builders
 
 import (
+	"fmt"
 	"github.com/CameronHonis/chess"
 	"github.com/CameronHonis/chess-arbitrator/helpers"
 	"github.com/CameronHonis/chess-arbitrator/models"
@@ -27,6 +28,8 @@ func NewMatch(whiteClientKey models.Key, blackClientKey models.Key, timeControl
 
 type MatchBuilder struct {
 	match *models.Match
+	// the first client supplied setting that could not be applied, returned by FromChallenge
+	err error
 }
 
 func NewMatchBuilder() *MatchBuilder {
@@ -47,6 +50,9 @@ func (mb *MatchBuilder) WithUuid(uuid string) *MatchBuilder {
 
 func (mb *MatchBuilder) WithBoard(board *chess.Board) *MatchBuilder {
 	mb.match.Board = board
+	if board == nil {
+		return mb
+	}
 	if board.Result == chess.BOARD_RESULT_WHITE_WINS_BY_CHECKMATE {
 		mb.match.Result = models.MATCH_RESULT_WHITE_WINS_BY_CHECKMATE
 	} else if board.Result == chess.BOARD_RESULT_BLACK_WINS_BY_CHECKMATE {
@@ -105,16 +111,98 @@ func (mb *MatchBuilder) WithLastMove(move *chess.Move) *MatchBuilder {
 	return mb
 }
 
+func (mb *MatchBuilder) WithMoveHistory(moveHistory []*chess.Move) *MatchBuilder {
+	mb.match.MoveHistory = moveHistory
+	return mb
+}
+
 func (mb *MatchBuilder) WithLastMoveTime(lastMoveTime *time.Time) *MatchBuilder {
 	mb.match.LastMoveTime = lastMoveTime
 	return mb
 }
 
+func (mb *MatchBuilder) WithPlyCount(plyCount int) *MatchBuilder {
+	mb.match.PlyCount = plyCount
+	return mb
+}
+
 func (mb *MatchBuilder) WithBotName(botName string) *MatchBuilder {
 	mb.match.BotName = botName
 	return mb
 }
 
+// WithStartingFEN sets up the board from the fen. Matches from custom positions are never rated. An invalid fen is
+// recorded as the builder's error and leaves the match as it was.
+func (mb *MatchBuilder) WithStartingFEN(fen string) *MatchBuilder {
+	if fenErr := helpers.ValidateFEN(fen); fenErr != nil {
+		mb.err = fmt.Errorf("invalid starting fen %s: %s", fen, fenErr)
+		return mb
+	}
+	board, boardErr := chess.BoardFromFEN(fen)
+	if boardErr != nil {
+		mb.err = fmt.Errorf("invalid starting fen %s: %s", fen, boardErr)
+		return mb
+	}
+	mb.match.StartingFEN = fen
+	mb.match.IsRated = false
+	return mb.WithBoard(board)
+}
+
+func (mb *MatchBuilder) WithIsRated(isRated bool) *MatchBuilder {
+	isCustomPosition := mb.match.StartingFEN != "" && mb.match.Variant.IsStandard()
+	mb.match.IsRated = isRated && !isCustomPosition
+	return mb
+}
+
+// WithVariant sets the variant, drawing a random start position for chess960 matches
+func (mb *MatchBuilder) WithVariant(variant models.Variant) *MatchBuilder {
+	mb.match.Variant = variant.OrStandard()
+	if mb.match.Variant == models.VARIANT_CHESS960 {
+		return mb.WithChess960Position(helpers.Chess960PositionFromSeed(time.Now().UnixNano()))
+	}
+	if mb.match.Variant == models.VARIANT_HORDE {
+		mb.match.StartingFEN = helpers.HORDE_STARTING_FEN
+		mb.match.CurrentFEN = helpers.HORDE_STARTING_FEN
+		// NOTE: the chess lib may not accept a kingless side, in which case the match has no board and is tracked on
+		// CurrentFEN alone
+		board, boardErr := chess.BoardFromFEN(helpers.HORDE_STARTING_FEN)
+		if boardErr != nil {
+			board = nil
+		}
+		return mb.WithBoard(board)
+	}
+	return mb
+}
+
+func (mb *MatchBuilder) WithCurrentFEN(fen string) *MatchBuilder {
+	mb.match.CurrentFEN = fen
+	return mb
+}
+
+func (mb *MatchBuilder) WithCheckCounts(whiteCheckCount, blackCheckCount int) *MatchBuilder {
+	mb.match.WhiteCheckCount = whiteCheckCount
+	mb.match.BlackCheckCount = blackCheckCount
+	return mb
+}
+
+func (mb *MatchBuilder) WithChess960Position(position int) *MatchBuilder {
+	fen, fenErr := helpers.Chess960StartingFEN(position)
+	if fenErr != nil {
+		return mb
+	}
+	// NOTE: the chess lib has no chess960 castling, so its board is given no castling rights and castling is
+	// tracked separately on CurrentFEN
+	board, boardErr := chess.BoardFromFEN(helpers.WithoutCastlingRights(fen))
+	if boardErr != nil {
+		return mb
+	}
+	mb.match.Variant = models.VARIANT_CHESS960
+	mb.match.Chess960Position = position
+	mb.match.StartingFEN = fen
+	mb.match.CurrentFEN = fen
+	return mb.WithBoard(board)
+}
+
 func (mb *MatchBuilder) WithClientKeys(clientAKey models.Key, clientBKey models.Key) *MatchBuilder {
 	clientAIsWhite := helpers.RandomBool()
 	var whiteClientKey, blackClientKey models.Key
@@ -134,6 +222,9 @@ func (mb *MatchBuilder) WithResult(result models.MatchResult) *MatchBuilder {
 	mb.match.Result = result
 
 	setBoardResult := func(result chess.BoardResult) {
+		if mb.match.Board == nil {
+			return
+		}
 		mb.match.Board = chess.NewBoardBuilder().FromBoard(mb.match.Board).WithResult(result).Build()
 	}
 	switch result {
@@ -155,7 +246,8 @@ func (mb *MatchBuilder) WithResult(result models.MatchResult) *MatchBuilder {
 	return mb
 }
 
-func (mb *MatchBuilder) FromChallenge(challenge *models.Challenge) *MatchBuilder {
+// FromChallenge sets up the match the challenge asks for, returning an error if any of its settings can't be applied
+func (mb *MatchBuilder) FromChallenge(challenge *models.Challenge) (*MatchBuilder, error) {
 	mb.match = NewMatch(challenge.ChallengerKey, challenge.ChallengedKey, challenge.TimeControl, models.MATCH_RESULT_IN_PROGRESS)
 	if challenge.IsChallengerWhite {
 		mb.WithWhiteClientKey(challenge.ChallengerKey)
@@ -167,7 +259,12 @@ func (mb *MatchBuilder) FromChallenge(challenge *models.Challenge) *MatchBuilder
 		mb.WithClientKeys(challenge.ChallengerKey, challenge.ChallengedKey)
 	}
 	mb.WithBotName(challenge.BotName)
-	return mb
+	mb.WithVariant(challenge.Variant)
+	if challenge.StartingFEN != "" {
+		mb.WithStartingFEN(challenge.StartingFEN)
+	}
+	mb.WithIsRated(challenge.IsRated)
+	return mb, mb.err
 }
 
 func (mb *MatchBuilder) FromMatch(match *models.Match) *MatchBuilder {
@@ -3,6 +3,7 @@ package builders_test
 import (
 	"github.com/CameronHonis/chess"
 	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
 	"github.com/CameronHonis/chess-arbitrator/models"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -173,4 +174,61 @@ var _ = Describe("MatchBuilder", func() {
 			})
 		})
 	})
+	Describe("FromChallenge", func() {
+		var challenge *models.Challenge
+		BeforeEach(func() {
+			challenge = builders.NewChallenge("challenger", "challenged", true, false, builders.NewBlitzTimeControl(), "", true)
+			challenge.IsRated = true
+		})
+		buildFromChallenge := func() *models.Match {
+			matchBuilder, buildErr := builders.NewMatchBuilder().FromChallenge(challenge)
+			Expect(buildErr).ToNot(HaveOccurred())
+			return matchBuilder.Build()
+		}
+		It("carries the rated flag over to the match", func() {
+			newMatch := buildFromChallenge()
+			Expect(newMatch.IsRated).To(BeTrue())
+		})
+		When("the challenge has a starting fen", func() {
+			var fen string
+			BeforeEach(func() {
+				fen = "k7/8/8/8/8/8/8/K5Q1 w - - 0 1"
+				challenge.StartingFEN = fen
+			})
+			It("records the fen on the match", func() {
+				newMatch := buildFromChallenge()
+				Expect(newMatch.StartingFEN).To(Equal(fen))
+			})
+			It("starts the board from the fen", func() {
+				expBoard, _ := chess.BoardFromFEN(fen)
+				newMatch := buildFromChallenge()
+				Expect(newMatch.Board).To(Equal(expBoard))
+			})
+			It("is never rated", func() {
+				newMatch := buildFromChallenge()
+				Expect(newMatch.IsRated).To(BeFalse())
+			})
+			It("returns an error rather than falling back to a standard game when the fen is invalid", func() {
+				challenge.StartingFEN = "not a fen"
+				_, buildErr := builders.NewMatchBuilder().FromChallenge(challenge)
+				Expect(buildErr).To(HaveOccurred())
+			})
+		})
+		When("the challenge is for chess960", func() {
+			BeforeEach(func() {
+				challenge.Variant = models.VARIANT_CHESS960
+			})
+			It("starts from a chess960 position", func() {
+				newMatch := buildFromChallenge()
+				expFEN, _ := helpers.Chess960StartingFEN(newMatch.Chess960Position)
+				Expect(newMatch.Variant).To(Equal(models.VARIANT_CHESS960))
+				Expect(newMatch.StartingFEN).To(Equal(expFEN))
+				Expect(newMatch.CurrentFEN).To(Equal(expFEN))
+			})
+			It("stays rated", func() {
+				newMatch := buildFromChallenge()
+				Expect(newMatch.IsRated).To(BeTrue())
+			})
+		})
+	})
 })
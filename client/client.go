@@ -0,0 +1,445 @@
+package client
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/gorilla/websocket"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Client speaks the arbitrator's websocket protocol for Go bots and tools. It authenticates on connect, ties every
+// request to its ACK or ERROR reply, and redials with the same creds and subscriptions when the connection drops.
+type Client struct {
+	config *ClientConfig
+
+	conn    *websocket.Conn
+	auth    *models.AuthMessageContent
+	welcome *models.WelcomeMessageContent
+	topics  []models.MessageTopic
+
+	pendingByRequestId map[string]chan *models.Message
+	lastRequestId      int
+	authCh             chan *models.AuthMessageContent
+
+	messages         chan *models.Message
+	matchUpdates     chan *models.Match
+	challengeUpdates chan *models.Challenge
+
+	isClosed       bool
+	isReconnecting bool
+	done           chan struct{}
+	doneOnce       sync.Once
+	err            error
+	mu             sync.Mutex
+	writeMu        sync.Mutex
+}
+
+// Connect dials the arbitrator and waits until the client is authenticated and has shaken hands
+func Connect(config *ClientConfig) (*Client, error) {
+	c := &Client{
+		config:             config,
+		auth:               config.Auth,
+		topics:             make([]models.MessageTopic, 0),
+		pendingByRequestId: make(map[string]chan *models.Message),
+		authCh:             make(chan *models.AuthMessageContent, 1),
+		messages:           make(chan *models.Message, config.EventBufferSize),
+		matchUpdates:       make(chan *models.Match, config.EventBufferSize),
+		challengeUpdates:   make(chan *models.Challenge, config.EventBufferSize),
+		done:               make(chan struct{}),
+	}
+	if err := c.dial(); err != nil {
+		return nil, err
+	}
+	if err := c.handshake(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) Auth() *models.AuthMessageContent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.auth
+}
+
+func (c *Client) PublicKey() models.Key {
+	if auth := c.Auth(); auth != nil {
+		return auth.PublicKey
+	}
+	return ""
+}
+
+func (c *Client) Welcome() *models.WelcomeMessageContent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.welcome
+}
+
+// Messages receives every message from the arbitrator, including those also sent to the typed channels
+func (c *Client) Messages() <-chan *models.Message {
+	return c.messages
+}
+
+func (c *Client) MatchUpdates() <-chan *models.Match {
+	return c.matchUpdates
+}
+
+func (c *Client) ChallengeUpdates() <-chan *models.Challenge {
+	return c.challengeUpdates
+}
+
+// Done is closed once the client is closed or gives up reconnecting, after which Err reports why
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.isClosed = true
+	conn := c.conn
+	c.mu.Unlock()
+
+	c.shutdown(fmt.Errorf("client closed"))
+	return conn.Close()
+}
+
+func (c *Client) JoinMatchmaking(content *models.FindMatchMessageContent) error {
+	return c.Request(models.CONTENT_TYPE_JOIN_MATCHMAKING, content)
+}
+
+func (c *Client) LeaveMatchmaking() error {
+	return c.Request(models.CONTENT_TYPE_LEAVE_MATCHMAKING, &models.NoMessageContent{})
+}
+
+func (c *Client) Challenge(challenge *models.Challenge) error {
+	return c.Request(models.CONTENT_TYPE_CHALLENGE_REQUEST, &models.ChallengeRequestMessageContent{
+		Challenge: challenge,
+	})
+}
+
+func (c *Client) AcceptChallenge(challengerKey models.Key) error {
+	return c.Request(models.CONTENT_TYPE_ACCEPT_CHALLENGE, &models.AcceptChallengeMessageContent{
+		ChallengerClientKey: challengerKey,
+	})
+}
+
+func (c *Client) DeclineChallenge(challengerKey models.Key) error {
+	return c.Request(models.CONTENT_TYPE_DECLINE_CHALLENGE, &models.DeclineChallengeMessageContent{
+		ChallengerClientKey: challengerKey,
+	})
+}
+
+func (c *Client) RevokeChallenge(challengedKey models.Key) error {
+	return c.Request(models.CONTENT_TYPE_REVOKE_CHALLENGE, &models.RevokeChallengeMessageContent{
+		ChallengedClientKey: challengedKey,
+	})
+}
+
+func (c *Client) Move(matchId string, move *chess.Move) error {
+	return c.Request(models.CONTENT_TYPE_MOVE, &models.MoveMessageContent{
+		MatchId: matchId,
+		Move:    move,
+	})
+}
+
+func (c *Client) Resign(matchId string) error {
+	return c.Request(models.CONTENT_TYPE_RESIGN_MATCH, &models.ResignMessageContent{
+		MatchId: matchId,
+	})
+}
+
+func (c *Client) UpgradeAuth(role models.RoleName, secret string) error {
+	return c.Request(models.CONTENT_TYPE_UPGRADE_AUTH_REQUEST, &models.UpgradeAuthRequestMessageContent{
+		Role:   role,
+		Secret: secret,
+	})
+}
+
+// Subscribe subscribes to the topic, and again on every reconnect
+func (c *Client) Subscribe(topic models.MessageTopic) error {
+	if err := c.subscribe(topic); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, subbedTopic := range c.topics {
+		if subbedTopic == topic {
+			return nil
+		}
+	}
+	c.topics = append(c.topics, topic)
+	return nil
+}
+
+// Request sends a message and waits for the arbitrator to ACK it. A failed request returns the *models.ProtocolError
+// the arbitrator replied with.
+func (c *Client) Request(contentType models.ContentType, content interface{}) error {
+	c.mu.Lock()
+	c.lastRequestId++
+	requestId := strconv.Itoa(c.lastRequestId)
+	replyCh := make(chan *models.Message, 1)
+	c.pendingByRequestId[requestId] = replyCh
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pendingByRequestId, requestId)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(&models.Message{
+		ContentType: contentType,
+		Content:     content,
+		RequestId:   requestId,
+	}); err != nil {
+		return err
+	}
+
+	select {
+	case reply, ok := <-replyCh:
+		if !ok {
+			return fmt.Errorf("connection lost before %s was acknowledged", contentType)
+		}
+		if errContent, isErr := reply.Content.(*models.ErrorMessageContent); isErr {
+			return &models.ProtocolError{Code: errContent.Code, Message: errContent.Message}
+		}
+		return nil
+	case <-time.After(c.config.RequestTimeout):
+		return fmt.Errorf("timed out waiting for %s to be acknowledged", contentType)
+	}
+}
+
+func (c *Client) send(msg *models.Message) error {
+	c.mu.Lock()
+	conn := c.conn
+	if c.auth != nil {
+		msg.SenderKey = c.auth.PublicKey
+		msg.PrivateKey = c.auth.PrivateKey
+	}
+	c.mu.Unlock()
+
+	msgJson, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return conn.WriteMessage(websocket.TextMessage, msgJson)
+}
+
+func (c *Client) dial() error {
+	conn, _, err := websocket.DefaultDialer.Dial(c.config.Url, nil)
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %s", c.config.Url, err)
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	go c.listenOnConn(conn)
+	return nil
+}
+
+// handshake authenticates the current connection with the stored creds, negotiates the protocol and restores
+// subscriptions
+func (c *Client) handshake() error {
+	if err := c.refreshAuth(); err != nil {
+		return err
+	}
+	if err := c.Request(models.CONTENT_TYPE_HELLO, &models.HelloMessageContent{
+		ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+		Features:        withRequestIds(c.config.Features),
+	}); err != nil {
+		return fmt.Errorf("handshake failed: %s", err)
+	}
+	if !hasFeature(c.Welcome(), models.FEATURE_REQUEST_IDS) {
+		return fmt.Errorf("handshake failed: the arbitrator did not agree to %s, requests would never be acknowledged", models.FEATURE_REQUEST_IDS)
+	}
+	c.mu.Lock()
+	topics := append(make([]models.MessageTopic, 0, len(c.topics)), c.topics...)
+	c.mu.Unlock()
+	for _, topic := range topics {
+		if err := c.subscribe(topic); err != nil {
+			return fmt.Errorf("could not resubscribe to %s: %s", topic, err)
+		}
+	}
+	return nil
+}
+
+// refreshAuth authenticates the current connection with the stored creds. A rejected refresh returns the
+// *models.ProtocolError the arbitrator replied with straight away.
+func (c *Client) refreshAuth() error {
+	select {
+	case <-c.authCh:
+	default:
+	}
+	if err := c.Request(models.CONTENT_TYPE_REFRESH_AUTH, &models.RefreshAuthMessageContent{ExistingAuth: c.Auth()}); err != nil {
+		return err
+	}
+	// NOTE: the creds come in their own AUTH message, which may arrive on either side of the ACK
+	select {
+	case <-c.authCh:
+		return nil
+	case <-time.After(c.config.RequestTimeout):
+		return fmt.Errorf("timed out waiting for auth")
+	}
+}
+
+func (c *Client) subscribe(topic models.MessageTopic) error {
+	return c.Request(models.CONTENT_TYPE_SUBSCRIBE_REQUEST, &models.SubscribeRequestMessageContent{
+		Topic: topic,
+	})
+}
+
+func (c *Client) listenOnConn(conn *websocket.Conn) {
+	for {
+		_, rawMsg, readErr := conn.ReadMessage()
+		if readErr != nil {
+			c.onConnLost(conn, readErr)
+			return
+		}
+		msg, unmarshalErr := models.UnmarshalToMessage(rawMsg)
+		if unmarshalErr != nil {
+			continue
+		}
+		c.handleMsg(msg)
+	}
+}
+
+func (c *Client) handleMsg(msg *models.Message) {
+	switch content := msg.Content.(type) {
+	case *models.AuthMessageContent:
+		// NOTE: the arbitrator may rotate the private key at any time, not only in reply to REFRESH_AUTH
+		c.mu.Lock()
+		c.auth = content
+		c.mu.Unlock()
+		select {
+		case c.authCh <- content:
+		default:
+		}
+	case *models.WelcomeMessageContent:
+		c.mu.Lock()
+		c.welcome = content
+		c.mu.Unlock()
+	case *models.AckMessageContent, *models.ErrorMessageContent:
+		c.mu.Lock()
+		replyCh, ok := c.pendingByRequestId[msg.RequestId]
+		c.mu.Unlock()
+		if ok {
+			select {
+			case replyCh <- msg:
+			default:
+			}
+		}
+	case *models.MatchUpdateMessageContent:
+		publish(c.matchUpdates, content.Match)
+	case *models.ChallengeUpdatedMessageContent:
+		publish(c.challengeUpdates, content.Challenge)
+	}
+	publish(c.messages, msg)
+}
+
+// onConnLost fails the requests waiting on the lost connection, then redials with backoff until the handshake
+// succeeds or the attempts run out
+func (c *Client) onConnLost(conn *websocket.Conn, cause error) {
+	c.mu.Lock()
+	if c.isClosed || c.isReconnecting || c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.isReconnecting = true
+	for requestId, replyCh := range c.pendingByRequestId {
+		close(replyCh)
+		delete(c.pendingByRequestId, requestId)
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.isReconnecting = false
+		c.mu.Unlock()
+	}()
+
+	backoff := c.config.ReconnectBackoff
+	for attempt := 0; attempt < c.config.MaxReconnectAttempts; attempt++ {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > c.config.MaxReconnectBackoff {
+			backoff = c.config.MaxReconnectBackoff
+		}
+		c.mu.Lock()
+		isClosed := c.isClosed
+		c.mu.Unlock()
+		if isClosed {
+			return
+		}
+		if dialErr := c.dial(); dialErr != nil {
+			continue
+		}
+		if handshakeErr := c.handshake(); handshakeErr != nil {
+			_ = c.currentConn().Close()
+			continue
+		}
+		return
+	}
+	c.mu.Lock()
+	c.isClosed = true
+	c.mu.Unlock()
+	c.shutdown(fmt.Errorf("connection lost: %s", cause))
+}
+
+func (c *Client) currentConn() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+func (c *Client) shutdown(err error) {
+	c.doneOnce.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
+
+// publish hands the event to the channel without blocking, so a consumer that stops reading never stalls the
+// connection
+func publish[T any](ch chan T, event T) {
+	select {
+	case ch <- event:
+	default:
+	}
+}
+
+// withRequestIds adds FEATURE_REQUEST_IDS, without which the arbitrator never acknowledges requests
+func withRequestIds(features []models.Feature) []models.Feature {
+	for _, feature := range features {
+		if feature == models.FEATURE_REQUEST_IDS {
+			return features
+		}
+	}
+	return append(append(make([]models.Feature, 0, len(features)+1), features...), models.FEATURE_REQUEST_IDS)
+}
+
+func hasFeature(welcome *models.WelcomeMessageContent, feature models.Feature) bool {
+	if welcome == nil {
+		return false
+	}
+	for _, f := range welcome.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"time"
+)
+
+type ClientConfig struct {
+	Url string
+	// creds from a previous session, which are refreshed rather than replaced when still valid
+	Auth *models.AuthMessageContent
+	// features to request in the HELLO handshake, FEATURE_REQUEST_IDS is always requested since Request relies on it
+	Features []models.Feature
+	// how long to wait on a reply before giving up on a request
+	RequestTimeout time.Duration
+	// how many times to redial after losing the connection, or 0 to never reconnect
+	MaxReconnectAttempts int
+	// the wait before the first redial, doubled after every failed attempt up to MaxReconnectBackoff
+	ReconnectBackoff    time.Duration
+	MaxReconnectBackoff time.Duration
+	// how many unread events each channel holds before further events are dropped
+	EventBufferSize int
+}
+
+func NewClientConfig(url string) *ClientConfig {
+	return &ClientConfig{
+		Url:                  url,
+		Features:             models.AllFeatures(),
+		RequestTimeout:       5 * time.Second,
+		MaxReconnectAttempts: 10,
+		ReconnectBackoff:     200 * time.Millisecond,
+		MaxReconnectBackoff:  5 * time.Second,
+		EventBufferSize:      256,
+	}
+}
+
+type ClientConfigBuilder struct {
+	config *ClientConfig
+}
+
+func NewClientConfigBuilder(url string) *ClientConfigBuilder {
+	return &ClientConfigBuilder{
+		config: NewClientConfig(url),
+	}
+}
+
+func (b *ClientConfigBuilder) WithAuth(auth *models.AuthMessageContent) *ClientConfigBuilder {
+	b.config.Auth = auth
+	return b
+}
+
+func (b *ClientConfigBuilder) WithFeatures(features []models.Feature) *ClientConfigBuilder {
+	b.config.Features = features
+	return b
+}
+
+func (b *ClientConfigBuilder) WithRequestTimeout(timeout time.Duration) *ClientConfigBuilder {
+	b.config.RequestTimeout = timeout
+	return b
+}
+
+func (b *ClientConfigBuilder) WithReconnect(maxAttempts int, backoff time.Duration, maxBackoff time.Duration) *ClientConfigBuilder {
+	b.config.MaxReconnectAttempts = maxAttempts
+	b.config.ReconnectBackoff = backoff
+	b.config.MaxReconnectBackoff = maxBackoff
+	return b
+}
+
+func (b *ClientConfigBuilder) WithEventBufferSize(size int) *ClientConfigBuilder {
+	b.config.EventBufferSize = size
+	return b
+}
+
+func (b *ClientConfigBuilder) Build() *ClientConfig {
+	return b.config
+}
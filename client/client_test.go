@@ -0,0 +1,207 @@
+package client_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/client"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeArbitrator authenticates every connection as the same client, ACKs every request and records what it received
+type fakeArbitrator struct {
+	server   *httptest.Server
+	received []*models.Message
+	conns    []*websocket.Conn
+	// refuses every REFRESH_AUTH with this error, when set
+	refreshErr *models.ErrorMessageContent
+	mu         sync.Mutex
+}
+
+func newFakeArbitrator() *fakeArbitrator {
+	f := &fakeArbitrator{}
+	upgrader := websocket.Upgrader{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.mu.Unlock()
+		go f.listenOnConn(conn)
+	}))
+	return f
+}
+
+func (f *fakeArbitrator) url() string {
+	return "ws" + strings.TrimPrefix(f.server.URL, "http")
+}
+
+func (f *fakeArbitrator) listenOnConn(conn *websocket.Conn) {
+	for {
+		_, rawMsg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg, _ := models.UnmarshalToMessage(rawMsg)
+		f.mu.Lock()
+		f.received = append(f.received, msg)
+		f.mu.Unlock()
+
+		switch content := msg.Content.(type) {
+		case *models.RefreshAuthMessageContent:
+			f.mu.Lock()
+			refreshErr := f.refreshErr
+			f.mu.Unlock()
+			if refreshErr != nil {
+				f.write(conn, msg.RequestId, models.CONTENT_TYPE_ERROR, refreshErr)
+				continue
+			}
+			f.write(conn, "", models.CONTENT_TYPE_AUTH, &models.AuthMessageContent{PublicKey: "client-key", PrivateKey: "private-key"})
+		case *models.HelloMessageContent:
+			f.write(conn, msg.RequestId, models.CONTENT_TYPE_WELCOME, &models.WelcomeMessageContent{ProtocolVersion: content.ProtocolVersion, Features: content.Features})
+		case *models.MoveMessageContent:
+			if content.MatchId == "missing-match" {
+				f.write(conn, msg.RequestId, models.CONTENT_TYPE_ERROR, &models.ErrorMessageContent{Code: models.ERROR_CODE_NOT_FOUND, Message: "no match with id missing-match"})
+				continue
+			}
+		case *models.ResignMessageContent:
+			f.write(conn, "", models.CONTENT_TYPE_MATCH_UPDATED, &models.MatchUpdateMessageContent{Match: &models.Match{Uuid: content.MatchId, Result: models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION}})
+		}
+		f.write(conn, msg.RequestId, models.CONTENT_TYPE_ACK, &models.AckMessageContent{RequestContentType: msg.ContentType})
+	}
+}
+
+func (f *fakeArbitrator) write(conn *websocket.Conn, requestId string, contentType models.ContentType, content interface{}) {
+	msgJson, _ := (&models.Message{ContentType: contentType, Content: content, RequestId: requestId}).Marshal()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = conn.WriteMessage(websocket.TextMessage, msgJson)
+}
+
+func (f *fakeArbitrator) dropConns() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeArbitrator) receivedOfType(contentType models.ContentType) []*models.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msgs := make([]*models.Message, 0)
+	for _, msg := range f.received {
+		if msg.ContentType == contentType {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+var _ = Describe("Client", func() {
+	var arbitrator *fakeArbitrator
+	var c *client.Client
+	BeforeEach(func() {
+		arbitrator = newFakeArbitrator()
+		config := client.NewClientConfigBuilder(arbitrator.url()).
+			WithRequestTimeout(time.Second).
+			WithReconnect(5, 10*time.Millisecond, 50*time.Millisecond).
+			Build()
+		var err error
+		c, err = client.Connect(config)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		_ = c.Close()
+		arbitrator.server.Close()
+	})
+	Describe("Connect", func() {
+		It("authenticates", func() {
+			Expect(c.PublicKey()).To(Equal(models.Key("client-key")))
+		})
+		It("shakes hands on the current protocol version", func() {
+			Expect(c.Welcome()).ToNot(BeNil())
+			Expect(c.Welcome().ProtocolVersion).To(Equal(models.CURRENT_PROTOCOL_VERSION))
+		})
+	})
+	Describe("requests", func() {
+		It("signs requests with the creds", func() {
+			Expect(c.Resign("some-match")).To(Succeed())
+			resignMsg := arbitrator.receivedOfType(models.CONTENT_TYPE_RESIGN_MATCH)[0]
+			Expect(resignMsg.SenderKey).To(Equal(models.Key("client-key")))
+			Expect(resignMsg.PrivateKey).To(Equal(models.Key("private-key")))
+		})
+		When("the arbitrator replies with an error", func() {
+			It("returns the protocol error", func() {
+				err := c.Move("missing-match", nil)
+				Expect(err).To(Equal(&models.ProtocolError{Code: models.ERROR_CODE_NOT_FOUND, Message: "no match with id missing-match"}))
+			})
+		})
+	})
+	Describe("events", func() {
+		It("publishes match updates", func() {
+			Expect(c.Resign("some-match")).To(Succeed())
+			var match *models.Match
+			Eventually(c.MatchUpdates()).Should(Receive(&match))
+			Expect(match.Uuid).To(Equal("some-match"))
+		})
+		It("publishes every message", func() {
+			Expect(c.Resign("some-match")).To(Succeed())
+			Eventually(c.Messages()).Should(Receive(HaveField("ContentType", models.CONTENT_TYPE_MATCH_UPDATED)))
+		})
+	})
+	When("the connection drops", func() {
+		BeforeEach(func() {
+			Expect(c.Subscribe("some-topic")).To(Succeed())
+			arbitrator.dropConns()
+		})
+		It("reconnects with the stored creds", func() {
+			Eventually(func() int {
+				return len(arbitrator.receivedOfType(models.CONTENT_TYPE_REFRESH_AUTH))
+			}).Should(Equal(2))
+			refreshMsg := arbitrator.receivedOfType(models.CONTENT_TYPE_REFRESH_AUTH)[1]
+			Expect(refreshMsg.Content.(*models.RefreshAuthMessageContent).ExistingAuth).To(Equal(&models.AuthMessageContent{
+				PublicKey:  "client-key",
+				PrivateKey: "private-key",
+			}))
+		})
+		It("resubscribes", func() {
+			Eventually(func() int {
+				return len(arbitrator.receivedOfType(models.CONTENT_TYPE_SUBSCRIBE_REQUEST))
+			}).Should(Equal(2))
+		})
+		It("keeps serving requests", func() {
+			Eventually(func() error {
+				return c.Resign("some-match")
+			}).Should(Succeed())
+		})
+	})
+	When("the arbitrator refuses the creds", func() {
+		It("fails the connect with the arbitrator's error instead of timing out", func() {
+			refusingArbitrator := newFakeArbitrator()
+			defer refusingArbitrator.server.Close()
+			refusingArbitrator.refreshErr = &models.ErrorMessageContent{Code: models.ERROR_CODE_UNAUTHORIZED, Message: "invalid auth"}
+			config := client.NewClientConfigBuilder(refusingArbitrator.url()).WithRequestTimeout(5 * time.Second).Build()
+			startedAt := time.Now()
+			_, err := client.Connect(config)
+			Expect(err).To(Equal(&models.ProtocolError{Code: models.ERROR_CODE_UNAUTHORIZED, Message: "invalid auth"}))
+			Expect(time.Since(startedAt)).To(BeNumerically("<", time.Second))
+		})
+	})
+	When("the arbitrator stays down", func() {
+		It("gives up after the reconnect attempts run out", func() {
+			arbitrator.server.Close()
+			arbitrator.dropConns()
+			Eventually(c.Done()).Should(BeClosed())
+			Expect(c.Err()).To(HaveOccurred())
+		})
+	})
+})
@@ -10,7 +10,7 @@ import (
 func HandleEchoMessage(m *ClientsManager, msg *models.Message) error {
 	_, ok := msg.Content.(*models.EchoMessageContent)
 	if !ok {
-		return fmt.Errorf("could not cast message to EchoMessageContent")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message to EchoMessageContent")
 	}
 	return m.DirectMessage(msg, msg.SenderKey)
 }
@@ -18,7 +18,7 @@ func HandleEchoMessage(m *ClientsManager, msg *models.Message) error {
 func HandleRefreshAuthMessage(c *ClientsManager, msg *models.Message, conn *websocket.Conn) (models.Key, error) {
 	refreshAuthMsg, ok := msg.Content.(*models.RefreshAuthMessageContent)
 	if !ok {
-		return "", fmt.Errorf("invalid message content %s, expected REFRESH_AUTH_MESSAGE_CONTENT", msg)
+		return "", models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid message content %s, expected REFRESH_AUTH_MESSAGE_CONTENT", msg)
 	}
 	existingAuth := refreshAuthMsg.ExistingAuth
 
@@ -34,10 +34,14 @@ func HandleRefreshAuthMessage(c *ClientsManager, msg *models.Message, conn *webs
 	if clientKey == "" {
 		clientKey = c.AuthService.CreateNewClient().ClientKey
 	}
+	// NOTE: a client reconnecting after a half-open drop takes over from its stale connection, rather than waiting for
+	// the heartbeat to notice it's gone
+	if staleConn, _ := c.getConnByKey(clientKey); staleConn != nil && staleConn != conn {
+		c.dropStaleConn(clientKey, staleConn)
+	}
 
-	registerErr := c.registerConn(clientKey, conn)
-	if registerErr != nil {
-		c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("could not register WS conn to key %s", clientKey))
+	if registerErr := c.registerConn(clientKey, conn); registerErr != nil {
+		return "", models.NewProtocolError(models.ERROR_CODE_INVALID_STATE, "could not register connection for client %s: %s", clientKey, registerErr)
 	}
 
 	match, matchErr := c.MatcherService.MatchByClientKey(clientKey)
@@ -68,26 +72,38 @@ func HandleRefreshAuthMessage(c *ClientsManager, msg *models.Message, conn *webs
 func HandleJoinMatchmakingMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.FindMatchMessageContent)
 	if !ok {
-		return fmt.Errorf("could not cast message content to FindMatchMessageContent")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message content to FindMatchMessageContent")
 	}
 
-	// TODO: query for elo, winStreak, lossStreak
-	return m.MatchmakingService.AddClient(&models.ClientProfile{
-		ClientKey:  msg.SenderKey,
-		Elo:        1000,
-		WinStreak:  0,
-		LossStreak: 0,
-	}, msgContent.TimeControl)
+	joinErr := m.MatchmakingService.JoinMatchmaking(msg.SenderKey, msgContent)
+	if joinErr != nil {
+		sendDeps := m.directReplyDeps(msg.SenderKey, msg.RequestId)
+		_ = SendMatchmakingJoinFailed(sendDeps, joinErr.Error())
+	}
+	return joinErr
 }
 
 func HandleLeaveMatchmakingMessage(m *ClientsManager, msg *models.Message) error {
-	return m.MatchmakingService.RemoveClient(msg.SenderKey)
+	return m.MatchmakingService.RemoveClient(msg.SenderKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)
+}
+
+func HandleCreatePartyMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.CreatePartyMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message content to CreatePartyMessageContent")
+	}
+	_, createErr := m.MatchmakingService.CreateParty(msg.SenderKey, msgContent.Mode, msgContent.TimeControl, msgContent.Variant, msgContent.IsRated)
+	return createErr
+}
+
+func HandleLeavePartyMessage(m *ClientsManager, msg *models.Message) error {
+	return m.MatchmakingService.LeaveParty(msg.SenderKey)
 }
 
 func HandleSubscribeRequestMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.SubscribeRequestMessageContent)
 	if !ok {
-		return fmt.Errorf("could not cast message to SubscribeRequestMessageContent")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message to SubscribeRequestMessageContent")
 	}
 	subErr := m.SubService.SubClient(msg.SenderKey, msgContent.Topic)
 	return subErr
@@ -96,7 +112,7 @@ func HandleSubscribeRequestMessage(m *ClientsManager, msg *models.Message) error
 func HandleRequestUpgradeAuthMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.UpgradeAuthRequestMessageContent)
 	if !ok {
-		return fmt.Errorf("could not cast message to UpgradeAuthRequestMessageContent")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message to UpgradeAuthRequestMessageContent")
 	}
 	return m.AuthService.SwitchRole(msg.SenderKey, msgContent.Role, msgContent.Secret)
 }
@@ -104,12 +120,15 @@ func HandleRequestUpgradeAuthMessage(m *ClientsManager, msg *models.Message) err
 func HandleMoveMessage(m *ClientsManager, moveMsg *models.Message) error {
 	moveMsgContent, ok := moveMsg.Content.(*models.MoveMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid move message content")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid move message content")
+	}
+	if _, matchErr := m.MatcherService.MatchById(moveMsgContent.MatchId); matchErr != nil {
+		return models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, matchErr.Error())
 	}
 	moveErr := m.MatcherService.ExecuteMove(moveMsgContent.MatchId, moveMsgContent.Move)
 	if moveErr != nil {
 		go m.Dispatch(matcher.NewMoveFailureEvent(moveMsgContent.MatchId, moveMsgContent.Move, moveMsg.SenderKey, moveErr.Error()))
-		return nil
+		return moveErr
 	}
 	return nil
 }
@@ -117,7 +136,10 @@ func HandleMoveMessage(m *ClientsManager, moveMsg *models.Message) error {
 func HandleResignMessage(m *ClientsManager, resignMsg *models.Message) error {
 	resignMsgContent, ok := resignMsg.Content.(*models.ResignMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid resign message content")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid resign message content")
+	}
+	if _, matchErr := m.MatcherService.MatchById(resignMsgContent.MatchId); matchErr != nil {
+		return models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, matchErr.Error())
 	}
 	return m.MatcherService.ResignMatch(resignMsgContent.MatchId, resignMsg.SenderKey)
 }
@@ -125,47 +147,117 @@ func HandleResignMessage(m *ClientsManager, resignMsg *models.Message) error {
 func HandleChallengePlayerMessage(m *ClientsManager, challengeMsg *models.Message) error {
 	challengeMsgContent, ok := challengeMsg.Content.(*models.ChallengeRequestMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid challenge message content")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid challenge message content")
 	}
-	challengeErr := m.MatcherService.RequestChallenge(challengeMsgContent.Challenge)
-	if challengeErr != nil {
-		return challengeErr
-	}
-	return nil
+	return m.MatcherService.RequestChallenge(challengeMsgContent.Challenge)
 }
 
 func HandleAcceptChallengeMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.AcceptChallengeMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid accept challenge message content")
-	}
-	acceptChallengeErr := m.MatcherService.AcceptChallenge(msgContent.ChallengerClientKey, msg.SenderKey)
-	if acceptChallengeErr != nil {
-		return nil
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid accept challenge message content")
 	}
-	return nil
+	return m.MatcherService.AcceptChallenge(msgContent.ChallengerClientKey, msg.SenderKey)
 }
 
 func HandleDeclineChallengeMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.DeclineChallengeMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid decline challenge message content")
-	}
-	declineChallengeErr := m.MatcherService.DeclineChallenge(msgContent.ChallengerClientKey, msg.SenderKey)
-	if declineChallengeErr != nil {
-		m.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("could not decline challenge: %s", declineChallengeErr))
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid decline challenge message content")
 	}
-	return nil
+	return m.MatcherService.DeclineChallenge(msgContent.ChallengerClientKey, msg.SenderKey)
 }
 
 func HandleRevokeChallengeMessage(m *ClientsManager, msg *models.Message) error {
 	msgContent, ok := msg.Content.(*models.RevokeChallengeMessageContent)
 	if !ok {
-		return fmt.Errorf("invalid revoke challenge message content")
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid revoke challenge message content")
+	}
+	return m.MatcherService.RevokeChallenge(msg.SenderKey, msgContent.ChallengedClientKey)
+}
+
+func HandleSetChallengePolicyMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.SetChallengePolicyMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid set challenge policy message content")
 	}
-	revokeChallengeErr := m.MatcherService.RevokeChallenge(msg.SenderKey, msgContent.ChallengedClientKey)
-	if revokeChallengeErr != nil {
-		m.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("could not revoke challenge: %s", revokeChallengeErr))
+	return m.MatcherService.SetChallengePolicy(msg.SenderKey, msgContent.Policy)
+}
+
+func HandleConfirmMatchMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.ConfirmMatchMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid confirm match message content")
+	}
+	return m.MatchmakingService.ConfirmMatch(msg.SenderKey, msgContent.ReadyCheckId)
+}
+
+func HandleSetAvoidListMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.SetAvoidListMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid set avoid list message content")
 	}
+	m.MatchmakingService.SetAvoidList(msg.SenderKey, msgContent.AvoidedKeys)
 	return nil
 }
+
+func HandleInviteChallengeMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.InviteChallengeRequestMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid invite challenge message content")
+	}
+	if msgContent.Challenge == nil {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invite challenge message missing challenge")
+	}
+	challenge := *msgContent.Challenge
+	challenge.ChallengerKey = msg.SenderKey
+	_, challengeErr := m.MatcherService.RequestInviteChallenge(&challenge)
+	return challengeErr
+}
+
+func HandleAcceptInviteChallengeMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.AcceptInviteChallengeMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid accept invite challenge message content")
+	}
+	return m.MatcherService.AcceptInviteChallenge(msgContent.InviteToken, msg.SenderKey)
+}
+
+func HandleRevokeInviteChallengeMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.RevokeInviteChallengeMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "invalid revoke invite challenge message content")
+	}
+	return m.MatcherService.RevokeInviteChallenge(msgContent.InviteToken, msg.SenderKey)
+}
+
+func HandleGetPenaltiesMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.GetPenaltiesMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message to GetPenaltiesMessageContent")
+	}
+	if role, _ := m.AuthService.GetRole(msg.SenderKey); role != models.ADMIN {
+		return models.NewProtocolError(models.ERROR_CODE_UNAUTHORIZED, "client %s is not permitted to view penalties", msg.SenderKey)
+	}
+
+	penalties := m.PenaltyService.Penalties()
+	if msgContent.ClientKey != "" {
+		penalties = []*models.Penalty{m.PenaltyService.Penalty(msgContent.ClientKey)}
+	}
+	sendDeps := m.directReplyDeps(msg.SenderKey, msg.RequestId)
+	return SendPenalties(sendDeps, penalties)
+}
+
+func HandleHelloMessage(m *ClientsManager, msg *models.Message) error {
+	msgContent, ok := msg.Content.(*models.HelloMessageContent)
+	if !ok {
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not cast message to HelloMessageContent")
+	}
+	welcome, err := m.Handshake(msg.SenderKey, msgContent)
+	if err != nil {
+		return err
+	}
+	// NOTE: the WELCOME always answers the HELLO it was tagged with, the client learns what it negotiated from it
+	sendDeps := NewSendReplyDeps(m.DirectMessage, msg.SenderKey, msg.RequestId)
+	return SendWelcome(sendDeps, welcome)
+}
@@ -6,20 +6,30 @@ import (
 	"github.com/CameronHonis/chess-arbitrator/matcher"
 	mm "github.com/CameronHonis/chess-arbitrator/matchmaking"
 	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/chess-arbitrator/penalty"
 	sub "github.com/CameronHonis/chess-arbitrator/sub_service"
 	"github.com/CameronHonis/log"
 	"github.com/CameronHonis/marker"
 	"github.com/CameronHonis/service"
 	"github.com/gorilla/websocket"
 	"sync"
+	"time"
 )
 
 type ClientsManagerI interface {
 	service.ServiceI
 
 	AddConn(conn *websocket.Conn)
+	ServeConn(conn *websocket.Conn)
 	BroadcastMessage(message *models.Message)
 	DirectMessage(message *models.Message, clientKey models.Key) error
+	ProtocolVersion(clientKey models.Key) int
+	HasFeature(clientKey models.Key, feature models.Feature) bool
+	RoundTripTime(clientKey models.Key) (time.Duration, error)
+	OutboundQueueStats(clientKey models.Key) (*OutboundQueueStats, error)
+	IsPublicTopic(topic models.MessageTopic) bool
+	OpenStream(topic models.MessageTopic, lastEventId uint64) (*EventStream, error)
+	CloseStream(stream *EventStream)
 }
 
 type ClientsManager struct {
@@ -31,15 +41,27 @@ type ClientsManager struct {
 	AuthService        auth.AuthenticationServiceI
 	MatchmakingService mm.MatchmakingServiceI
 	MatcherService     matcher.MatcherServiceI
+	PenaltyService     penalty.PenaltyServiceI
 
-	__state__    marker.Marker
-	connByPubKey map[models.Key]*websocket.Conn
-	mu           sync.Mutex
+	__state__          marker.Marker
+	connByPubKey       map[models.Key]*websocket.Conn
+	welcomeByClientKey map[models.Key]*models.WelcomeMessageContent
+	rttByConn          map[*websocket.Conn]time.Duration
+	writerByConn       map[*websocket.Conn]*connWriter
+	streamByKey        map[models.Key]*EventStream
+	replayBuffer       []*StreamEvent
+	lastEventId        uint64
+	mu                 sync.Mutex
 }
 
 func NewClientsManager(config *ClientsManagerConfig) *ClientsManager {
 	s := &ClientsManager{
-		connByPubKey: make(map[models.Key]*websocket.Conn),
+		connByPubKey:       make(map[models.Key]*websocket.Conn),
+		welcomeByClientKey: make(map[models.Key]*models.WelcomeMessageContent),
+		rttByConn:          make(map[*websocket.Conn]time.Duration),
+		writerByConn:       make(map[*websocket.Conn]*connWriter),
+		streamByKey:        make(map[models.Key]*EventStream),
+		replayBuffer:       make([]*StreamEvent, 0),
 	}
 	s.Service = *service.NewService(s, config)
 
@@ -56,28 +78,65 @@ func (c *ClientsManager) OnBuild() {
 	c.AddEventListener(matcher.CHALLENGE_DENIED, OnChallengeDenied)
 	c.AddEventListener(matcher.CHALLENGE_ACCEPTED, OnChallengeAccepted)
 	c.AddEventListener(matcher.CHALLENGE_ACCEPT_FAILED, OnChallengeAcceptFailed)
+	c.AddEventListener(matcher.CHALLENGE_EXPIRED, OnChallengeExpired)
 	c.AddEventListener(matcher.MATCH_CREATED, OnMatchCreated)
 	c.AddEventListener(matcher.MATCH_CREATION_FAILED, OnMatchCreationFailed)
 	c.AddEventListener(matcher.MATCH_UPDATED, OnMatchUpdated)
 	c.AddEventListener(matcher.MATCH_ENDED, OnMatchEnded)
 	c.AddEventListener(matcher.MOVE_FAILURE, OnMoveFailed)
+	c.AddEventListener(mm.MATCHMAKING_JOINED, OnMatchmakingJoined)
+	c.AddEventListener(mm.MATCHMAKING_LEFT, OnMatchmakingLeft)
+	c.AddEventListener(mm.MATCHMAKING_STATUS_UPDATED, OnMatchmakingStatusUpdated)
+	c.AddEventListener(mm.MATCH_FOUND, OnMatchFound)
+	c.AddEventListener(mm.MATCH_CANCELLED, OnMatchCancelled)
+	c.AddEventListener(mm.PARTY_UPDATED, OnPartyUpdated)
+	c.AddEventListener(mm.PARTY_DISBANDED, OnPartyDisbanded)
 }
 
 func (c *ClientsManager) AddConn(conn *websocket.Conn) {
 	go c.listenOnConn(conn)
 }
 
+// ServeConn is AddConn for callers that need to know when the connection is done, returning once it has closed
+func (c *ClientsManager) ServeConn(conn *websocket.Conn) {
+	c.listenOnConn(conn)
+}
+
 func (c *ClientsManager) BroadcastMessage(message *models.Message) {
 	msgCopy := *message
 	msgCopy.PrivateKey = ""
+	msgCopy.RequestId = ""
+	currentWelcome := &models.WelcomeMessageContent{ProtocolVersion: models.CURRENT_PROTOCOL_VERSION, Features: models.AllFeatures()}
+	currentMsgJson, encodeErr := encodeMessage(&msgCopy, currentWelcome)
+	if encodeErr != nil {
+		c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error encoding broadcast: %s", encodeErr), log.ALL_BUT_TEST_ENV)
+		return
+	}
+	// NOTE: recorded before looking up subscribers, so a stream opening mid-broadcast replays what it wasn't sent
+	event := c.recordBroadcast(&msgCopy, currentMsgJson)
 	subbedClientKeys := c.SubService.ClientKeysSubbedToTopic(msgCopy.Topic)
+	// NOTE: subscribers on the same protocol version and features share one encoding, so large topics are only
+	// marshalled once per dialect
+	msgJsonByDialect := map[string][]byte{dialectOf(currentWelcome): currentMsgJson}
 	for _, clientKey := range subbedClientKeys.Flatten() {
-		conn, err := c.getConnByKey(clientKey)
-		if err != nil {
-			c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error getting client from key: %s", err), log.ALL_BUT_TEST_ENV)
+		if stream := c.getStreamByKey(clientKey); stream != nil {
+			if !stream.push(event) {
+				c.CloseStream(stream)
+			}
 			continue
 		}
-		writeErr := c.writeMessage(clientKey, conn, &msgCopy)
+		welcome := c.welcome(clientKey)
+		msgJson, ok := msgJsonByDialect[dialectOf(welcome)]
+		if !ok {
+			var encodeErr error
+			msgJson, encodeErr = encodeMessage(&msgCopy, welcome)
+			if encodeErr != nil {
+				c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error encoding broadcast: %s", encodeErr), log.ALL_BUT_TEST_ENV)
+				return
+			}
+			msgJsonByDialect[dialectOf(welcome)] = msgJson
+		}
+		writeErr := c.writeMessage(clientKey, msgJson, isDroppableFor(&msgCopy, clientKey))
 		if writeErr != nil {
 			c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error broadcasting to client: %s", writeErr), log.ALL_BUT_TEST_ENV)
 			continue
@@ -91,11 +150,63 @@ func (c *ClientsManager) DirectMessage(message *models.Message, clientKey models
 	}
 	msgCopy := *message
 	msgCopy.Topic = "directMessage"
-	conn, err := c.getConnByKey(clientKey)
-	if err != nil {
+	msgJson, encodeErr := encodeMessage(&msgCopy, c.welcome(clientKey))
+	if encodeErr != nil {
+		return encodeErr
+	}
+	if err := c.writeMessage(clientKey, msgJson, false); err != nil {
 		return fmt.Errorf("unable to send DM: %s", err)
 	}
-	return c.writeMessage(clientKey, conn, &msgCopy)
+	return nil
+}
+
+// Handshake settles the protocol version and features for the client's connection. Clients that never shake hands
+// are spoken to in PROTOCOL_VERSION_1 with no optional features.
+func (c *ClientsManager) Handshake(clientKey models.Key, hello *models.HelloMessageContent) (*models.WelcomeMessageContent, error) {
+	version, ok := models.NegotiateProtocolVersion(hello.ProtocolVersion)
+	if !ok {
+		return nil, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "protocol version %d is no longer supported, the oldest supported version is %d", hello.ProtocolVersion, models.MIN_PROTOCOL_VERSION)
+	}
+	config := c.Config().(*ClientsManagerConfig)
+	welcome := &models.WelcomeMessageContent{
+		ProtocolVersion: version,
+		Features:        models.NegotiateFeatures(hello.Features, config.EnabledFeatures),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.welcomeByClientKey[clientKey] = welcome
+	return welcome, nil
+}
+
+func (c *ClientsManager) ProtocolVersion(clientKey models.Key) int {
+	return c.welcome(clientKey).ProtocolVersion
+}
+
+func (c *ClientsManager) HasFeature(clientKey models.Key, feature models.Feature) bool {
+	for _, f := range c.welcome(clientKey).Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// welcome is what the client settled on in its handshake
+func (c *ClientsManager) welcome(clientKey models.Key) *models.WelcomeMessageContent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if welcome, ok := c.welcomeByClientKey[clientKey]; ok {
+		return welcome
+	}
+	return &models.WelcomeMessageContent{ProtocolVersion: models.PROTOCOL_VERSION_1, Features: make([]models.Feature, 0)}
+}
+
+// hasShakenHands callers must not hold c.mu
+func (c *ClientsManager) hasShakenHands(clientKey models.Key) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.welcomeByClientKey[clientKey]
+	return ok
 }
 
 func (c *ClientsManager) registerConn(pubKey models.Key, conn *websocket.Conn) error {
@@ -108,19 +219,44 @@ func (c *ClientsManager) registerConn(pubKey models.Key, conn *websocket.Conn) e
 	return nil
 }
 
-func (c *ClientsManager) deregisterConn(pubKey models.Key) error {
+// deregisterConn forgets the client's connection and everything it was waiting on, unless the client has since moved
+// to another connection
+func (c *ClientsManager) deregisterConn(pubKey models.Key, conn *websocket.Conn) error {
+	c.mu.Lock()
+	if registeredConn, ok := c.connByPubKey[pubKey]; !ok || registeredConn != conn {
+		c.mu.Unlock()
+		return fmt.Errorf("no client with key %s on this connection", pubKey)
+	}
+	delete(c.connByPubKey, pubKey)
+	delete(c.welcomeByClientKey, pubKey)
+	c.mu.Unlock()
+
 	role, _ := c.AuthService.GetRole(pubKey)
 	if role == models.BOT {
 		c.AuthService.RemoveClient(pubKey)
 	}
+	c.MatcherService.RevokeAllChallenges(pubKey)
+	_ = c.MatchmakingService.RemoveClient(pubKey, models.MATCHMAKING_LEFT_REASON_DISCONNECTED)
+	_ = c.MatchmakingService.LeaveParty(pubKey)
+	c.MatchmakingService.ForgetClient(pubKey)
+	return nil
+}
 
-	if _, err := c.getConnByKey(pubKey); err != nil {
-		return err
+// dropStaleConn deregisters the client from a connection it has been replaced on, then closes it. The connection's
+// listener finds nothing left to deregister once its read fails.
+func (c *ClientsManager) dropStaleConn(pubKey models.Key, conn *websocket.Conn) {
+	c.Logger.Log(models.ENV_SERVER, fmt.Sprintf("replacing stale connection for %s", pubKey))
+	if deregErr := c.deregisterConn(pubKey, conn); deregErr != nil {
+		c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error deregistering stale connection: %s", deregErr), log.ALL_BUT_TEST_ENV)
 	}
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.connByPubKey, pubKey)
-	return nil
+	writer, ok := c.writerByConn[conn]
+	c.mu.Unlock()
+	if !ok {
+		_ = conn.Close()
+		return
+	}
+	writer.closeAfterDrain(websocket.CloseNormalClosure, "replaced by a new connection")
 }
 
 func (c *ClientsManager) getConnByKey(pubKey models.Key) (*websocket.Conn, error) {
@@ -133,21 +269,73 @@ func (c *ClientsManager) getConnByKey(pubKey models.Key) (*websocket.Conn, error
 	return conn, nil
 }
 
+func (c *ClientsManager) getWriterByKey(pubKey models.Key) (*connWriter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, ok := c.connByPubKey[pubKey]
+	if !ok {
+		return nil, fmt.Errorf("no client with key %s", pubKey)
+	}
+	writer, ok := c.writerByConn[conn]
+	if !ok {
+		return nil, fmt.Errorf("no writer for client with key %s", pubKey)
+	}
+	return writer, nil
+}
+
+func (c *ClientsManager) OutboundQueueStats(clientKey models.Key) (*OutboundQueueStats, error) {
+	writer, err := c.getWriterByKey(clientKey)
+	if err != nil {
+		return nil, err
+	}
+	return writer.stats(), nil
+}
+
 func (c *ClientsManager) listenOnConn(conn *websocket.Conn) {
 	var clientKey models.Key = "??"
+	config := c.Config().(*ClientsManagerConfig)
+	limiter := newConnRateLimiter(config)
+	writer := newConnWriter(conn, config)
+	c.mu.Lock()
+	c.writerByConn[conn] = writer
+	c.mu.Unlock()
+	go writer.run()
+	c.armHeartbeat(conn)
+	stopPinging := make(chan struct{})
+	go c.pingUntilClosed(conn, stopPinging)
+	defer func() {
+		close(stopPinging)
+		writer.stop()
+		c.mu.Lock()
+		delete(c.rttByConn, conn)
+		delete(c.writerByConn, conn)
+		c.mu.Unlock()
+	}()
 	for {
 		_, rawMsg, readErr := conn.ReadMessage()
 		if readErr != nil {
 			c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error reading message from websocket: %s", readErr), log.ALL_BUT_TEST_ENV)
-			if deregErr := c.deregisterConn(clientKey); deregErr != nil {
+			if deregErr := c.deregisterConn(clientKey, conn); deregErr != nil {
 				c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error deregistering client: %s", deregErr), log.ALL_BUT_TEST_ENV)
 			}
+			_ = conn.Close()
 			return
 		}
+		c.extendReadDeadline(conn)
 		c.Logger.Log(string(clientKey), ">> ", string(rawMsg))
+		if !limiter.allowFrame() {
+			c.rejectThrottledMsg(conn, limiter, clientKey, models.RequestIdFromJson(rawMsg), "")
+			continue
+		}
 		msg, unmarshalErr := models.UnmarshalToMessage(rawMsg)
 		if unmarshalErr != nil {
 			c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("error unmarshalling message: %s", unmarshalErr))
+			sendDeps := c.replyDeps(conn, clientKey, models.RequestIdFromJson(rawMsg))
+			_ = SendError(sendDeps, "", models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "malformed message: %s", unmarshalErr))
+			continue
+		}
+		if !limiter.allowContentType(msg.ContentType) {
+			c.rejectThrottledMsg(conn, limiter, clientKey, msg.RequestId, msg.ContentType)
 			continue
 		}
 
@@ -156,15 +344,18 @@ func (c *ClientsManager) listenOnConn(conn *websocket.Conn) {
 			pubKey, refreshErr := HandleRefreshAuthMessage(c, msg, conn)
 			if refreshErr != nil {
 				c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("could not refresh creds: %s", refreshErr.Error()))
-			} else {
-				clientKey = pubKey
+				_ = SendError(c.replyDeps(conn, clientKey, msg.RequestId), msg.ContentType, models.AsProtocolError(refreshErr, models.ERROR_CODE_BAD_REQUEST))
+				continue
 			}
+			clientKey = pubKey
+			_ = SendAck(c.replyDeps(conn, clientKey, msg.RequestId), msg.ContentType)
 			continue
 		}
 
 		if authErr := c.AuthService.VetAuthInMessage(msg); authErr != nil {
-			sendDeps := NewSendDirectDeps(c.DirectMessage, clientKey)
+			sendDeps := c.replyDeps(conn, clientKey, msg.RequestId)
 			_ = SendInvalidAuth(sendDeps)
+			_ = SendError(sendDeps, msg.ContentType, models.NewProtocolError(models.ERROR_CODE_UNAUTHORIZED, "invalid auth"))
 			c.Logger.LogRed(fmt.Sprintf("error validating auth in message: %s", authErr))
 			continue
 		} else if msg.SenderKey != "" {
@@ -178,28 +369,136 @@ func (c *ClientsManager) listenOnConn(conn *websocket.Conn) {
 	}
 }
 
-func (c *ClientsManager) handleMsg(clientKey models.Key, msg *models.Message) error {
+// rejectThrottledMsg tells the client its message was rate limited, and closes the connection if the client keeps
+// flooding it
+func (c *ClientsManager) rejectThrottledMsg(conn *websocket.Conn, limiter *connRateLimiter, clientKey models.Key, requestId string, contentType models.ContentType) {
+	sendDeps := c.replyDeps(conn, clientKey, requestId)
+	_ = SendError(sendDeps, contentType, models.NewProtocolError(models.ERROR_CODE_RATE_LIMITED, "too many messages, slow down"))
+	if !limiter.forgive() {
+		c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("disconnecting %s for exceeding rate limits", clientKey), log.ALL_BUT_TEST_ENV)
+		c.mu.Lock()
+		writer, ok := c.writerByConn[conn]
+		c.mu.Unlock()
+		if !ok {
+			_ = conn.Close()
+			return
+		}
+		// NOTE: the writer flushes the rate_limited error before closing, so the client learns why it was dropped
+		writer.closeAfterDrain(websocket.ClosePolicyViolation, "rate limit exceeded")
+	}
+}
 
+// handleMsg runs the handler for the message, then tells the client whether it succeeded with an ACK or an ERROR
+func (c *ClientsManager) handleMsg(clientKey models.Key, msg *models.Message) error {
+	sendDeps := c.directReplyDeps(clientKey, msg.RequestId)
 	config := c.Config().(*ClientsManagerConfig)
-	if msgHandler := config.HandlerByContentType(msg.ContentType); msgHandler != nil {
-		if handlerErr := msgHandler(c, msg); handlerErr != nil {
-			c.Logger.LogRed(models.ENV_CLIENT_MNGR, fmt.Sprintf("error handling msg \n\t%+v\n\n\t%s", *msg, handlerErr))
-		}
-	} else {
+	msgHandler := config.HandlerByContentType(msg.ContentType)
+	if msgHandler == nil {
+		_ = SendError(sendDeps, msg.ContentType, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "unsupported content type %s", msg.ContentType))
 		return fmt.Errorf("no handler configured for msg %s", msg)
 	}
+	if handlerErr := msgHandler(c, msg); handlerErr != nil {
+		c.Logger.LogRed(models.ENV_CLIENT_MNGR, fmt.Sprintf("error handling msg \n\t%+v\n\n\t%s", *msg, handlerErr))
+		_ = SendError(sendDeps, msg.ContentType, models.AsProtocolError(handlerErr, models.ERROR_CODE_INVALID_STATE))
+	} else {
+		_ = SendAck(sendDeps, msg.ContentType)
+	}
 	c.BroadcastMessage(msg)
 	return nil
 }
 
-func (c *ClientsManager) writeMessage(pubkey models.Key, conn *websocket.Conn, msg *models.Message) error {
-	msgJson, jsonErr := msg.Marshal()
-	if jsonErr != nil {
-		return jsonErr
+// replyDeps replies to a request read off the connection. Clients that have no key yet, or whose key belongs to
+// another connection, are replied to on the connection itself.
+func (c *ClientsManager) replyDeps(conn *websocket.Conn, clientKey models.Key, requestId string) *SendDirectDeps {
+	if registeredConn, _ := c.getConnByKey(clientKey); registeredConn == conn {
+		return c.directReplyDeps(clientKey, requestId)
+	}
+	return c.newReplyDeps(c.writeToConn(conn), clientKey, requestId)
+}
+
+// directReplyDeps replies to a request from a registered client
+func (c *ClientsManager) directReplyDeps(clientKey models.Key, requestId string) *SendDirectDeps {
+	return c.newReplyDeps(c.DirectMessage, clientKey, requestId)
+}
+
+// newReplyDeps only echoes the request id, and only sends ACKs and ERRORs, to clients that negotiated request ids. A
+// client yet to shake hands is assumed to speak them when it tags the request with an id.
+func (c *ClientsManager) newReplyDeps(writer DirectMessageFn, clientKey models.Key, requestId string) *SendDirectDeps {
+	isSpeakingRequestIds := requestId != ""
+	if c.hasShakenHands(clientKey) {
+		isSpeakingRequestIds = c.HasFeature(clientKey, models.FEATURE_REQUEST_IDS)
+	}
+	if isSpeakingRequestIds {
+		return NewSendReplyDeps(writer, clientKey, requestId)
+	}
+	return NewSendDirectDeps(withoutReplies(writer), clientKey)
+}
+
+// withoutReplies drops ACKs and ERRORs, which a client can't tie to its request without request ids
+func withoutReplies(writer DirectMessageFn) DirectMessageFn {
+	return func(msg *models.Message, clientKey models.Key) error {
+		if msg.ContentType == models.CONTENT_TYPE_ACK || msg.ContentType == models.CONTENT_TYPE_ERROR {
+			return nil
+		}
+		return writer(msg, clientKey)
+	}
+}
+
+// writeToConn writes straight to the connection's writer. The connection has not shaken hands, so it is spoken to in
+// PROTOCOL_VERSION_1.
+func (c *ClientsManager) writeToConn(conn *websocket.Conn) DirectMessageFn {
+	return func(msg *models.Message, clientKey models.Key) error {
+		msgCopy := *msg
+		msgCopy.Topic = "directMessage"
+		msgJson, encodeErr := encodeMessage(&msgCopy, c.welcome(""))
+		if encodeErr != nil {
+			return encodeErr
+		}
+		c.mu.Lock()
+		writer, ok := c.writerByConn[conn]
+		c.mu.Unlock()
+		if !ok {
+			return fmt.Errorf("no writer for connection of client %s", clientKey)
+		}
+		c.Logger.Log(string(clientKey), "<< ", string(msgJson))
+		return writer.enqueue(msgJson, false)
+	}
+}
+
+// writeMessage hands the message to the client's writer without waiting on the socket. Droppable messages, like
+// broadcasts to spectators, may be discarded if the client isn't keeping up.
+func (c *ClientsManager) writeMessage(pubkey models.Key, msgJson []byte, isDroppable bool) error {
+	writer, err := c.getWriterByKey(pubkey)
+	if err != nil {
+		return err
 	}
 	c.Logger.Log(string(pubkey), "<< ", string(msgJson))
+	return writer.enqueue(msgJson, isDroppable)
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return conn.WriteMessage(websocket.TextMessage, msgJson)
+// isDroppableFor reports whether a broadcast may be discarded for a subscriber that isn't keeping up. Spectators can
+// afford to miss an update, but the clients an update is about must receive it or be disconnected.
+func isDroppableFor(msg *models.Message, clientKey models.Key) bool {
+	switch content := msg.Content.(type) {
+	case *models.MatchUpdateMessageContent:
+		match := content.Match
+		return match == nil || (match.WhiteClientKey != clientKey && match.BlackClientKey != clientKey)
+	case *models.ChallengeUpdatedMessageContent:
+		challenge := content.Challenge
+		return challenge == nil || (challenge.ChallengerKey != clientKey && challenge.ChallengedKey != clientKey)
+	}
+	return true
+}
+
+// encodeMessage shapes the message for the protocol version and features the client settled on
+func encodeMessage(msg *models.Message, welcome *models.WelcomeMessageContent) ([]byte, error) {
+	msgCopy := *msg
+	msgCopy.Content = models.AdaptContent(msg.ContentType, msg.Content, welcome.ProtocolVersion)
+	msgCopy.Content = models.AdaptContentToFeatures(msg.ContentType, msgCopy.Content, welcome.Features)
+	return msgCopy.Marshal()
+}
+
+// dialectOf keys the encodings of a broadcast, clients in the same dialect receive identical bytes
+func dialectOf(welcome *models.WelcomeMessageContent) string {
+	return fmt.Sprint(welcome.ProtocolVersion, welcome.Features)
 }
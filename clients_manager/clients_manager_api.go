@@ -17,6 +17,42 @@ func NewSendDirectDeps(writer DirectMessageFn, clientKey models.Key) *SendDirect
 	return &SendDirectDeps{writer, clientKey}
 }
 
+// NewSendReplyDeps sends direct messages as replies to a request, echoing its request id
+func NewSendReplyDeps(writer DirectMessageFn, clientKey models.Key, requestId string) *SendDirectDeps {
+	replyWriter := func(msg *models.Message, clientKey models.Key) error {
+		msg.RequestId = requestId
+		return writer(msg, clientKey)
+	}
+	return &SendDirectDeps{replyWriter, clientKey}
+}
+
+func SendError(deps *SendDirectDeps, requestContentType models.ContentType, err *models.ProtocolError) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_ERROR,
+		Content: &models.ErrorMessageContent{
+			Code:               err.Code,
+			Message:            err.Message,
+			RequestContentType: requestContentType,
+		},
+	}, deps.clientKey)
+}
+
+func SendAck(deps *SendDirectDeps, requestContentType models.ContentType) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_ACK,
+		Content: &models.AckMessageContent{
+			RequestContentType: requestContentType,
+		},
+	}, deps.clientKey)
+}
+
+func SendWelcome(deps *SendDirectDeps, welcome *models.WelcomeMessageContent) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_WELCOME,
+		Content:     welcome,
+	}, deps.clientKey)
+}
+
 func SendAuth(deps *SendDirectDeps, priKey models.Key) error {
 	return deps.writer(&models.Message{
 		ContentType: models.CONTENT_TYPE_AUTH,
@@ -92,6 +128,89 @@ func SendChallengeUpdate(deps *SendDirectDeps, challenge *models.Challenge) erro
 	}, deps.clientKey)
 }
 
+func SendMatchmakingJoined(deps *SendDirectDeps, queues []*models.MatchmakingQueue) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCHMAKING_JOINED,
+		Content: &models.MatchmakingJoinedMessageContent{
+			Queues: queues,
+		},
+	}, deps.clientKey)
+}
+
+func SendMatchmakingJoinFailed(deps *SendDirectDeps, reason string) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCHMAKING_JOIN_FAILED,
+		Content: &models.MatchmakingJoinFailedMessageContent{
+			Reason: reason,
+		},
+	}, deps.clientKey)
+}
+
+func SendMatchmakingLeft(deps *SendDirectDeps, reason models.MatchmakingLeftReason) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCHMAKING_LEFT,
+		Content: &models.MatchmakingLeftMessageContent{
+			Reason: reason,
+		},
+	}, deps.clientKey)
+}
+
+func SendMatchFound(deps *SendDirectDeps, readyCheck *models.ReadyCheck) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCH_FOUND,
+		Content: &models.MatchFoundMessageContent{
+			ReadyCheck: readyCheck,
+		},
+	}, deps.clientKey)
+}
+
+func SendMatchCancelled(deps *SendDirectDeps, readyCheckId string, reason models.ReadyCheckCancelledReason) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCH_CANCELLED,
+		Content: &models.MatchCancelledMessageContent{
+			ReadyCheckId: readyCheckId,
+			Reason:       reason,
+		},
+	}, deps.clientKey)
+}
+
+func SendMatchmakingStatus(deps *SendDirectDeps, statuses []*models.MatchmakingStatus) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_MATCHMAKING_STATUS,
+		Content: &models.MatchmakingStatusMessageContent{
+			Statuses: statuses,
+		},
+	}, deps.clientKey)
+}
+
+func SendPartyUpdated(deps *SendDirectDeps, party *models.Party) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_PARTY_UPDATED,
+		Content: &models.PartyUpdatedMessageContent{
+			Party: party,
+		},
+	}, deps.clientKey)
+}
+
+func SendPartyDisbanded(deps *SendDirectDeps, code string, reason models.PartyDisbandedReason) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_PARTY_DISBANDED,
+		Content: &models.PartyDisbandedMessageContent{
+			Code:   code,
+			Reason: reason,
+		},
+	}, deps.clientKey)
+}
+
+func SendPenalties(deps *SendDirectDeps, penalties []*models.Penalty) error {
+	return deps.writer(&models.Message{
+		ContentType: models.CONTENT_TYPE_PENALTIES,
+		Content: &models.PenaltiesMessageContent{
+			Penalties: penalties,
+		},
+	}, deps.clientKey)
+}
+
 type BroadcastMessageFn func(msg *models.Message)
 
 type SendTopicDeps struct {
@@ -3,6 +3,7 @@ package clients_manager
 import (
 	"github.com/CameronHonis/chess-arbitrator/models"
 	"github.com/CameronHonis/service"
+	"time"
 )
 
 type MessageHandler func(*ClientsManager, *models.Message) error
@@ -10,11 +11,48 @@ type MessageHandler func(*ClientsManager, *models.Message) error
 type ClientsManagerConfig struct {
 	service.ConfigI
 	handlerByContentType map[models.ContentType]MessageHandler
+	// features offered to clients during the HELLO handshake
+	EnabledFeatures []models.Feature
+	// how often each connection is pinged
+	PingInterval time.Duration
+	// how long a connection may go without sending anything, pongs included, before it is dropped
+	PongTimeout time.Duration
+	// how long a single write may block before the connection is considered dead
+	WriteTimeout time.Duration
+	// how many messages may wait to be written to a connection
+	SendBufferSize int
+	// how many broadcasts in a row a client may miss to a full send buffer before it is disconnected
+	MaxDroppedMessages int
+	// the limit on every message read from a connection
+	RateLimit RateLimit
+	// further limits on messages of the given content types, counted separately for each connection
+	RateLimitByContentType map[models.ContentType]RateLimit
+	// how many throttled messages a client is forgiven before it is disconnected
+	ViolationAllowance RateLimit
+	// how many recent broadcasts are kept for event streams resuming from an earlier event
+	StreamReplaySize int
+	// topics that event streams may follow without creds, over the REST and gRPC APIs alike
+	PublicStreamTopicPrefixes []string
 }
 
 func NewClientsManagerConfig(handlersByMsgTopic map[models.ContentType]MessageHandler) *ClientsManagerConfig {
 	return &ClientsManagerConfig{
 		handlerByContentType: handlersByMsgTopic,
+		EnabledFeatures:      models.AllFeatures(),
+		PingInterval:         15 * time.Second,
+		PongTimeout:          45 * time.Second,
+		WriteTimeout:         10 * time.Second,
+		SendBufferSize:       256,
+		MaxDroppedMessages:   64,
+		RateLimit:            RateLimit{PerSecond: 20, Burst: 40},
+		RateLimitByContentType: map[models.ContentType]RateLimit{
+			models.CONTENT_TYPE_MOVE:              {PerSecond: 10, Burst: 20},
+			models.CONTENT_TYPE_CHALLENGE_REQUEST: {PerSecond: 1, Burst: 5},
+			models.CONTENT_TYPE_ECHO:              {PerSecond: 1, Burst: 5},
+		},
+		ViolationAllowance:        RateLimit{PerSecond: 0.5, Burst: 20},
+		StreamReplaySize:          128,
+		PublicStreamTopicPrefixes: []string{"match-"},
 	}
 }
 
@@ -41,6 +79,44 @@ func (b *ClientsManagerConfigBuilder) WithMessageHandler(contentType models.Cont
 	return b
 }
 
+func (b *ClientsManagerConfigBuilder) WithEnabledFeatures(features []models.Feature) *ClientsManagerConfigBuilder {
+	b.config.EnabledFeatures = features
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithHeartbeat(pingInterval time.Duration, pongTimeout time.Duration, writeTimeout time.Duration) *ClientsManagerConfigBuilder {
+	b.config.PingInterval = pingInterval
+	b.config.PongTimeout = pongTimeout
+	b.config.WriteTimeout = writeTimeout
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithSendBuffer(size int, maxDroppedMessages int) *ClientsManagerConfigBuilder {
+	b.config.SendBufferSize = size
+	b.config.MaxDroppedMessages = maxDroppedMessages
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithRateLimit(limit RateLimit) *ClientsManagerConfigBuilder {
+	b.config.RateLimit = limit
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithContentTypeRateLimit(contentType models.ContentType, limit RateLimit) *ClientsManagerConfigBuilder {
+	b.config.RateLimitByContentType[contentType] = limit
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithViolationAllowance(allowance RateLimit) *ClientsManagerConfigBuilder {
+	b.config.ViolationAllowance = allowance
+	return b
+}
+
+func (b *ClientsManagerConfigBuilder) WithStreamReplaySize(size int) *ClientsManagerConfigBuilder {
+	b.config.StreamReplaySize = size
+	return b
+}
+
 func (b *ClientsManagerConfigBuilder) Build() *ClientsManagerConfig {
 	return b.config
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/CameronHonis/chess-arbitrator/auth"
 	"github.com/CameronHonis/chess-arbitrator/builders"
 	"github.com/CameronHonis/chess-arbitrator/matcher"
+	mm "github.com/CameronHonis/chess-arbitrator/matchmaking"
 	"github.com/CameronHonis/chess-arbitrator/models"
 	. "github.com/CameronHonis/service"
 )
@@ -74,13 +75,15 @@ var OnChallengeCreated = func(self ServiceI, event EventI) bool {
 	challenge := event.Payload().(*matcher.ChallengeCreatedEventPayload).Challenge
 
 	challengerSubErr := c.SubService.SubClient(challenge.ChallengerKey, challenge.Topic())
-	challengedSubErr := c.SubService.SubClient(challenge.ChallengedKey, challenge.Topic())
-
 	if challengerSubErr != nil {
 		c.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengerSubErr, " (challenger)")
 	}
-	if challengedSubErr != nil {
-		c.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengerSubErr, " (challenged)")
+	// NOTE: invite challenges have no challenged client until the invite is claimed
+	if challenge.ChallengedKey != "" {
+		challengedSubErr := c.SubService.SubClient(challenge.ChallengedKey, challenge.Topic())
+		if challengedSubErr != nil {
+			c.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengedSubErr, " (challenged)")
+		}
 	}
 
 	sendTopicDeps := NewSendTopicDeps(c.BroadcastMessage, challenge.Topic())
@@ -116,6 +119,20 @@ var OnChallengeDenied = func(self ServiceI, event EventI) bool {
 	return true
 }
 
+var OnChallengeExpired = func(self ServiceI, event EventI) bool {
+	clientManager := self.(*ClientsManager)
+	challenge := event.Payload().(*matcher.ChallengeExpiredEventPayload).Challenge
+	inactiveChallenge := builders.NewChallengeBuilder().FromChallenge(challenge).WithIsActive(false).Build()
+
+	sendTopicDeps := NewSendTopicDeps(clientManager.BroadcastMessage, challenge.Topic())
+	SendChallengeUpdateToAll(sendTopicDeps, inactiveChallenge)
+
+	_ = clientManager.SubService.UnsubClient(challenge.ChallengerKey, challenge.Topic())
+	_ = clientManager.SubService.UnsubClient(challenge.ChallengedKey, challenge.Topic())
+
+	return true
+}
+
 var OnChallengeAccepted = func(s ServiceI, event EventI) bool {
 	clientManager := s.(*ClientsManager)
 	baseErrMsg := "could not follow up on challenge accepted: "
@@ -126,13 +143,14 @@ var OnChallengeAccepted = func(s ServiceI, event EventI) bool {
 	SendChallengeUpdateToAll(sendTopicDeps, inactiveChallenge)
 
 	challengerSubErr := clientManager.SubService.UnsubClient(challenge.ChallengerKey, challenge.Topic())
-	challengedSubErr := clientManager.SubService.UnsubClient(challenge.ChallengedKey, challenge.Topic())
-
 	if challengerSubErr != nil {
 		clientManager.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengerSubErr, " (challenger)")
 	}
-	if challengedSubErr != nil {
-		clientManager.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengerSubErr, " (challenged)")
+	if challenge.InviteToken == "" {
+		challengedSubErr := clientManager.SubService.UnsubClient(challenge.ChallengedKey, challenge.Topic())
+		if challengedSubErr != nil {
+			clientManager.Logger.LogRed(models.ENV_CLIENT_MNGR, baseErrMsg, challengedSubErr, " (challenged)")
+		}
 	}
 
 	return true
@@ -221,6 +239,11 @@ var OnMatchEnded = func(self ServiceI, event EventI) bool {
 		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not unsub black client from match topic", blackUnsubErr)
 	}
 
+	if loserKey := match.LoserKey(); loserKey != "" {
+		_, connErr := clientsManager.getConnByKey(loserKey)
+		clientsManager.PenaltyService.RecordMatchEnded(match, connErr == nil)
+	}
+
 	return true
 }
 
@@ -237,3 +260,80 @@ var OnMoveFailed = func(self ServiceI, event EventI) bool {
 
 	return true
 }
+
+var OnMatchmakingJoined = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.MatchmakingJoinedEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendMatchmakingJoined(sendDeps, payload.Queues); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send matchmaking joined message", sendErr)
+	}
+	return true
+}
+
+var OnMatchmakingLeft = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.MatchmakingLeftEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendMatchmakingLeft(sendDeps, payload.Reason); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send matchmaking left message", sendErr)
+	}
+	return true
+}
+
+var OnMatchmakingStatusUpdated = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.MatchmakingStatusUpdatedEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendMatchmakingStatus(sendDeps, payload.Statuses); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send matchmaking status message", sendErr)
+	}
+	return true
+}
+
+var OnMatchFound = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.MatchFoundEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendMatchFound(sendDeps, payload.ReadyCheck); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send match found message", sendErr)
+	}
+	return true
+}
+
+var OnMatchCancelled = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.MatchCancelledEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendMatchCancelled(sendDeps, payload.ReadyCheckId, payload.Reason); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send match cancelled message", sendErr)
+	}
+	return true
+}
+
+var OnPartyUpdated = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.PartyUpdatedEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendPartyUpdated(sendDeps, payload.Party); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send party updated message", sendErr)
+	}
+	return true
+}
+
+var OnPartyDisbanded = func(self ServiceI, event EventI) bool {
+	clientsManager := self.(*ClientsManager)
+	payload := event.Payload().(*mm.PartyDisbandedEventPayload)
+
+	sendDeps := NewSendDirectDeps(clientsManager.DirectMessage, payload.ClientKey)
+	if sendErr := SendPartyDisbanded(sendDeps, payload.Code, payload.Reason); sendErr != nil {
+		clientsManager.Logger.LogRed(models.ENV_CLIENT_MNGR, "could not send party disbanded message", sendErr)
+	}
+	return true
+}
@@ -1,6 +1,8 @@
 package clients_manager_test
 
 import (
+	"fmt"
+	"github.com/CameronHonis/chess"
 	"github.com/CameronHonis/chess-arbitrator/auth"
 	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
 	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
@@ -9,9 +11,14 @@ import (
 	"github.com/CameronHonis/service"
 	"github.com/CameronHonis/service/test_helpers"
 	"github.com/CameronHonis/set"
+	"github.com/gorilla/websocket"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
 )
 
 func CreateServices(ctrl *gomock.Controller) *cm.ClientsManager {
@@ -37,16 +44,106 @@ func CreateServices(ctrl *gomock.Controller) *cm.ClientsManager {
 	matcherServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
 	matcherServiceMock.EXPECT().Build().AnyTimes()
 
+	penaltyServiceMock := mocks.NewMockPenaltyServiceI(ctrl)
+	penaltyServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	penaltyServiceMock.EXPECT().Build().AnyTimes()
+
 	ucs := cm.NewClientsManager(cm.NewClientsManagerConfig(make(map[models.ContentType]cm.MessageHandler)))
 	ucs.AddDependency(subServiceMock)
 	ucs.AddDependency(authServiceMock)
 	ucs.AddDependency(loggerServiceMock)
 	ucs.AddDependency(matchmakingMock)
 	ucs.AddDependency(matcherServiceMock)
+	ucs.AddDependency(penaltyServiceMock)
 
 	return ucs
 }
 
+// ConnFixture serves a clients manager over a real websocket, for specs that exercise a live connection
+type ConnFixture struct {
+	ClientsManager *cm.ClientsManager
+	Server         *httptest.Server
+	Conn           *websocket.Conn
+	// receives the key of each client the clients manager deregisters
+	DeregisteredKeys chan models.Key
+}
+
+// NewConnFixture dials a clients manager built by CreateServices, after configure has had its say on the config. The
+// connection is not authenticated until RefreshAuth is called, after which it belongs to "some-client-key".
+func NewConnFixture(configure func(config *cm.ClientsManagerConfig)) *ConnFixture {
+	ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+	clientsManager := CreateServices(ctrl)
+	configure(clientsManager.Config().(*cm.ClientsManagerConfig))
+
+	authServiceMock := clientsManager.AuthService.(*mocks.MockAuthenticationServiceI)
+	authServiceMock.EXPECT().CreateNewClient().Return(&models.AuthCreds{ClientKey: "some-client-key"}).AnyTimes()
+	authServiceMock.EXPECT().GetRole(gomock.Any()).Return(models.PLEB, nil).AnyTimes()
+	matcherServiceMock := clientsManager.MatcherService.(*mocks.MockMatcherServiceI)
+	matcherServiceMock.EXPECT().MatchByClientKey(gomock.Any()).Return(nil, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no match")).AnyTimes()
+	matcherServiceMock.EXPECT().AllChallenges(gomock.Any()).Return(set.EmptySet[*models.Challenge]()).AnyTimes()
+	// NOTE: each fixture gets its own channel, since connections from earlier specs may still be winding down
+	deregisteredKeys := make(chan models.Key, 1)
+	matcherServiceMock.EXPECT().RevokeAllChallenges(gomock.Any()).Do(func(clientKey models.Key) {
+		deregisteredKeys <- clientKey
+	}).AnyTimes()
+	matchmakingMock := clientsManager.MatchmakingService.(*mocks.MockMatchmakingServiceI)
+	matchmakingMock.EXPECT().RemoveClient(gomock.Any(), gomock.Any()).AnyTimes()
+	matchmakingMock.EXPECT().LeaveParty(gomock.Any()).AnyTimes()
+	matchmakingMock.EXPECT().ForgetClient(gomock.Any()).AnyTimes()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverConn, err := upgrader.Upgrade(w, r, nil)
+		if err == nil {
+			clientsManager.AddConn(serverConn)
+		}
+	}))
+	conn, _, dialErr := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	Expect(dialErr).ToNot(HaveOccurred())
+	return &ConnFixture{
+		ClientsManager:   clientsManager,
+		Server:           server,
+		Conn:             conn,
+		DeregisteredKeys: deregisteredKeys,
+	}
+}
+
+// RefreshAuth authenticates the connection as a new client and waits until the clients manager has registered it
+func (f *ConnFixture) RefreshAuth() {
+	f.Send(&models.Message{
+		ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
+		Content:     &models.RefreshAuthMessageContent{},
+	})
+	Eventually(func() error {
+		_, err := f.ClientsManager.OutboundQueueStats("some-client-key")
+		return err
+	}).Should(Succeed())
+}
+
+func (f *ConnFixture) Send(msg *models.Message) {
+	msgJson, marshalErr := msg.Marshal()
+	Expect(marshalErr).ToNot(HaveOccurred())
+	Expect(f.Conn.WriteMessage(websocket.TextMessage, msgJson)).To(Succeed())
+}
+
+// NextMessage reads off the connection until a message of the content type arrives
+func (f *ConnFixture) NextMessage(contentType models.ContentType) *models.Message {
+	Expect(f.Conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+	for {
+		_, rawMsg, readErr := f.Conn.ReadMessage()
+		Expect(readErr).ToNot(HaveOccurred())
+		msg, unmarshalErr := models.UnmarshalToMessage(rawMsg)
+		if unmarshalErr == nil && msg.ContentType == contentType {
+			return msg
+		}
+	}
+}
+
+func (f *ConnFixture) Close() {
+	_ = f.Conn.Close()
+	f.Server.Close()
+}
+
 type TestMessageContentType struct {
 	SomePayload string `json:"somePayload"`
 }
@@ -177,3 +274,237 @@ var _ = Describe("side effects", func() {
 		})
 	})
 })
+
+var _ = Describe("replies", func() {
+	var sentMsgs []*models.Message
+	var writer cm.DirectMessageFn
+	BeforeEach(func() {
+		sentMsgs = make([]*models.Message, 0)
+		writer = func(msg *models.Message, _ models.Key) error {
+			sentMsgs = append(sentMsgs, msg)
+			return nil
+		}
+	})
+	It("echoes the request id on replies", func() {
+		sendDeps := cm.NewSendReplyDeps(writer, "some-client-key", "req-1")
+		Expect(cm.SendAck(sendDeps, models.CONTENT_TYPE_JOIN_MATCHMAKING)).To(Succeed())
+		Expect(sentMsgs).To(HaveLen(1))
+		Expect(sentMsgs[0].RequestId).To(Equal("req-1"))
+		Expect(sentMsgs[0].Content).To(Equal(&models.AckMessageContent{RequestContentType: models.CONTENT_TYPE_JOIN_MATCHMAKING}))
+	})
+	It("sends the code and message of a failed request", func() {
+		sendDeps := cm.NewSendReplyDeps(writer, "some-client-key", "req-2")
+		protocolErr := models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no match with id %s", "some-match")
+		Expect(cm.SendError(sendDeps, models.CONTENT_TYPE_RESIGN_MATCH, protocolErr)).To(Succeed())
+		Expect(sentMsgs[0].ContentType).To(Equal(models.CONTENT_TYPE_ERROR))
+		Expect(sentMsgs[0].RequestId).To(Equal("req-2"))
+		Expect(sentMsgs[0].Content).To(Equal(&models.ErrorMessageContent{
+			Code:               models.ERROR_CODE_NOT_FOUND,
+			Message:            "no match with id some-match",
+			RequestContentType: models.CONTENT_TYPE_RESIGN_MATCH,
+		}))
+	})
+})
+
+var _ = Describe("REFRESH_AUTH", func() {
+	var fixture *ConnFixture
+	BeforeEach(func() {
+		fixture = NewConnFixture(func(config *cm.ClientsManagerConfig) {})
+	})
+	AfterEach(func() {
+		fixture.Close()
+	})
+	It("acks the refresh, echoing its request id", func() {
+		fixture.Send(&models.Message{
+			ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
+			Content:     &models.RefreshAuthMessageContent{},
+			RequestId:   "req-1",
+		})
+		ack := fixture.NextMessage(models.CONTENT_TYPE_ACK)
+		Expect(ack.RequestId).To(Equal("req-1"))
+		Expect(ack.Content).To(Equal(&models.AckMessageContent{RequestContentType: models.CONTENT_TYPE_REFRESH_AUTH}))
+	})
+	It("replies on the connection itself to requests made before authenticating", func() {
+		authServiceMock := fixture.ClientsManager.AuthService.(*mocks.MockAuthenticationServiceI)
+		authServiceMock.EXPECT().VetAuthInMessage(gomock.Any()).Return(fmt.Errorf("no creds")).AnyTimes()
+		fixture.Send(&models.Message{
+			ContentType: models.CONTENT_TYPE_ECHO,
+			Content:     &models.EchoMessageContent{Message: "hi"},
+			RequestId:   "req-2",
+		})
+		errMsg := fixture.NextMessage(models.CONTENT_TYPE_ERROR)
+		Expect(errMsg.RequestId).To(Equal("req-2"))
+		Expect(errMsg.Content.(*models.ErrorMessageContent).Code).To(Equal(models.ERROR_CODE_UNAUTHORIZED))
+	})
+	When("the creds are already in use on another connection", func() {
+		var authServiceMock *mocks.MockAuthenticationServiceI
+		var otherFixture *ConnFixture
+		BeforeEach(func() {
+			authServiceMock = fixture.ClientsManager.AuthService.(*mocks.MockAuthenticationServiceI)
+			fixture.RefreshAuth()
+			otherConn, _, dialErr := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(fixture.Server.URL, "http"), nil)
+			Expect(dialErr).ToNot(HaveOccurred())
+			DeferCleanup(otherConn.Close)
+			otherFixture = &ConnFixture{ClientsManager: fixture.ClientsManager, Server: fixture.Server, Conn: otherConn}
+		})
+		refreshOnOtherConn := func() *models.Message {
+			otherFixture.Send(&models.Message{
+				ContentType: models.CONTENT_TYPE_REFRESH_AUTH,
+				Content: &models.RefreshAuthMessageContent{
+					ExistingAuth: &models.AuthMessageContent{PublicKey: "some-client-key", PrivateKey: "some-private-key"},
+				},
+				RequestId: "req-3",
+			})
+			return otherFixture.NextMessage(models.CONTENT_TYPE_ACK)
+		}
+		It("moves the client onto the new connection and closes the stale one", func() {
+			authServiceMock.EXPECT().RefreshPrivateKey(models.Key("some-client-key"), models.Key("some-private-key")).Return(nil)
+			Expect(refreshOnOtherConn().RequestId).To(Equal("req-3"))
+			Eventually(fixture.DeregisteredKeys).Should(Receive(Equal(models.Key("some-client-key"))))
+
+			Expect(fixture.Conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+			var readErr error
+			for readErr == nil {
+				_, _, readErr = fixture.Conn.ReadMessage()
+			}
+			Expect(websocket.IsCloseError(readErr, websocket.CloseNormalClosure)).To(BeTrue())
+			Expect(readErr.(*websocket.CloseError).Text).To(Equal("replaced by a new connection"))
+			Consistently(func() error {
+				_, err := fixture.ClientsManager.OutboundQueueStats("some-client-key")
+				return err
+			}, 100*time.Millisecond).Should(Succeed())
+			Expect(fixture.DeregisteredKeys).ToNot(Receive())
+		})
+		When("the creds are wrong", func() {
+			It("leaves the connected client alone", func() {
+				authServiceMock.EXPECT().RefreshPrivateKey(gomock.Any(), gomock.Any()).Return(fmt.Errorf("invalid creds"))
+				authServiceMock.EXPECT().CreateNewClient().Return(&models.AuthCreds{ClientKey: "other-client-key"})
+				refreshOnOtherConn()
+				Consistently(fixture.DeregisteredKeys, 100*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+	})
+})
+
+var _ = Describe("Handshake", func() {
+	var clientsManager *cm.ClientsManager
+	BeforeEach(func() {
+		clientsManager = CreateServices(gomock.NewController(GinkgoT()))
+	})
+	When("the client has not shaken hands", func() {
+		It("speaks version 1 with no features", func() {
+			Expect(clientsManager.ProtocolVersion("some-client-key")).To(Equal(models.PROTOCOL_VERSION_1))
+			Expect(clientsManager.HasFeature("some-client-key", models.FEATURE_REQUEST_IDS)).To(BeFalse())
+		})
+	})
+	When("the client declares a supported version", func() {
+		var welcome *models.WelcomeMessageContent
+		BeforeEach(func() {
+			config := clientsManager.Config().(*cm.ClientsManagerConfig)
+			config.EnabledFeatures = []models.Feature{models.FEATURE_REQUEST_IDS, models.FEATURE_MOVE_HISTORY}
+			var err error
+			welcome, err = clientsManager.Handshake("some-client-key", &models.HelloMessageContent{
+				ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+				Features:        []models.Feature{models.FEATURE_MOVE_HISTORY, models.FEATURE_PARTIES},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("replies with the negotiated version and features", func() {
+			Expect(welcome).To(Equal(&models.WelcomeMessageContent{
+				ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+				Features:        []models.Feature{models.FEATURE_MOVE_HISTORY},
+			}))
+		})
+		It("remembers the negotiated version and features", func() {
+			Expect(clientsManager.ProtocolVersion("some-client-key")).To(Equal(models.CURRENT_PROTOCOL_VERSION))
+			Expect(clientsManager.HasFeature("some-client-key", models.FEATURE_MOVE_HISTORY)).To(BeTrue())
+			Expect(clientsManager.HasFeature("some-client-key", models.FEATURE_PARTIES)).To(BeFalse())
+		})
+	})
+	When("the client declares an unsupported version", func() {
+		It("rejects the handshake with a bad request", func() {
+			_, err := clientsManager.Handshake("some-client-key", &models.HelloMessageContent{ProtocolVersion: 0})
+			Expect(models.AsProtocolError(err, models.ERROR_CODE_INVALID_STATE).Code).To(Equal(models.ERROR_CODE_BAD_REQUEST))
+			Expect(clientsManager.ProtocolVersion("some-client-key")).To(Equal(models.PROTOCOL_VERSION_1))
+		})
+	})
+})
+
+var _ = Describe("negotiated features", func() {
+	var fixture *ConnFixture
+	BeforeEach(func() {
+		fixture = NewConnFixture(func(config *cm.ClientsManagerConfig) {})
+		authServiceMock := fixture.ClientsManager.AuthService.(*mocks.MockAuthenticationServiceI)
+		authServiceMock.EXPECT().VetAuthInMessage(gomock.Any()).Return(nil).AnyTimes()
+		authServiceMock.EXPECT().StripAuthFromMessage(gomock.Any()).AnyTimes()
+		fixture.RefreshAuth()
+	})
+	AfterEach(func() {
+		fixture.Close()
+	})
+	Describe("request ids", func() {
+		// NOTE: the fixture has no handlers configured, so every ECHO fails with a bad request
+		sendEcho := func(features []models.Feature) {
+			_, err := fixture.ClientsManager.Handshake("some-client-key", &models.HelloMessageContent{
+				ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+				Features:        features,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			fixture.Send(&models.Message{
+				ContentType: models.CONTENT_TYPE_ECHO,
+				Content:     &models.EchoMessageContent{Message: "hi"},
+				RequestId:   "req-1",
+			})
+		}
+		It("replies to clients that negotiated them", func() {
+			sendEcho([]models.Feature{models.FEATURE_REQUEST_IDS})
+			Expect(fixture.NextMessage(models.CONTENT_TYPE_ERROR).RequestId).To(Equal("req-1"))
+		})
+		It("does not reply to clients that did not", func() {
+			sendEcho([]models.Feature{models.FEATURE_MOVE_HISTORY})
+			Expect(fixture.Conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))).To(Succeed())
+			for {
+				_, rawMsg, readErr := fixture.Conn.ReadMessage()
+				if readErr != nil {
+					break
+				}
+				msg, unmarshalErr := models.UnmarshalToMessage(rawMsg)
+				Expect(unmarshalErr).ToNot(HaveOccurred())
+				Expect(msg.ContentType).ToNot(BeElementOf(models.CONTENT_TYPE_ACK, models.CONTENT_TYPE_ERROR))
+			}
+		})
+	})
+	Describe("move history", func() {
+		var matchUpdateMsg *models.Message
+		BeforeEach(func() {
+			move := chess.Move{}
+			matchUpdateMsg = &models.Message{
+				ContentType: models.CONTENT_TYPE_MATCH_UPDATED,
+				Content: &models.MatchUpdateMessageContent{
+					Match: &models.Match{Uuid: "some-match-id", LastMove: &move, MoveHistory: []*chess.Move{&move}},
+				},
+			}
+		})
+		It("is sent to clients that negotiated it", func() {
+			_, err := fixture.ClientsManager.Handshake("some-client-key", &models.HelloMessageContent{
+				ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+				Features:        []models.Feature{models.FEATURE_MOVE_HISTORY},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fixture.ClientsManager.DirectMessage(matchUpdateMsg, "some-client-key")).To(Succeed())
+			matchUpdate := fixture.NextMessage(models.CONTENT_TYPE_MATCH_UPDATED).Content.(*models.MatchUpdateMessageContent)
+			Expect(matchUpdate.Match.MoveHistory).To(HaveLen(1))
+		})
+		It("is stripped for clients on the current version that did not", func() {
+			_, err := fixture.ClientsManager.Handshake("some-client-key", &models.HelloMessageContent{
+				ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+				Features:        []models.Feature{models.FEATURE_REQUEST_IDS},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fixture.ClientsManager.DirectMessage(matchUpdateMsg, "some-client-key")).To(Succeed())
+			matchUpdate := fixture.NextMessage(models.CONTENT_TYPE_MATCH_UPDATED).Content.(*models.MatchUpdateMessageContent)
+			Expect(matchUpdate.Match.Uuid).To(Equal("some-match-id"))
+			Expect(matchUpdate.Match.MoveHistory).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,149 @@
+package clients_manager
+
+import (
+	"fmt"
+	"github.com/gorilla/websocket"
+	"sync"
+	"time"
+)
+
+// OutboundQueueStats describes the backlog of messages waiting to be written to a connection
+type OutboundQueueStats struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+	// droppable messages discarded because the queue was full, over the life of the connection
+	Dropped int `json:"dropped"`
+}
+
+// connWriter owns all data frame writes to a connection, so a slow client only ever backs up its own queue
+type connWriter struct {
+	conn         *websocket.Conn
+	queue        chan []byte
+	writeTimeout time.Duration
+	maxDropped   int
+
+	dropped            int
+	consecutiveDropped int
+	done               chan struct{}
+	stopOnce           sync.Once
+	closing            chan struct{}
+	closeCode          int
+	closeReason        string
+	closeOnce          sync.Once
+	mu                 sync.Mutex
+}
+
+func newConnWriter(conn *websocket.Conn, config *ClientsManagerConfig) *connWriter {
+	return &connWriter{
+		conn:         conn,
+		queue:        make(chan []byte, config.SendBufferSize),
+		writeTimeout: config.WriteTimeout,
+		maxDropped:   config.MaxDroppedMessages,
+		done:         make(chan struct{}),
+		closing:      make(chan struct{}),
+	}
+}
+
+// run writes queued messages until stopped. A failed write closes the connection, which fails the pending read in
+// listenOnConn and deregisters the client.
+func (w *connWriter) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.closing:
+			w.drainAndClose()
+			return
+		case msgJson := <-w.queue:
+			if err := w.write(msgJson); err != nil {
+				w.stop()
+				_ = w.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (w *connWriter) write(msgJson []byte) error {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+	return w.conn.WriteMessage(websocket.TextMessage, msgJson)
+}
+
+// closeAfterDrain closes the connection once the messages already queued, like the error explaining why, are written.
+// The client is sent a close frame carrying the reason.
+func (w *connWriter) closeAfterDrain(code int, reason string) {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closeCode = code
+		w.closeReason = reason
+		w.mu.Unlock()
+		close(w.closing)
+	})
+}
+
+func (w *connWriter) drainAndClose() {
+	defer func() {
+		w.stop()
+		_ = w.conn.Close()
+	}()
+	for {
+		select {
+		case msgJson := <-w.queue:
+			if err := w.write(msgJson); err != nil {
+				return
+			}
+		default:
+			w.mu.Lock()
+			closeMsg := websocket.FormatCloseMessage(w.closeCode, w.closeReason)
+			w.mu.Unlock()
+			_ = w.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(w.writeTimeout))
+			return
+		}
+	}
+}
+
+// enqueue queues the message without blocking. When the queue is full a droppable message is discarded, but the
+// client is disconnected once it has missed too many in a row, or as soon as a message it must receive doesn't fit.
+func (w *connWriter) enqueue(msgJson []byte, isDroppable bool) error {
+	select {
+	case <-w.done:
+		return fmt.Errorf("connection is closed")
+	case w.queue <- msgJson:
+		w.mu.Lock()
+		w.consecutiveDropped = 0
+		w.mu.Unlock()
+		return nil
+	default:
+	}
+
+	w.mu.Lock()
+	if isDroppable {
+		w.dropped++
+		w.consecutiveDropped++
+	}
+	isTooSlow := !isDroppable || w.consecutiveDropped > w.maxDropped
+	w.mu.Unlock()
+
+	if isTooSlow {
+		w.stop()
+		_ = w.conn.Close()
+		return fmt.Errorf("disconnected slow consumer with %d queued messages", len(w.queue))
+	}
+	return fmt.Errorf("dropped message for slow consumer with %d queued messages", len(w.queue))
+}
+
+func (w *connWriter) stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+}
+
+func (w *connWriter) stats() *OutboundQueueStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &OutboundQueueStats{
+		Depth:    len(w.queue),
+		Capacity: cap(w.queue),
+		Dropped:  w.dropped,
+	}
+}
@@ -0,0 +1,104 @@
+package clients_manager_test
+
+import (
+	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/set"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"time"
+)
+
+var _ = Describe("outbound queue", func() {
+	var fixture *ConnFixture
+	var clientsManager *cm.ClientsManager
+	var conn *websocket.Conn
+	var broadcast func(payloadSize int)
+	BeforeEach(func() {
+		fixture = NewConnFixture(func(config *cm.ClientsManagerConfig) {
+			config.PingInterval = 10 * time.Second
+			config.PongTimeout = 10 * time.Second
+			config.WriteTimeout = 10 * time.Second
+			config.SendBufferSize = 2
+			config.MaxDroppedMessages = 4
+		})
+		clientsManager = fixture.ClientsManager
+		conn = fixture.Conn
+		subServiceMock := clientsManager.SubService.(*mocks.MockSubscriptionServiceI)
+		subServiceMock.EXPECT().ClientKeysSubbedToTopic(gomock.Eq(models.MessageTopic("some-topic"))).
+			Return(set.FromSlice([]models.Key{"some-client-key"})).AnyTimes()
+
+		broadcast = func(payloadSize int) {
+			clientsManager.BroadcastMessage(&models.Message{
+				Topic:       "some-topic",
+				ContentType: models.CONTENT_TYPE_ERROR,
+				Content:     &models.ErrorMessageContent{Message: strings.Repeat("x", payloadSize)},
+			})
+		}
+		fixture.RefreshAuth()
+	})
+	AfterEach(func() {
+		fixture.Close()
+	})
+	It("reports the queue capacity", func() {
+		stats, err := clientsManager.OutboundQueueStats("some-client-key")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stats.Capacity).To(Equal(2))
+	})
+	When("the client keeps up", func() {
+		It("delivers every broadcast", func() {
+			received := make(chan []byte, 16)
+			go func() {
+				for {
+					_, rawMsg, err := conn.ReadMessage()
+					if err != nil {
+						return
+					}
+					received <- rawMsg
+				}
+			}()
+			for i := 0; i < 10; i++ {
+				broadcast(16)
+				Eventually(received).Should(Receive(ContainSubstring(`"topic":"some-topic"`)))
+			}
+			stats, _ := clientsManager.OutboundQueueStats("some-client-key")
+			Expect(stats.Dropped).To(BeZero())
+		})
+	})
+	When("the client stops reading", func() {
+		It("drops broadcasts and then disconnects the client", func() {
+			maxDropped := 0
+			Eventually(func() <-chan models.Key {
+				broadcast(1 << 20)
+				if stats, err := clientsManager.OutboundQueueStats("some-client-key"); err == nil && stats.Dropped > maxDropped {
+					maxDropped = stats.Dropped
+				}
+				return fixture.DeregisteredKeys
+			}, 5*time.Second, time.Millisecond).Should(Receive(Equal(models.Key("some-client-key"))))
+			Expect(maxDropped).To(BeNumerically(">", 0))
+		})
+	})
+	When("a player stops reading the updates to their match", func() {
+		It("disconnects the player instead of dropping an update", func() {
+			maxDropped := 0
+			Eventually(func() <-chan models.Key {
+				clientsManager.BroadcastMessage(&models.Message{
+					Topic:       "some-topic",
+					ContentType: models.CONTENT_TYPE_MATCH_UPDATED,
+					Content: &models.MatchUpdateMessageContent{
+						Match: &models.Match{Uuid: strings.Repeat("x", 1<<20), WhiteClientKey: "some-client-key"},
+					},
+				})
+				if stats, err := clientsManager.OutboundQueueStats("some-client-key"); err == nil && stats.Dropped > maxDropped {
+					maxDropped = stats.Dropped
+				}
+				return fixture.DeregisteredKeys
+			}, 5*time.Second, time.Millisecond).Should(Receive(Equal(models.Key("some-client-key"))))
+			Expect(maxDropped).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,143 @@
+package clients_manager
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+)
+
+// StreamEvent is a broadcast as delivered to an event stream. Ids increase with every broadcast, across all topics.
+type StreamEvent struct {
+	Id          uint64
+	Topic       models.MessageTopic
+	ContentType models.ContentType
+	Data        []byte
+}
+
+// EventStream follows a single topic on behalf of a read-only consumer, such as a server-sent events request. The
+// stream is subscribed under its own key, so it receives the same broadcasts as websocket clients on the topic.
+type EventStream struct {
+	Key   models.Key
+	Topic models.MessageTopic
+
+	events chan *StreamEvent
+	// the id of the last event queued, so an event reaching the stream both live and from the replay buffer is only
+	// queued once
+	lastPushedId uint64
+	done         chan struct{}
+	closeOnce    sync.Once
+	mu           sync.Mutex
+}
+
+func (s *EventStream) Events() <-chan *StreamEvent {
+	return s.events
+}
+
+// Done is closed once the stream stops receiving events, either because it was closed or because its consumer fell
+// too far behind. Consumers that fell behind can resume from their last event id.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *EventStream) push(event *StreamEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.Id <= s.lastPushedId {
+		return true
+	}
+	select {
+	case <-s.done:
+		return false
+	case s.events <- event:
+		s.lastPushedId = event.Id
+		return true
+	default:
+		s.close()
+		return false
+	}
+}
+
+func (s *EventStream) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// IsPublicTopic reports whether an event stream may follow the topic without creds
+func (c *ClientsManager) IsPublicTopic(topic models.MessageTopic) bool {
+	config := c.Config().(*ClientsManagerConfig)
+	for _, prefix := range config.PublicStreamTopicPrefixes {
+		if strings.HasPrefix(string(topic), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenStream subscribes a new event stream to the topic. Events broadcast after lastEventId that are still in the
+// replay buffer are queued on the stream first, so a consumer that reconnects picks up where it left off.
+func (c *ClientsManager) OpenStream(topic models.MessageTopic, lastEventId uint64) (*EventStream, error) {
+	config := c.Config().(*ClientsManagerConfig)
+	stream := &EventStream{
+		Key:    models.Key(fmt.Sprintf("stream-%s", uuid.New().String())),
+		Topic:  topic,
+		events: make(chan *StreamEvent, config.SendBufferSize),
+		done:   make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	if lastEventId == 0 {
+		lastEventId = c.lastEventId
+	}
+	c.mu.Unlock()
+
+	if subErr := c.SubService.SubClient(stream.Key, topic); subErr != nil {
+		return nil, models.AsProtocolError(subErr, models.ERROR_CODE_UNAUTHORIZED)
+	}
+
+	// NOTE: broadcasts made while subscribing skipped the stream, so they're replayed along with the missed ones
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, event := range c.replayBuffer {
+		if event.Id > lastEventId && event.Topic == topic && !stream.push(event) {
+			break
+		}
+	}
+	c.streamByKey[stream.Key] = stream
+	return stream, nil
+}
+
+func (c *ClientsManager) CloseStream(stream *EventStream) {
+	stream.close()
+	c.SubService.UnsubClientFromAll(stream.Key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.streamByKey, stream.Key)
+}
+
+func (c *ClientsManager) getStreamByKey(key models.Key) *EventStream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.streamByKey[key]
+}
+
+// recordBroadcast numbers the broadcast and keeps it for streams that resume later
+func (c *ClientsManager) recordBroadcast(msg *models.Message, msgJson []byte) *StreamEvent {
+	config := c.Config().(*ClientsManagerConfig)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastEventId++
+	event := &StreamEvent{
+		Id:          c.lastEventId,
+		Topic:       msg.Topic,
+		ContentType: msg.ContentType,
+		Data:        msgJson,
+	}
+	c.replayBuffer = append(c.replayBuffer, event)
+	if len(c.replayBuffer) > config.StreamReplaySize {
+		c.replayBuffer = c.replayBuffer[len(c.replayBuffer)-config.StreamReplaySize:]
+	}
+	return event
+}
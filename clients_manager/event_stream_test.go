@@ -0,0 +1,96 @@
+package clients_manager_test
+
+import (
+	"fmt"
+	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/set"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("event streams", func() {
+	var clientsManager *cm.ClientsManager
+	var subServiceMock *mocks.MockSubscriptionServiceI
+	var topic models.MessageTopic
+	var broadcast func(n int)
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		clientsManager = CreateServices(ctrl)
+		config := clientsManager.Config().(*cm.ClientsManagerConfig)
+		config.SendBufferSize = 4
+		config.StreamReplaySize = 4
+
+		topic = "match-some-uuid"
+		subbedKeys := set.EmptySet[models.Key]()
+		subServiceMock = clientsManager.SubService.(*mocks.MockSubscriptionServiceI)
+		subServiceMock.EXPECT().SubClient(gomock.Any(), gomock.Eq(topic)).DoAndReturn(func(clientKey models.Key, _ models.MessageTopic) error {
+			subbedKeys.Add(clientKey)
+			return nil
+		}).AnyTimes()
+		subServiceMock.EXPECT().UnsubClientFromAll(gomock.Any()).Do(func(clientKey models.Key) {
+			subbedKeys.Remove(clientKey)
+		}).AnyTimes()
+		subServiceMock.EXPECT().ClientKeysSubbedToTopic(gomock.Eq(topic)).DoAndReturn(func(models.MessageTopic) *set.Set[models.Key] {
+			return set.FromSlice(subbedKeys.Flatten())
+		}).AnyTimes()
+
+		broadcast = func(n int) {
+			for i := 0; i < n; i++ {
+				clientsManager.BroadcastMessage(&models.Message{
+					Topic:       topic,
+					ContentType: models.CONTENT_TYPE_ECHO,
+					Content:     &models.EchoMessageContent{Message: fmt.Sprint(i)},
+				})
+			}
+		}
+	})
+	It("receives broadcasts on its topic", func() {
+		stream, err := clientsManager.OpenStream(topic, 0)
+		Expect(err).ToNot(HaveOccurred())
+		broadcast(2)
+		var first, second *cm.StreamEvent
+		Eventually(stream.Events()).Should(Receive(&first))
+		Eventually(stream.Events()).Should(Receive(&second))
+		Expect(first.ContentType).To(Equal(models.CONTENT_TYPE_ECHO))
+		Expect(string(first.Data)).To(ContainSubstring(`"topic":"match-some-uuid"`))
+		Expect(second.Id).To(Equal(first.Id + 1))
+	})
+	It("doesn't replay broadcasts from before it opened", func() {
+		broadcast(2)
+		stream, _ := clientsManager.OpenStream(topic, 0)
+		Consistently(stream.Events()).ShouldNot(Receive())
+	})
+	It("resumes after the last event id", func() {
+		stream, _ := clientsManager.OpenStream(topic, 0)
+		broadcast(3)
+		var first *cm.StreamEvent
+		Eventually(stream.Events()).Should(Receive(&first))
+		clientsManager.CloseStream(stream)
+
+		resumedStream, _ := clientsManager.OpenStream(topic, first.Id)
+		var resumed *cm.StreamEvent
+		Eventually(resumedStream.Events()).Should(Receive(&resumed))
+		Expect(resumed.Id).To(Equal(first.Id + 1))
+		Eventually(resumedStream.Events()).Should(Receive(&resumed))
+		Expect(resumed.Id).To(Equal(first.Id + 2))
+		Consistently(resumedStream.Events()).ShouldNot(Receive())
+	})
+	It("closes a stream that falls behind", func() {
+		stream, _ := clientsManager.OpenStream(topic, 0)
+		broadcast(5)
+		Eventually(stream.Done()).Should(BeClosed())
+	})
+	When("the topic ACL rejects the stream", func() {
+		BeforeEach(func() {
+			subServiceMock.EXPECT().SubClient(gomock.Any(), gomock.Any()).Return(fmt.Errorf("not allowed")).AnyTimes()
+		})
+		It("returns an unauthorized error", func() {
+			_, err := clientsManager.OpenStream("challenge-some-uuid", 0)
+			Expect(err).To(HaveOccurred())
+			Expect(models.AsProtocolError(err, "").Code).To(Equal(models.ERROR_CODE_UNAUTHORIZED))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package clients_manager
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/log"
+	"github.com/gorilla/websocket"
+	"strconv"
+	"time"
+)
+
+// armHeartbeat has every pong and message extend the read deadline of the connection. Must be called by the
+// goroutine that reads from the connection, before its first read.
+func (c *ClientsManager) armHeartbeat(conn *websocket.Conn) {
+	config := c.Config().(*ClientsManagerConfig)
+	_ = conn.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	conn.SetPongHandler(func(appData string) error {
+		c.recordRoundTrip(conn, appData)
+		return conn.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	})
+}
+
+func (c *ClientsManager) extendReadDeadline(conn *websocket.Conn) {
+	config := c.Config().(*ClientsManagerConfig)
+	_ = conn.SetReadDeadline(time.Now().Add(config.PongTimeout))
+}
+
+// pingUntilClosed pings the connection with the time sent until stop is closed. A connection that stops answering
+// runs past its read deadline, which fails the pending read in listenOnConn and deregisters the client the same way
+// as any other read error.
+func (c *ClientsManager) pingUntilClosed(conn *websocket.Conn, stop <-chan struct{}) {
+	config := c.Config().(*ClientsManagerConfig)
+	ticker := time.NewTicker(config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			sentAt := strconv.FormatInt(time.Now().UnixNano(), 10)
+			if pingErr := conn.WriteControl(websocket.PingMessage, []byte(sentAt), time.Now().Add(config.WriteTimeout)); pingErr != nil {
+				c.Logger.LogRed(models.ENV_SERVER, fmt.Sprintf("could not ping connection, closing it: %s", pingErr), log.ALL_BUT_TEST_ENV)
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *ClientsManager) recordRoundTrip(conn *websocket.Conn, appData string) {
+	sentAtNano, parseErr := strconv.ParseInt(appData, 10, 64)
+	if parseErr != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rttByConn[conn] = time.Since(time.Unix(0, sentAtNano))
+}
+
+// RoundTripTime is the latest ping round trip of the client's connection, or 0 if no pong has come back yet
+func (c *ClientsManager) RoundTripTime(clientKey models.Key) (time.Duration, error) {
+	conn, err := c.getConnByKey(clientKey)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rttByConn[conn], nil
+}
@@ -0,0 +1,68 @@
+package clients_manager_test
+
+import (
+	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("heartbeat", func() {
+	var fixture *ConnFixture
+	var clientsManager *cm.ClientsManager
+	var conn *websocket.Conn
+	BeforeEach(func() {
+		fixture = NewConnFixture(func(config *cm.ClientsManagerConfig) {
+			config.PingInterval = 10 * time.Millisecond
+			config.PongTimeout = 50 * time.Millisecond
+			config.WriteTimeout = 50 * time.Millisecond
+		})
+		fixture.RefreshAuth()
+		clientsManager = fixture.ClientsManager
+		conn = fixture.Conn
+	})
+	AfterEach(func() {
+		fixture.Close()
+	})
+	When("the client answers pings", func() {
+		BeforeEach(func() {
+			go func() {
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+		})
+		It("measures the round trip", func() {
+			Eventually(func() time.Duration {
+				rtt, _ := clientsManager.RoundTripTime("some-client-key")
+				return rtt
+			}).Should(BeNumerically(">", 0))
+		})
+		It("keeps the connection past the pong timeout", func() {
+			Consistently(fixture.DeregisteredKeys, 150*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+	When("the client stops answering pings", func() {
+		BeforeEach(func() {
+			conn.SetPingHandler(func(string) error { return nil })
+			go func() {
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+		})
+		It("deregisters the client", func() {
+			Eventually(fixture.DeregisteredKeys).Should(Receive(Equal(models.Key("some-client-key"))))
+			Eventually(func() error {
+				_, err := clientsManager.RoundTripTime("some-client-key")
+				return err
+			}).Should(HaveOccurred())
+		})
+	})
+})
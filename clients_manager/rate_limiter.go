@@ -0,0 +1,85 @@
+package clients_manager
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"time"
+)
+
+// RateLimit is a token bucket that refills at PerSecond tokens a second and holds at most Burst tokens. A zero
+// PerSecond leaves the bucket unlimited.
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+type tokenBucket struct {
+	limit      RateLimit
+	tokens     float64
+	refilledAt time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	return &tokenBucket{
+		limit:      limit,
+		tokens:     float64(limit.Burst),
+		refilledAt: time.Now(),
+	}
+}
+
+func (b *tokenBucket) take() bool {
+	if b.limit.PerSecond <= 0 {
+		return true
+	}
+	now := time.Now()
+	b.tokens += now.Sub(b.refilledAt).Seconds() * b.limit.PerSecond
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.refilledAt = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// connRateLimiter throttles the messages read off a single connection. Only the connection's reader touches it, so
+// it isn't locked.
+type connRateLimiter struct {
+	config        *ClientsManagerConfig
+	overall       *tokenBucket
+	byContentType map[models.ContentType]*tokenBucket
+	violations    *tokenBucket
+}
+
+func newConnRateLimiter(config *ClientsManagerConfig) *connRateLimiter {
+	return &connRateLimiter{
+		config:        config,
+		overall:       newTokenBucket(config.RateLimit),
+		byContentType: make(map[models.ContentType]*tokenBucket),
+		violations:    newTokenBucket(config.ViolationAllowance),
+	}
+}
+
+func (l *connRateLimiter) allowFrame() bool {
+	return l.overall.take()
+}
+
+func (l *connRateLimiter) allowContentType(contentType models.ContentType) bool {
+	limit, ok := l.config.RateLimitByContentType[contentType]
+	if !ok {
+		return true
+	}
+	bucket, ok := l.byContentType[contentType]
+	if !ok {
+		bucket = newTokenBucket(limit)
+		l.byContentType[contentType] = bucket
+	}
+	return bucket.take()
+}
+
+// forgive records a throttled message, reporting false once the client has run out of allowance and should be
+// disconnected
+func (l *connRateLimiter) forgive() bool {
+	return l.violations.take()
+}
@@ -0,0 +1,112 @@
+package clients_manager_test
+
+import (
+	"fmt"
+	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+var _ = Describe("rate limiting", func() {
+	var fixture *ConnFixture
+	var errorCodeByRequestId chan map[string]models.ErrorCode
+	var readErrs chan error
+	var sendEcho func(requestId string)
+	BeforeEach(func() {
+		fixture = NewConnFixture(func(config *cm.ClientsManagerConfig) {
+			config.RateLimit = cm.RateLimit{}
+			config.RateLimitByContentType = map[models.ContentType]cm.RateLimit{
+				models.CONTENT_TYPE_ECHO: {PerSecond: 0.01, Burst: 2},
+			}
+			config.ViolationAllowance = cm.RateLimit{PerSecond: 0.01, Burst: 3}
+		})
+		authServiceMock := fixture.ClientsManager.AuthService.(*mocks.MockAuthenticationServiceI)
+		authServiceMock.EXPECT().VetAuthInMessage(gomock.Any()).Return(nil).AnyTimes()
+		authServiceMock.EXPECT().StripAuthFromMessage(gomock.Any()).AnyTimes()
+
+		errorCodeByRequestId = make(chan map[string]models.ErrorCode, 1)
+		errorCodeByRequestId <- make(map[string]models.ErrorCode)
+		readErrs = make(chan error, 1)
+		// NOTE: the reader outlives the spec until the connection closes, so it holds on to this spec's channels
+		conn, errorCodes, connReadErrs := fixture.Conn, errorCodeByRequestId, readErrs
+		go func() {
+			for {
+				_, rawMsg, err := conn.ReadMessage()
+				if err != nil {
+					connReadErrs <- err
+					return
+				}
+				msg, unmarshalErr := models.UnmarshalToMessage(rawMsg)
+				if unmarshalErr != nil || msg.ContentType != models.CONTENT_TYPE_ERROR {
+					continue
+				}
+				codeByRequestId := <-errorCodes
+				codeByRequestId[msg.RequestId] = msg.Content.(*models.ErrorMessageContent).Code
+				errorCodes <- codeByRequestId
+			}
+		}()
+		sendEcho = func(requestId string) {
+			fixture.Send(&models.Message{
+				ContentType: models.CONTENT_TYPE_ECHO,
+				Content:     &models.EchoMessageContent{Message: "hi"},
+				RequestId:   requestId,
+			})
+		}
+		fixture.RefreshAuth()
+	})
+	AfterEach(func() {
+		fixture.Close()
+	})
+	errorCodeFor := func(requestId string) func() models.ErrorCode {
+		return func() models.ErrorCode {
+			errorCodes := <-errorCodeByRequestId
+			defer func() { errorCodeByRequestId <- errorCodes }()
+			return errorCodes[requestId]
+		}
+	}
+	When("the client stays within its burst", func() {
+		It("doesn't throttle the client", func() {
+			sendEcho("1")
+			sendEcho("2")
+			Consistently(errorCodeFor("2"), 100*time.Millisecond).ShouldNot(Equal(models.ERROR_CODE_RATE_LIMITED))
+		})
+	})
+	When("the client exceeds the limit for a content type", func() {
+		It("replies with a rate_limited error", func() {
+			for i := 1; i <= 3; i++ {
+				sendEcho(fmt.Sprint(i))
+			}
+			Eventually(errorCodeFor("3")).Should(Equal(models.ERROR_CODE_RATE_LIMITED))
+			Expect(errorCodeFor("1")()).ToNot(Equal(models.ERROR_CODE_RATE_LIMITED))
+		})
+		It("keeps the connection while the client has allowance left", func() {
+			for i := 1; i <= 5; i++ {
+				sendEcho(fmt.Sprint(i))
+			}
+			Consistently(fixture.DeregisteredKeys, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+	When("the client keeps flooding", func() {
+		It("disconnects the client", func() {
+			for i := 1; i <= 6; i++ {
+				sendEcho(fmt.Sprint(i))
+			}
+			Eventually(fixture.DeregisteredKeys).Should(Receive(Equal(models.Key("some-client-key"))))
+		})
+		It("tells the client why before closing the connection", func() {
+			for i := 1; i <= 6; i++ {
+				sendEcho(fmt.Sprint(i))
+			}
+			var readErr error
+			Eventually(readErrs).Should(Receive(&readErr))
+			Expect(errorCodeFor("6")()).To(Equal(models.ERROR_CODE_RATE_LIMITED))
+			Expect(websocket.IsCloseError(readErr, websocket.ClosePolicyViolation)).To(BeTrue())
+			Expect(readErr.(*websocket.CloseError).Text).To(Equal("rate limit exceeded"))
+		})
+	})
+})
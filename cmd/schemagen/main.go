@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/schema"
+	"os"
+)
+
+// writes the JSON Schema of the message protocol to the given path, or to stdout when none is given
+func main() {
+	schemaJson, err := schema.Marshal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not generate schema: %s\n", err)
+		os.Exit(1)
+	}
+	if len(os.Args) < 2 {
+		_, _ = os.Stdout.Write(schemaJson)
+		return
+	}
+	if writeErr := os.WriteFile(os.Args[1], schemaJson, 0644); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "could not write schema: %s\n", writeErr)
+		os.Exit(1)
+	}
+}
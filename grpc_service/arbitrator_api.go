@@ -0,0 +1,210 @@
+package grpc_service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	SERVICE_NAME = "arbitrator.Arbitrator"
+
+	CLIENT_KEY_METADATA    = "x-client-key"
+	AUTHORIZATION_METADATA = "authorization"
+)
+
+type Empty struct{}
+
+type MatchRequest struct {
+	MatchId string `json:"matchId"`
+}
+
+type MatchList struct {
+	Matches []*models.Match `json:"matches"`
+}
+
+type RecentMatchesRequest struct {
+	ClientKey models.Key `json:"clientKey"`
+	// how many matches to return, newest first. Defaults to models.DEFAULT_PAGE_LIMIT.
+	Limit int `json:"limit"`
+}
+
+type WatchTopicRequest struct {
+	Topic models.MessageTopic `json:"topic"`
+	// resume after this event, if it's still buffered
+	LastEventId uint64 `json:"lastEventId"`
+}
+
+// TopicEvent is a broadcast on a watched topic. Data holds the message exactly as websocket clients receive it.
+type TopicEvent struct {
+	Id          uint64              `json:"id"`
+	Topic       models.MessageTopic `json:"topic"`
+	ContentType models.ContentType  `json:"contentType"`
+	Data        json.RawMessage     `json:"data"`
+}
+
+var arbitratorServiceDesc = grpc.ServiceDesc{
+	ServiceName: SERVICE_NAME,
+	HandlerType: (*GrpcServiceI)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("GetMatch", (*GrpcService).GetMatch),
+		unaryMethod("LiveMatches", (*GrpcService).LiveMatches),
+		unaryMethod("RecentMatches", (*GrpcService).RecentMatches),
+		unaryMethod("RequestChallenge", (*GrpcService).RequestChallenge),
+		unaryMethod("AcceptChallenge", (*GrpcService).AcceptChallenge),
+		unaryMethod("JoinMatchmaking", (*GrpcService).JoinMatchmaking),
+	},
+	Streams: []grpc.StreamDesc{
+		serverStream("WatchMatch", (*GrpcService).WatchMatch),
+		serverStream("WatchTopic", (*GrpcService).WatchTopic),
+	},
+}
+
+func unaryMethod[Req any, Res any](name string, call func(*GrpcService, context.Context, *Req) (*Res, error)) grpc.MethodDesc {
+	handler := func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(Req)
+		if decErr := dec(req); decErr != nil {
+			return nil, decErr
+		}
+		invoke := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(*GrpcService), ctx, req.(*Req))
+		}
+		if interceptor == nil {
+			return invoke(ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(name)}
+		return interceptor(ctx, req, info, invoke)
+	}
+	return grpc.MethodDesc{MethodName: name, Handler: handler}
+}
+
+func serverStream[Req any](name string, call func(*GrpcService, *Req, grpc.ServerStream) error) grpc.StreamDesc {
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		req := new(Req)
+		if recvErr := stream.RecvMsg(req); recvErr != nil {
+			return recvErr
+		}
+		return call(srv.(*GrpcService), req, stream)
+	}
+	return grpc.StreamDesc{StreamName: name, Handler: handler, ServerStreams: true}
+}
+
+func fullMethod(name string) string {
+	return "/" + SERVICE_NAME + "/" + name
+}
+
+// ArbitratorClient is the typed client for the API, for backend services dialing the arbitrator. Calls that act on
+// behalf of a client need its creds, attached to the context with WithCreds.
+type ArbitratorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArbitratorClient(cc grpc.ClientConnInterface) *ArbitratorClient {
+	return &ArbitratorClient{cc: cc}
+}
+
+// WithCreds attaches the client's creds to the outgoing context, for calls made on its behalf
+func WithCreds(ctx context.Context, creds *models.AuthMessageContent) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		CLIENT_KEY_METADATA, string(creds.PublicKey),
+		AUTHORIZATION_METADATA, "Bearer "+string(creds.PrivateKey),
+	)
+}
+
+func (c *ArbitratorClient) GetMatch(ctx context.Context, matchId string, opts ...grpc.CallOption) (*models.Match, error) {
+	match := &models.Match{}
+	if err := c.invoke(ctx, "GetMatch", &MatchRequest{MatchId: matchId}, match, opts); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (c *ArbitratorClient) LiveMatches(ctx context.Context, opts ...grpc.CallOption) ([]*models.Match, error) {
+	matchList := &MatchList{}
+	err := c.invoke(ctx, "LiveMatches", &Empty{}, matchList, opts)
+	return matchList.Matches, err
+}
+
+func (c *ArbitratorClient) RecentMatches(ctx context.Context, req *RecentMatchesRequest, opts ...grpc.CallOption) ([]*models.Match, error) {
+	matchList := &MatchList{}
+	err := c.invoke(ctx, "RecentMatches", req, matchList, opts)
+	return matchList.Matches, err
+}
+
+func (c *ArbitratorClient) RequestChallenge(ctx context.Context, challenge *models.Challenge, opts ...grpc.CallOption) error {
+	return c.invoke(ctx, "RequestChallenge", challenge, &Empty{}, opts)
+}
+
+func (c *ArbitratorClient) AcceptChallenge(ctx context.Context, challengerKey models.Key, opts ...grpc.CallOption) error {
+	accept := &models.AcceptChallengeMessageContent{ChallengerClientKey: challengerKey}
+	return c.invoke(ctx, "AcceptChallenge", accept, &Empty{}, opts)
+}
+
+func (c *ArbitratorClient) JoinMatchmaking(ctx context.Context, findMatch *models.FindMatchMessageContent, opts ...grpc.CallOption) error {
+	return c.invoke(ctx, "JoinMatchmaking", findMatch, &Empty{}, opts)
+}
+
+// WatchMatch streams the match as it stands, then every update to it. The stream ends once the match does.
+func (c *ArbitratorClient) WatchMatch(ctx context.Context, matchId string, opts ...grpc.CallOption) (*MatchWatcher, error) {
+	stream, streamErr := c.openStream(ctx, 0, &MatchRequest{MatchId: matchId}, opts)
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	return &MatchWatcher{stream}, nil
+}
+
+// WatchTopic streams every broadcast on the topic, starting after lastEventId when it's still buffered
+func (c *ArbitratorClient) WatchTopic(ctx context.Context, req *WatchTopicRequest, opts ...grpc.CallOption) (*TopicWatcher, error) {
+	stream, streamErr := c.openStream(ctx, 1, req, opts)
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	return &TopicWatcher{stream}, nil
+}
+
+func (c *ArbitratorClient) invoke(ctx context.Context, method string, req interface{}, res interface{}, opts []grpc.CallOption) error {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(JSON_CONTENT_SUBTYPE)}, opts...)
+	return c.cc.Invoke(ctx, fullMethod(method), req, res, opts...)
+}
+
+func (c *ArbitratorClient) openStream(ctx context.Context, streamIdx int, req interface{}, opts []grpc.CallOption) (grpc.ClientStream, error) {
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(JSON_CONTENT_SUBTYPE)}, opts...)
+	streamDesc := &arbitratorServiceDesc.Streams[streamIdx]
+	stream, streamErr := c.cc.NewStream(ctx, streamDesc, fullMethod(streamDesc.StreamName), opts...)
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	if sendErr := stream.SendMsg(req); sendErr != nil {
+		return nil, sendErr
+	}
+	if closeErr := stream.CloseSend(); closeErr != nil {
+		return nil, closeErr
+	}
+	return stream, nil
+}
+
+type MatchWatcher struct {
+	grpc.ClientStream
+}
+
+func (w *MatchWatcher) Recv() (*models.Match, error) {
+	match := &models.Match{}
+	if err := w.RecvMsg(match); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+type TopicWatcher struct {
+	grpc.ClientStream
+}
+
+func (w *TopicWatcher) Recv() (*TopicEvent, error) {
+	event := &TopicEvent{}
+	if err := w.RecvMsg(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
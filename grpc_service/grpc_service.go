@@ -0,0 +1,123 @@
+package grpc_service
+
+import (
+	"context"
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/auth"
+	"github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/matcher"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/log"
+	"github.com/CameronHonis/marker"
+	"github.com/CameronHonis/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync"
+)
+
+type GrpcServiceI interface {
+	service.ServiceI
+	Serve(listener net.Listener) error
+}
+
+// GrpcService serves the arbitrator's API over gRPC, for backend services that would rather make typed calls than
+// speak the websocket protocol. Watched topics are followed through the clients manager's event streams, which hold
+// their subscriptions in the subscription service, so watchers see the same broadcasts websocket subscribers do and
+// the service has no need to depend on the subscription service itself.
+type GrpcService struct {
+	service.Service
+
+	__dependencies__   marker.Marker
+	MatcherService     matcher.MatcherServiceI
+	MatchmakingService matchmaking.MatchmakingServiceI
+	ClientsManager     clients_manager.ClientsManagerI
+	AuthService        auth.AuthenticationServiceI
+	Logger             log.LoggerServiceI
+
+	__state__ marker.Marker
+	server    *grpc.Server
+	mu        sync.Mutex
+}
+
+func NewGrpcService(config *GrpcServiceConfig) *GrpcService {
+	grpcService := &GrpcService{}
+	grpcService.Service = *service.NewService(grpcService, config)
+	return grpcService
+}
+
+func (gs *GrpcService) OnStart() {
+	go gs.StartGrpcServer()
+}
+
+func (gs *GrpcService) OnStop() {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.server != nil {
+		gs.server.Stop()
+	}
+}
+
+func (gs *GrpcService) StartGrpcServer() {
+	config := gs.Config().(*GrpcServiceConfig)
+	listener, listenErr := net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
+	if listenErr != nil {
+		gs.Logger.LogRed(models.ENV_GRPC, "could not listen:", listenErr)
+		return
+	}
+	gs.Logger.Log(models.ENV_GRPC, "grpc server spinning up on port", config.Port)
+	if serveErr := gs.Serve(listener); serveErr != nil {
+		gs.Logger.LogRed(models.ENV_GRPC, "could not serve:", serveErr)
+	}
+}
+
+// Serve answers RPCs on the listener until the service stops
+func (gs *GrpcService) Serve(listener net.Listener) error {
+	gs.mu.Lock()
+	if gs.server == nil {
+		gs.server = grpc.NewServer()
+		gs.server.RegisterService(&arbitratorServiceDesc, gs)
+	}
+	server := gs.server
+	gs.mu.Unlock()
+	return server.Serve(listener)
+}
+
+// authenticate vets the creds in the call's metadata, sent by ArbitratorClient when the context came from WithCreds
+func (gs *GrpcService) authenticate(ctx context.Context) (models.Key, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	clientKey := models.Key(firstMetadata(md, CLIENT_KEY_METADATA))
+	if err := auth.VetCreds(gs.AuthService, clientKey, auth.BearerToken(firstMetadata(md, AUTHORIZATION_METADATA))); err != nil {
+		return "", err
+	}
+	return clientKey, nil
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// vetWatcher admits anyone to public topics, and otherwise only clients the topic ACL admits
+func (gs *GrpcService) vetWatcher(ctx context.Context, topic models.MessageTopic) error {
+	if gs.ClientsManager.IsPublicTopic(topic) {
+		return nil
+	}
+	clientKey, authErr := gs.authenticate(ctx)
+	if authErr != nil {
+		return authErr
+	}
+	if aclErr := gs.AuthService.VetClientForTopic(clientKey, topic); aclErr != nil {
+		return models.NewProtocolError(models.ERROR_CODE_UNAUTHORIZED, "not allowed to follow %s", topic)
+	}
+	return nil
+}
+
+func toStatusErr(err error) error {
+	protocolErr := models.AsProtocolError(err, models.ERROR_CODE_INVALID_STATE)
+	return status.Error(protocolErr.GrpcCode(), protocolErr.Message)
+}
@@ -0,0 +1,29 @@
+package grpc_service
+
+import (
+	"github.com/CameronHonis/service"
+	"os"
+	"strconv"
+)
+
+type GrpcServiceConfig struct {
+	Port uint
+}
+
+func NewGrpcServiceConfig() *GrpcServiceConfig {
+	portEnvVal, _ := os.LookupEnv("GRPC_LISTEN_PORT")
+	var port uint
+	if num, err := strconv.Atoi(portEnvVal); err == nil {
+		port = uint(num)
+	} else {
+		port = 9090
+	}
+	return &GrpcServiceConfig{
+		Port: port,
+	}
+}
+
+func (gc *GrpcServiceConfig) MergeWith(other service.ConfigI) service.ConfigI {
+	newConfig := *(other.(*GrpcServiceConfig))
+	return &newConfig
+}
@@ -0,0 +1,16 @@
+package grpc_service_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var T *testing.T
+
+func TestGrpcService(t *testing.T) {
+	T = t
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GrpcService Suite")
+}
@@ -0,0 +1,254 @@
+package grpc_service_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	cm "github.com/CameronHonis/chess-arbitrator/clients_manager"
+	gs "github.com/CameronHonis/chess-arbitrator/grpc_service"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/set"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"sync"
+)
+
+var creds = &models.AuthMessageContent{PublicKey: "some-client", PrivateKey: "some-secret"}
+
+func CreateServices(ctrl *gomock.Controller) *gs.GrpcService {
+	subServiceMock := mocks.NewMockSubscriptionServiceI(ctrl)
+	subServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	subServiceMock.EXPECT().Build().AnyTimes()
+
+	authServiceMock := mocks.NewMockAuthenticationServiceI(ctrl)
+	authServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	authServiceMock.EXPECT().Build().AnyTimes()
+	authServiceMock.EXPECT().VetAuthInMessage(gomock.Any()).DoAndReturn(func(msg *models.Message) error {
+		if msg.SenderKey != creds.PublicKey || msg.PrivateKey != creds.PrivateKey {
+			return fmt.Errorf("invalid creds")
+		}
+		return nil
+	}).AnyTimes()
+	authServiceMock.EXPECT().VetClientForTopic(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	loggerServiceMock := mocks.NewMockLoggerServiceI(ctrl)
+	loggerServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	loggerServiceMock.EXPECT().Build().AnyTimes()
+	loggerServiceMock.EXPECT().Log(gomock.All(), gomock.Any()).AnyTimes()
+	loggerServiceMock.EXPECT().LogRed(gomock.All(), gomock.Any()).AnyTimes()
+
+	matchmakingMock := mocks.NewMockMatchmakingServiceI(ctrl)
+	matchmakingMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	matchmakingMock.EXPECT().Build().AnyTimes()
+
+	matcherServiceMock := mocks.NewMockMatcherServiceI(ctrl)
+	matcherServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	matcherServiceMock.EXPECT().Build().AnyTimes()
+
+	penaltyServiceMock := mocks.NewMockPenaltyServiceI(ctrl)
+	penaltyServiceMock.EXPECT().SetParent(gomock.All()).AnyTimes()
+	penaltyServiceMock.EXPECT().Build().AnyTimes()
+
+	clientsManager := cm.NewClientsManager(cm.NewClientsManagerConfig(make(map[models.ContentType]cm.MessageHandler)))
+	clientsManager.AddDependency(subServiceMock)
+	clientsManager.AddDependency(authServiceMock)
+	clientsManager.AddDependency(loggerServiceMock)
+	clientsManager.AddDependency(matchmakingMock)
+	clientsManager.AddDependency(matcherServiceMock)
+	clientsManager.AddDependency(penaltyServiceMock)
+
+	grpcService := gs.NewGrpcService(gs.NewGrpcServiceConfig())
+	grpcService.AddDependency(clientsManager)
+	grpcService.AddDependency(loggerServiceMock)
+	grpcService.AddDependency(matcherServiceMock)
+	grpcService.AddDependency(matchmakingMock)
+	grpcService.AddDependency(authServiceMock)
+
+	return grpcService
+}
+
+// dialBufconn serves the service in memory and returns a client for it
+func dialBufconn(grpcService *gs.GrpcService) *gs.ArbitratorClient {
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = grpcService.Serve(listener)
+	}()
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}
+	conn, dialErr := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	Expect(dialErr).ToNot(HaveOccurred())
+	DeferCleanup(func() {
+		_ = conn.Close()
+		grpcService.OnStop()
+	})
+	return gs.NewArbitratorClient(conn)
+}
+
+var _ = Describe("GrpcService", func() {
+	var grpcService *gs.GrpcService
+	var client *gs.ArbitratorClient
+	var matcherServiceMock *mocks.MockMatcherServiceI
+	var ctx context.Context
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		grpcService = CreateServices(ctrl)
+		matcherServiceMock = grpcService.MatcherService.(*mocks.MockMatcherServiceI)
+		client = dialBufconn(grpcService)
+		ctx = context.Background()
+	})
+	Describe("GetMatch", func() {
+		var match *models.Match
+		BeforeEach(func() {
+			match = builders.NewMatch("white-key", "black-key", builders.NewBlitzTimeControl(), models.MATCH_RESULT_IN_PROGRESS)
+		})
+		It("returns the live match", func() {
+			matcherServiceMock.EXPECT().MatchById(match.Uuid).Return(match, nil)
+			resMatch, err := client.GetMatch(ctx, match.Uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resMatch.Uuid).To(Equal(match.Uuid))
+			Expect(resMatch.WhiteClientKey).To(Equal(models.Key("white-key")))
+		})
+		It("falls back to ended matches", func() {
+			match.Result = models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION
+			matcherServiceMock.EXPECT().MatchById(match.Uuid).Return(nil, fmt.Errorf("no match"))
+			matcherServiceMock.EXPECT().EndedMatchById(match.Uuid).Return(match, nil)
+			resMatch, err := client.GetMatch(ctx, match.Uuid)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resMatch.Result).To(Equal(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION))
+		})
+		It("returns NotFound for unknown matches", func() {
+			matcherServiceMock.EXPECT().MatchById("some-uuid").Return(nil, fmt.Errorf("no match"))
+			matcherServiceMock.EXPECT().EndedMatchById("some-uuid").Return(nil, fmt.Errorf("no match"))
+			_, err := client.GetMatch(ctx, "some-uuid")
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+	Describe("RequestChallenge", func() {
+		It("requires creds", func() {
+			challenge := builders.NewChallenge("", "other-client", true, false, builders.NewBlitzTimeControl(), "", true)
+			err := client.RequestChallenge(ctx, challenge)
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		})
+		It("challenges from the calling client", func() {
+			challenge := builders.NewChallenge("spoofed-client", "other-client", true, false, builders.NewBlitzTimeControl(), "", true)
+			matcherServiceMock.EXPECT().RequestChallenge(gomock.Any()).DoAndReturn(func(challenge *models.Challenge) error {
+				Expect(challenge.ChallengerKey).To(Equal(creds.PublicKey))
+				Expect(challenge.ChallengedKey).To(Equal(models.Key("other-client")))
+				return nil
+			})
+			Expect(client.RequestChallenge(gs.WithCreds(ctx, creds), challenge)).To(Succeed())
+		})
+		It("maps protocol errors onto status codes", func() {
+			challenge := builders.NewChallenge("", "other-client", true, false, builders.NewBlitzTimeControl(), "", true)
+			matcherServiceMock.EXPECT().RequestChallenge(gomock.Any()).Return(models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "bad challenge"))
+			err := client.RequestChallenge(gs.WithCreds(ctx, creds), challenge)
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(status.Convert(err).Message()).To(Equal("bad challenge"))
+		})
+	})
+	Describe("JoinMatchmaking", func() {
+		It("queues the calling client", func() {
+			matchmakingMock := grpcService.MatchmakingService.(*mocks.MockMatchmakingServiceI)
+			matchmakingMock.EXPECT().JoinMatchmaking(creds.PublicKey, gomock.Any()).DoAndReturn(func(_ models.Key, findMatch *models.FindMatchMessageContent) error {
+				Expect(findMatch.BotFallback).To(BeTrue())
+				Expect(findMatch.AllQueues()).To(HaveLen(1))
+				return nil
+			})
+			findMatch := &models.FindMatchMessageContent{
+				TimeControl: builders.NewBlitzTimeControl(),
+				Variant:     models.VARIANT_STANDARD,
+				BotFallback: true,
+			}
+			Expect(client.JoinMatchmaking(gs.WithCreds(ctx, creds), findMatch)).To(Succeed())
+		})
+	})
+	Describe("watching", func() {
+		var topic models.MessageTopic
+		var broadcast func(msg *models.Message)
+		var match *models.Match
+		BeforeEach(func() {
+			match = builders.NewMatch("white-key", "black-key", builders.NewBlitzTimeControl(), models.MATCH_RESULT_IN_PROGRESS)
+			topic = match.Topic()
+			subbedKeys := set.EmptySet[models.Key]()
+			var mu sync.Mutex
+			clientsManager := grpcService.ClientsManager.(*cm.ClientsManager)
+			subServiceMock := clientsManager.SubService.(*mocks.MockSubscriptionServiceI)
+			subServiceMock.EXPECT().SubClient(gomock.Any(), gomock.Any()).DoAndReturn(func(clientKey models.Key, _ models.MessageTopic) error {
+				mu.Lock()
+				defer mu.Unlock()
+				subbedKeys.Add(clientKey)
+				return nil
+			}).AnyTimes()
+			subServiceMock.EXPECT().UnsubClientFromAll(gomock.Any()).Do(func(clientKey models.Key) {
+				mu.Lock()
+				defer mu.Unlock()
+				subbedKeys.Remove(clientKey)
+			}).AnyTimes()
+			subServiceMock.EXPECT().ClientKeysSubbedToTopic(gomock.Any()).DoAndReturn(func(models.MessageTopic) *set.Set[models.Key] {
+				mu.Lock()
+				defer mu.Unlock()
+				return set.FromSlice(subbedKeys.Flatten())
+			}).AnyTimes()
+			// broadcasts once the watcher has subscribed, so they aren't lost to the race with OpenStream
+			broadcast = func(msg *models.Message) {
+				Eventually(func() int {
+					mu.Lock()
+					defer mu.Unlock()
+					return subbedKeys.Size()
+				}).Should(Equal(1))
+				clientsManager.BroadcastMessage(msg)
+			}
+		})
+		It("streams broadcasts on the topic", func() {
+			watcher, err := client.WatchTopic(ctx, &gs.WatchTopicRequest{Topic: topic})
+			Expect(err).ToNot(HaveOccurred())
+			broadcast(&models.Message{
+				Topic:       topic,
+				ContentType: models.CONTENT_TYPE_ECHO,
+				Content:     &models.EchoMessageContent{Message: "hello"},
+			})
+			event, recvErr := watcher.Recv()
+			Expect(recvErr).ToNot(HaveOccurred())
+			Expect(event.Topic).To(Equal(topic))
+			Expect(event.ContentType).To(Equal(models.CONTENT_TYPE_ECHO))
+			Expect(string(event.Data)).To(ContainSubstring("hello"))
+		})
+		It("requires creds to watch a private topic", func() {
+			watcher, err := client.WatchTopic(ctx, &gs.WatchTopicRequest{Topic: "challenge-some-uuid"})
+			Expect(err).ToNot(HaveOccurred())
+			_, recvErr := watcher.Recv()
+			Expect(status.Code(recvErr)).To(Equal(codes.Unauthenticated))
+		})
+		It("streams the match until it ends", func() {
+			matcherServiceMock.EXPECT().MatchById(match.Uuid).Return(match, nil).AnyTimes()
+			watcher, err := client.WatchMatch(ctx, match.Uuid)
+			Expect(err).ToNot(HaveOccurred())
+			snapshot, recvErr := watcher.Recv()
+			Expect(recvErr).ToNot(HaveOccurred())
+			Expect(snapshot.Result).To(Equal(models.MATCH_RESULT_IN_PROGRESS))
+
+			endedMatch := *match
+			endedMatch.Result = models.MATCH_RESULT_BLACK_WINS_BY_RESIGNATION
+			broadcast(&models.Message{
+				Topic:       topic,
+				ContentType: models.CONTENT_TYPE_MATCH_UPDATED,
+				Content:     &models.MatchUpdateMessageContent{Match: &endedMatch},
+			})
+			update, recvErr := watcher.Recv()
+			Expect(recvErr).ToNot(HaveOccurred())
+			Expect(update.Result).To(Equal(models.MATCH_RESULT_BLACK_WINS_BY_RESIGNATION))
+			_, recvErr = watcher.Recv()
+			Expect(recvErr).To(MatchError(io.EOF))
+		})
+	})
+})
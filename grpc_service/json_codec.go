@@ -0,0 +1,29 @@
+package grpc_service
+
+import (
+	"encoding/json"
+	"google.golang.org/grpc/encoding"
+)
+
+// JSON_CONTENT_SUBTYPE selects JsonCodec for a call, which travels with the application/grpc+json content type
+const JSON_CONTENT_SUBTYPE = "json"
+
+func init() {
+	encoding.RegisterCodec(JsonCodec{})
+}
+
+// JsonCodec carries RPC payloads as JSON, so the API is defined over the same models the websocket protocol uses
+// rather than generated protobuf types
+type JsonCodec struct{}
+
+func (JsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JsonCodec) Name() string {
+	return JSON_CONTENT_SUBTYPE
+}
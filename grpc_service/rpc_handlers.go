@@ -0,0 +1,160 @@
+package grpc_service
+
+import (
+	"context"
+	"github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (gs *GrpcService) GetMatch(_ context.Context, req *MatchRequest) (*models.Match, error) {
+	if match, err := gs.MatcherService.MatchById(req.MatchId); err == nil {
+		return match, nil
+	}
+	match, err := gs.MatcherService.EndedMatchById(req.MatchId)
+	if err != nil {
+		return nil, toStatusErr(models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no match with id %s", req.MatchId))
+	}
+	return match, nil
+}
+
+func (gs *GrpcService) LiveMatches(_ context.Context, _ *Empty) (*MatchList, error) {
+	return &MatchList{Matches: gs.MatcherService.LiveMatches()}, nil
+}
+
+func (gs *GrpcService) RecentMatches(_ context.Context, req *RecentMatchesRequest) (*MatchList, error) {
+	limit, limitErr := models.VetPageLimit(req.Limit)
+	if limitErr != nil {
+		return nil, toStatusErr(limitErr)
+	}
+	return &MatchList{Matches: gs.MatcherService.RecentMatches(req.ClientKey, limit)}, nil
+}
+
+// RequestChallenge sends the challenge from the calling client, whatever challenger the request names
+func (gs *GrpcService) RequestChallenge(ctx context.Context, challenge *models.Challenge) (*Empty, error) {
+	clientKey, authErr := gs.authenticate(ctx)
+	if authErr != nil {
+		return nil, toStatusErr(authErr)
+	}
+	challenge.ChallengerKey = clientKey
+	if err := gs.MatcherService.RequestChallenge(challenge); err != nil {
+		return nil, toStatusErr(err)
+	}
+	return &Empty{}, nil
+}
+
+func (gs *GrpcService) AcceptChallenge(ctx context.Context, accept *models.AcceptChallengeMessageContent) (*Empty, error) {
+	clientKey, authErr := gs.authenticate(ctx)
+	if authErr != nil {
+		return nil, toStatusErr(authErr)
+	}
+	if err := gs.MatcherService.AcceptChallenge(accept.ChallengerClientKey, clientKey); err != nil {
+		return nil, toStatusErr(err)
+	}
+	return &Empty{}, nil
+}
+
+// JoinMatchmaking queues the calling client, or queues it within a party when the request names one
+func (gs *GrpcService) JoinMatchmaking(ctx context.Context, findMatch *models.FindMatchMessageContent) (*Empty, error) {
+	clientKey, authErr := gs.authenticate(ctx)
+	if authErr != nil {
+		return nil, toStatusErr(authErr)
+	}
+	if err := gs.MatchmakingService.JoinMatchmaking(clientKey, findMatch); err != nil {
+		return nil, toStatusErr(err)
+	}
+	return &Empty{}, nil
+}
+
+func (gs *GrpcService) WatchMatch(req *MatchRequest, stream grpc.ServerStream) error {
+	match, matchErr := gs.GetMatch(stream.Context(), req)
+	if matchErr != nil {
+		return matchErr
+	}
+	if vetErr := gs.vetWatcher(stream.Context(), match.Topic()); vetErr != nil {
+		return toStatusErr(vetErr)
+	}
+	if match.Result != models.MATCH_RESULT_IN_PROGRESS {
+		return stream.SendMsg(match)
+	}
+
+	eventStream, openErr := gs.ClientsManager.OpenStream(match.Topic(), 0)
+	if openErr != nil {
+		return toStatusErr(openErr)
+	}
+	defer gs.ClientsManager.CloseStream(eventStream)
+
+	// NOTE: the match is read again once subscribed, so no update lands between the snapshot and the stream
+	match, matchErr = gs.GetMatch(stream.Context(), req)
+	if matchErr != nil {
+		return matchErr
+	}
+	if sendErr := stream.SendMsg(match); sendErr != nil {
+		return sendErr
+	}
+	for match.Result == models.MATCH_RESULT_IN_PROGRESS {
+		event, nextErr := nextEvent(stream.Context(), eventStream)
+		if nextErr != nil {
+			return nextErr
+		}
+		if event.ContentType != models.CONTENT_TYPE_MATCH_UPDATED {
+			continue
+		}
+		msg, unmarshalErr := models.UnmarshalToMessage(event.Data)
+		if unmarshalErr != nil {
+			return status.Errorf(codes.Internal, "could not read match update: %s", unmarshalErr)
+		}
+		update, ok := msg.Content.(*models.MatchUpdateMessageContent)
+		if !ok || update.Match.PlyCount < match.PlyCount {
+			continue
+		}
+		match = update.Match
+		if sendErr := stream.SendMsg(match); sendErr != nil {
+			return sendErr
+		}
+	}
+	return nil
+}
+
+func (gs *GrpcService) WatchTopic(req *WatchTopicRequest, stream grpc.ServerStream) error {
+	if req.Topic == "" {
+		return toStatusErr(models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "missing topic"))
+	}
+	if vetErr := gs.vetWatcher(stream.Context(), req.Topic); vetErr != nil {
+		return toStatusErr(vetErr)
+	}
+	eventStream, openErr := gs.ClientsManager.OpenStream(req.Topic, req.LastEventId)
+	if openErr != nil {
+		return toStatusErr(openErr)
+	}
+	defer gs.ClientsManager.CloseStream(eventStream)
+
+	for {
+		event, nextErr := nextEvent(stream.Context(), eventStream)
+		if nextErr != nil {
+			return nextErr
+		}
+		sendErr := stream.SendMsg(&TopicEvent{
+			Id:          event.Id,
+			Topic:       event.Topic,
+			ContentType: event.ContentType,
+			Data:        event.Data,
+		})
+		if sendErr != nil {
+			return sendErr
+		}
+	}
+}
+
+func nextEvent(ctx context.Context, eventStream *clients_manager.EventStream) (*clients_manager.StreamEvent, error) {
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-eventStream.Done():
+		return nil, status.Error(codes.Unavailable, "watcher fell behind, resume from the last event id")
+	case event := <-eventStream.Events():
+		return event, nil
+	}
+}
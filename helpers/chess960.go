@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const CHESS960_POSITION_COUNT = 960
+
+// knight file pairs indexed by the 5th digit of the Scharnagl numbering scheme, as offsets into the remaining
+// empty squares once the bishops and queen are placed
+var chess960KnightOffsets = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+func Chess960PositionFromSeed(seed int64) int {
+	return rand.New(rand.NewSource(seed)).Intn(CHESS960_POSITION_COUNT)
+}
+
+// Chess960BackRank derives white's back rank for the given Scharnagl position number, where 518 is the standard
+// starting position
+func Chess960BackRank(position int) (string, error) {
+	if position < 0 || position >= CHESS960_POSITION_COUNT {
+		return "", fmt.Errorf("chess960 position %d out of range", position)
+	}
+	var backRank [8]byte
+	n := position
+	backRank[2*(n%4)+1] = 'B'
+	n /= 4
+	backRank[2*(n%4)] = 'B'
+	n /= 4
+	placeOnEmpty := func(piece byte, emptyIdx int) {
+		for file := 0; file < 8; file++ {
+			if backRank[file] != 0 {
+				continue
+			}
+			if emptyIdx == 0 {
+				backRank[file] = piece
+				return
+			}
+			emptyIdx--
+		}
+	}
+	placeOnEmpty('Q', n%6)
+	n /= 6
+	knightOffsets := chess960KnightOffsets[n]
+	// the second knight is placed after the first, so its offset shrinks by one
+	placeOnEmpty('N', knightOffsets[0])
+	placeOnEmpty('N', knightOffsets[1]-1)
+	placeOnEmpty('R', 0)
+	placeOnEmpty('K', 0)
+	placeOnEmpty('R', 0)
+	return string(backRank[:]), nil
+}
+
+// Chess960StartingFEN builds the starting fen for the position, using Shredder-FEN castling rights (rook files)
+func Chess960StartingFEN(position int) (string, error) {
+	backRank, backRankErr := Chess960BackRank(position)
+	if backRankErr != nil {
+		return "", backRankErr
+	}
+	queensideRookFile := strings.IndexByte(backRank, 'R')
+	kingsideRookFile := strings.LastIndexByte(backRank, 'R')
+	castlingRights := string([]byte{
+		'A' + byte(kingsideRookFile),
+		'A' + byte(queensideRookFile),
+		'a' + byte(kingsideRookFile),
+		'a' + byte(queensideRookFile),
+	})
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w %s - 0 1", strings.ToLower(backRank), backRank, castlingRights), nil
+}
+
+// WithoutCastlingRights replaces the castling field of the fen with "-"
+func WithoutCastlingRights(fen string) string {
+	fields := strings.Fields(fen)
+	if len(fields) < 3 {
+		return fen
+	}
+	fields[2] = "-"
+	return strings.Join(fields, " ")
+}
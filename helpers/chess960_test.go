@@ -0,0 +1,99 @@
+package helpers_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Chess960", func() {
+	Describe("Chess960BackRank", func() {
+		When("the position is 518", func() {
+			It("returns the standard back rank", func() {
+				Expect(helpers.Chess960BackRank(518)).To(Equal("RNBQKBNR"))
+			})
+		})
+		When("the position is 0", func() {
+			It("returns the first back rank in the numbering scheme", func() {
+				Expect(helpers.Chess960BackRank(0)).To(Equal("BBQNNRKR"))
+			})
+		})
+		When("the position is out of range", func() {
+			It("returns an error", func() {
+				_, err := helpers.Chess960BackRank(960)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		It("produces 960 distinct legal back ranks", func() {
+			seen := make(map[string]bool)
+			for position := 0; position < helpers.CHESS960_POSITION_COUNT; position++ {
+				backRank, err := helpers.Chess960BackRank(position)
+				Expect(err).ToNot(HaveOccurred())
+				seen[backRank] = true
+
+				firstBishop := strings.IndexByte(backRank, 'B')
+				lastBishop := strings.LastIndexByte(backRank, 'B')
+				Expect((lastBishop - firstBishop) % 2).To(Equal(1))
+				king := strings.IndexByte(backRank, 'K')
+				Expect(strings.IndexByte(backRank, 'R')).To(BeNumerically("<", king))
+				Expect(strings.LastIndexByte(backRank, 'R')).To(BeNumerically(">", king))
+			}
+			Expect(seen).To(HaveLen(helpers.CHESS960_POSITION_COUNT))
+		})
+	})
+	Describe("Chess960PositionFromSeed", func() {
+		It("is reproducible for the same seed", func() {
+			Expect(helpers.Chess960PositionFromSeed(42)).To(Equal(helpers.Chess960PositionFromSeed(42)))
+		})
+	})
+	Describe("Chess960StartingFEN", func() {
+		It("uses rook files for castling rights", func() {
+			Expect(helpers.Chess960StartingFEN(518)).To(Equal("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1"))
+		})
+	})
+	Describe("Castle", func() {
+		var position *helpers.FENPosition
+		When("castling kingside with the king next to its rook", func() {
+			BeforeEach(func() {
+				var err error
+				position, err = helpers.ParseFEN("4k3/8/8/8/8/8/8/1R4KR w HB - 0 1")
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("lands the king on g1 and the rook on f1", func() {
+				Expect(position.Castle(true)).To(Succeed())
+				Expect(position.String()).To(Equal("4k3/8/8/8/8/8/8/1R3RK1 b - - 1 1"))
+			})
+		})
+		When("castling queenside with pieces in the way", func() {
+			BeforeEach(func() {
+				var err error
+				position, err = helpers.ParseFEN("4k3/8/8/8/8/8/8/RN2K2R w HA - 0 1")
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("returns an error", func() {
+				Expect(position.Castle(false)).ToNot(Succeed())
+			})
+		})
+		When("the king would pass through check", func() {
+			BeforeEach(func() {
+				var err error
+				position, err = helpers.ParseFEN("4kr2/8/8/8/8/8/8/4K2R w K - 0 1")
+				Expect(err).ToNot(HaveOccurred())
+			})
+			It("returns an error", func() {
+				Expect(position.Castle(true)).ToNot(Succeed())
+			})
+		})
+	})
+	Describe("ApplyMove", func() {
+		It("removes the castling right of a moved rook and marks double pawn pushes", func() {
+			position, err := helpers.ParseFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			position.ApplyMove(1, 4, 3, 4, 0)
+			Expect(position.String()).To(Equal("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b HAha e3 0 1"))
+			position.ApplyMove(7, 7, 5, 7, 0)
+			Expect(position.CastlingRights).To(Equal("HAa"))
+		})
+	})
+})
@@ -0,0 +1,471 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const EMPTY_SQUARE byte = ' '
+
+type FENPosition struct {
+	// squares indexed by [rank][file], where [0][0] is a1 and [7][7] is h8
+	Squares        [8][8]byte
+	IsWhiteTurn    bool
+	CastlingRights string
+	EnPassant      string
+	HalfmoveClock  int
+	FullmoveNumber int
+}
+
+func ParseFEN(fen string) (*FENPosition, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("expected 6 fields in fen, got %d", len(fields))
+	}
+	position := &FENPosition{}
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("expected 8 ranks in fen, got %d", len(ranks))
+	}
+	for i, rankStr := range ranks {
+		rank := 7 - i
+		file := 0
+		for _, char := range rankStr {
+			if char >= '1' && char <= '8' {
+				for j := 0; j < int(char-'0') && file+j < 8; j++ {
+					position.Squares[rank][file+j] = EMPTY_SQUARE
+				}
+				file += int(char - '0')
+			} else if strings.ContainsRune("pnbrqkPNBRQK", char) {
+				if file < 8 {
+					position.Squares[rank][file] = byte(char)
+				}
+				file++
+			} else {
+				return nil, fmt.Errorf("invalid piece %q in fen", char)
+			}
+		}
+		if file != 8 {
+			return nil, fmt.Errorf("rank %d in fen does not span 8 files", rank+1)
+		}
+	}
+
+	switch fields[1] {
+	case "w":
+		position.IsWhiteTurn = true
+	case "b":
+		position.IsWhiteTurn = false
+	default:
+		return nil, fmt.Errorf("invalid side to move %q in fen", fields[1])
+	}
+
+	position.CastlingRights = fields[2]
+	position.EnPassant = fields[3]
+
+	halfmoveClock, halfmoveErr := strconv.Atoi(fields[4])
+	if halfmoveErr != nil || halfmoveClock < 0 {
+		return nil, fmt.Errorf("invalid halfmove clock %q in fen", fields[4])
+	}
+	position.HalfmoveClock = halfmoveClock
+
+	fullmoveNumber, fullmoveErr := strconv.Atoi(fields[5])
+	if fullmoveErr != nil || fullmoveNumber < 1 {
+		return nil, fmt.Errorf("invalid fullmove number %q in fen", fields[5])
+	}
+	position.FullmoveNumber = fullmoveNumber
+
+	return position, nil
+}
+
+// ValidateFEN checks that the fen describes a position that could be reached in a standard game: one king per side,
+// no pawns on the back ranks, castling rights that match the king and rook placement, a plausible en passant
+// square, and no check against the side that just moved.
+func ValidateFEN(fen string) error {
+	position, parseErr := ParseFEN(fen)
+	if parseErr != nil {
+		return parseErr
+	}
+	if pieceErr := position.validatePieces(); pieceErr != nil {
+		return pieceErr
+	}
+	if castlingErr := position.validateCastlingRights(); castlingErr != nil {
+		return castlingErr
+	}
+	if enPassantErr := position.validateEnPassant(); enPassantErr != nil {
+		return enPassantErr
+	}
+
+	idleKingChar := byte('k')
+	if !position.IsWhiteTurn {
+		idleKingChar = 'K'
+	}
+	rank, file, _ := position.FindPiece(idleKingChar)
+	if position.IsSquareAttacked(rank, file, position.IsWhiteTurn) {
+		return fmt.Errorf("side not to move is in check")
+	}
+	return nil
+}
+
+func (p *FENPosition) IsSquareAttacked(rank, file int, byWhite bool) bool {
+	pieceAt := func(r, f int) byte {
+		if r < 0 || r > 7 || f < 0 || f > 7 {
+			return EMPTY_SQUARE
+		}
+		return p.Squares[r][f]
+	}
+	isAttacker := func(piece byte, kinds string) bool {
+		if piece == EMPTY_SQUARE {
+			return false
+		}
+		isWhitePiece := piece >= 'A' && piece <= 'Z'
+		return isWhitePiece == byWhite && strings.ContainsRune(kinds, rune(piece|0x20))
+	}
+
+	pawnRank := rank - 1
+	if !byWhite {
+		pawnRank = rank + 1
+	}
+	if isAttacker(pieceAt(pawnRank, file-1), "p") || isAttacker(pieceAt(pawnRank, file+1), "p") {
+		return true
+	}
+	for _, offset := range [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}} {
+		if isAttacker(pieceAt(rank+offset[0], file+offset[1]), "n") {
+			return true
+		}
+	}
+	for _, offset := range [][2]int{{1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}} {
+		if isAttacker(pieceAt(rank+offset[0], file+offset[1]), "k") {
+			return true
+		}
+		sliders := "rq"
+		if offset[0] != 0 && offset[1] != 0 {
+			sliders = "bq"
+		}
+		for r, f := rank+offset[0], file+offset[1]; r >= 0 && r < 8 && f >= 0 && f < 8; r, f = r+offset[0], f+offset[1] {
+			if piece := p.Squares[r][f]; piece != EMPTY_SQUARE {
+				if isAttacker(piece, sliders) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+func (p *FENPosition) validatePieces() error {
+	countByPiece := make(map[byte]int)
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			piece := p.Squares[rank][file]
+			countByPiece[piece]++
+			if (piece == 'P' || piece == 'p') && (rank == 0 || rank == 7) {
+				return fmt.Errorf("pawn on back rank")
+			}
+		}
+	}
+	if countByPiece['K'] != 1 || countByPiece['k'] != 1 {
+		return fmt.Errorf("each side must have exactly one king")
+	}
+	if countByPiece['P'] > 8 || countByPiece['p'] > 8 {
+		return fmt.Errorf("too many pawns")
+	}
+	whiteCount := countByPiece['P'] + countByPiece['N'] + countByPiece['B'] + countByPiece['R'] + countByPiece['Q'] + 1
+	blackCount := countByPiece['p'] + countByPiece['n'] + countByPiece['b'] + countByPiece['r'] + countByPiece['q'] + 1
+	if whiteCount > 16 || blackCount > 16 {
+		return fmt.Errorf("too many pieces")
+	}
+	return nil
+}
+
+func (p *FENPosition) validateCastlingRights() error {
+	if p.CastlingRights == "-" {
+		return nil
+	}
+	seen := make(map[rune]bool)
+	for _, right := range p.CastlingRights {
+		if seen[right] {
+			return fmt.Errorf("duplicate castling right %q", right)
+		}
+		seen[right] = true
+
+		var kingChar, rookChar byte
+		var rank, rookFile int
+		switch right {
+		case 'K':
+			kingChar, rookChar, rank, rookFile = 'K', 'R', 0, 7
+		case 'Q':
+			kingChar, rookChar, rank, rookFile = 'K', 'R', 0, 0
+		case 'k':
+			kingChar, rookChar, rank, rookFile = 'k', 'r', 7, 7
+		case 'q':
+			kingChar, rookChar, rank, rookFile = 'k', 'r', 7, 0
+		default:
+			return fmt.Errorf("invalid castling right %q", right)
+		}
+		if p.Squares[rank][4] != kingChar || p.Squares[rank][rookFile] != rookChar {
+			return fmt.Errorf("castling right %q does not match king and rook placement", right)
+		}
+	}
+	return nil
+}
+
+func (p *FENPosition) validateEnPassant() error {
+	if p.EnPassant == "-" {
+		return nil
+	}
+	if len(p.EnPassant) != 2 || p.EnPassant[0] < 'a' || p.EnPassant[0] > 'h' {
+		return fmt.Errorf("invalid en passant square %q", p.EnPassant)
+	}
+	file := int(p.EnPassant[0] - 'a')
+	// the pawn that just double-stepped sits one rank past the en passant square
+	expRankChar, pawnRank, pawnChar := byte('6'), 4, byte('p')
+	if !p.IsWhiteTurn {
+		expRankChar, pawnRank, pawnChar = '3', 3, 'P'
+	}
+	if p.EnPassant[1] != expRankChar {
+		return fmt.Errorf("en passant square %q on wrong rank", p.EnPassant)
+	}
+	if p.Squares[pawnRank][file] != pawnChar {
+		return fmt.Errorf("en passant square %q has no pawn to capture", p.EnPassant)
+	}
+	return nil
+}
+
+func (p *FENPosition) FindPiece(piece byte) (rank int, file int, ok bool) {
+	for rank = 0; rank < 8; rank++ {
+		for file = 0; file < 8; file++ {
+			if p.Squares[rank][file] == piece {
+				return rank, file, true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+func (p *FENPosition) String() string {
+	var placement strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		emptyCount := 0
+		for file := 0; file < 8; file++ {
+			piece := p.Squares[rank][file]
+			if piece == EMPTY_SQUARE {
+				emptyCount++
+				continue
+			}
+			if emptyCount > 0 {
+				placement.WriteString(strconv.Itoa(emptyCount))
+				emptyCount = 0
+			}
+			placement.WriteByte(piece)
+		}
+		if emptyCount > 0 {
+			placement.WriteString(strconv.Itoa(emptyCount))
+		}
+		if rank > 0 {
+			placement.WriteByte('/')
+		}
+	}
+	sideToMove := "w"
+	if !p.IsWhiteTurn {
+		sideToMove = "b"
+	}
+	castlingRights := p.CastlingRights
+	if castlingRights == "" {
+		castlingRights = "-"
+	}
+	return fmt.Sprintf("%s %s %s %s %d %d", placement.String(), sideToMove, castlingRights, p.EnPassant, p.HalfmoveClock, p.FullmoveNumber)
+}
+
+func (p *FENPosition) Copy() *FENPosition {
+	positionCopy := *p
+	return &positionCopy
+}
+
+// ApplyMove plays a (non-castling) move that is assumed to be legal, maintaining en passant, castling rights and the
+// move clocks. Ranks and files are zero-indexed.
+func (p *FENPosition) ApplyMove(startRank, startFile, endRank, endFile int, promotedTo byte) {
+	piece := p.Squares[startRank][startFile]
+	captured := p.Squares[endRank][endFile]
+	isPawn := piece == 'P' || piece == 'p'
+
+	if isPawn && startFile != endFile && captured == EMPTY_SQUARE {
+		// en passant captures the pawn beside the moving pawn
+		captured = p.Squares[startRank][endFile]
+		p.Squares[startRank][endFile] = EMPTY_SQUARE
+	}
+	p.Squares[startRank][startFile] = EMPTY_SQUARE
+	if promotedTo != 0 {
+		p.Squares[endRank][endFile] = promotedTo
+	} else {
+		p.Squares[endRank][endFile] = piece
+	}
+
+	if piece == 'K' || piece == 'k' {
+		p.removeCastlingRights(piece == 'K', -1)
+	}
+	if (piece == 'R' && startRank == 0) || (piece == 'r' && startRank == 7) {
+		p.removeCastlingRights(piece == 'R', startFile)
+	}
+	if (captured == 'R' && endRank == 0) || (captured == 'r' && endRank == 7) {
+		p.removeCastlingRights(captured == 'R', endFile)
+	}
+
+	p.EnPassant = "-"
+	// NOTE: horde pawns double-stepping from the first rank cannot be taken en passant
+	if isPawn && (startRank == 1 || startRank == 6) && (endRank-startRank == 2 || startRank-endRank == 2) {
+		p.EnPassant = string([]byte{'a' + byte(startFile), '1' + byte((startRank+endRank)/2)})
+	}
+	p.finishTurn(isPawn || captured != EMPTY_SQUARE)
+}
+
+// CastlingRookFile resolves the file of the rook the side to move may castle with, supporting both standard (KQkq)
+// and Shredder-FEN (rook file) castling rights
+func (p *FENPosition) CastlingRookFile(isKingside bool) (int, bool) {
+	rank := p.homeRank()
+	kingFile := p.kingFileOnRank(rank)
+	for _, right := range p.CastlingRights {
+		isWhiteRight, rookFile := castlingRightRookFile(right)
+		if isWhiteRight != p.IsWhiteTurn || rookFile < 0 {
+			continue
+		}
+		if (rookFile > kingFile) == isKingside {
+			return rookFile, true
+		}
+	}
+	return -1, false
+}
+
+// Castle plays a castling move for the side to move using chess960 rules, where the king always lands on the g or c
+// file and the rook on the f or d file regardless of where either started
+func (p *FENPosition) Castle(isKingside bool) error {
+	rank := p.homeRank()
+	kingFile := p.kingFileOnRank(rank)
+	if kingFile < 0 {
+		return fmt.Errorf("king not on home rank")
+	}
+	rookFile, ok := p.CastlingRookFile(isKingside)
+	if !ok {
+		return fmt.Errorf("no castling right on that side")
+	}
+	rookChar := byte('R')
+	if !p.IsWhiteTurn {
+		rookChar = 'r'
+	}
+	if p.Squares[rank][rookFile] != rookChar {
+		return fmt.Errorf("castling rook is missing")
+	}
+	kingDestFile, rookDestFile := 2, 3
+	if isKingside {
+		kingDestFile, rookDestFile = 6, 5
+	}
+
+	minFile := minInt(minInt(kingFile, kingDestFile), minInt(rookFile, rookDestFile))
+	maxFile := maxInt(maxInt(kingFile, kingDestFile), maxInt(rookFile, rookDestFile))
+	for file := minFile; file <= maxFile; file++ {
+		if file != kingFile && file != rookFile && p.Squares[rank][file] != EMPTY_SQUARE {
+			return fmt.Errorf("castling path is blocked")
+		}
+	}
+	for file := minInt(kingFile, kingDestFile); file <= maxInt(kingFile, kingDestFile); file++ {
+		if p.IsSquareAttacked(rank, file, !p.IsWhiteTurn) {
+			return fmt.Errorf("king cannot castle out of, through or into check")
+		}
+	}
+
+	king, rook := p.Squares[rank][kingFile], p.Squares[rank][rookFile]
+	p.Squares[rank][kingFile] = EMPTY_SQUARE
+	p.Squares[rank][rookFile] = EMPTY_SQUARE
+	p.Squares[rank][kingDestFile] = king
+	p.Squares[rank][rookDestFile] = rook
+
+	p.removeCastlingRights(p.IsWhiteTurn, -1)
+	p.EnPassant = "-"
+	p.finishTurn(false)
+	return nil
+}
+
+func (p *FENPosition) finishTurn(resetsHalfmoveClock bool) {
+	if resetsHalfmoveClock {
+		p.HalfmoveClock = 0
+	} else {
+		p.HalfmoveClock++
+	}
+	if !p.IsWhiteTurn {
+		p.FullmoveNumber++
+	}
+	p.IsWhiteTurn = !p.IsWhiteTurn
+}
+
+func (p *FENPosition) homeRank() int {
+	if p.IsWhiteTurn {
+		return 0
+	}
+	return 7
+}
+
+func (p *FENPosition) kingFileOnRank(rank int) int {
+	kingChar := byte('K')
+	if rank == 7 {
+		kingChar = 'k'
+	}
+	for file := 0; file < 8; file++ {
+		if p.Squares[rank][file] == kingChar {
+			return file
+		}
+	}
+	return -1
+}
+
+// removeCastlingRights drops the side's castling rights with the rook on rookFile, or all of them if rookFile is -1
+func (p *FENPosition) removeCastlingRights(isWhite bool, rookFile int) {
+	if p.CastlingRights == "-" {
+		return
+	}
+	var remaining strings.Builder
+	for _, right := range p.CastlingRights {
+		isWhiteRight, rightRookFile := castlingRightRookFile(right)
+		if isWhiteRight == isWhite && (rookFile == -1 || rightRookFile == rookFile) {
+			continue
+		}
+		remaining.WriteRune(right)
+	}
+	p.CastlingRights = remaining.String()
+	if p.CastlingRights == "" {
+		p.CastlingRights = "-"
+	}
+}
+
+func castlingRightRookFile(right rune) (isWhite bool, rookFile int) {
+	switch {
+	case right == 'K':
+		return true, 7
+	case right == 'Q':
+		return true, 0
+	case right == 'k':
+		return false, 7
+	case right == 'q':
+		return false, 0
+	case right >= 'A' && right <= 'H':
+		return true, int(right - 'A')
+	case right >= 'a' && right <= 'h':
+		return false, int(right - 'a')
+	}
+	return false, -1
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
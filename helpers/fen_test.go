@@ -0,0 +1,67 @@
+package helpers_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEN", func() {
+	Describe("ValidateFEN", func() {
+		When("the fen is the standard starting position", func() {
+			It("returns no error", func() {
+				Expect(helpers.ValidateFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")).To(Succeed())
+			})
+		})
+		When("the fen is a legal endgame", func() {
+			It("returns no error", func() {
+				Expect(helpers.ValidateFEN("8/8/8/4k3/8/8/4P3/4K3 w - - 0 1")).To(Succeed())
+			})
+		})
+		When("the fen is missing fields", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("8/8/8/4k3/8/8/4P3/4K3 w")).ToNot(Succeed())
+			})
+		})
+		When("a rank does not span 8 files", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("8/8/8/4k4/8/8/4P3/4K3 w - - 0 1")).ToNot(Succeed())
+			})
+		})
+		When("a side is missing its king", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("8/8/8/8/8/8/4P3/4K3 w - - 0 1")).ToNot(Succeed())
+			})
+		})
+		When("a pawn sits on the back rank", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("P7/8/8/4k3/8/8/8/4K3 w - - 0 1")).ToNot(Succeed())
+			})
+		})
+		When("the side that just moved is in check", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("4k3/8/8/8/8/8/8/4K2r b - - 0 1")).ToNot(Succeed())
+			})
+		})
+		When("the side to move is in check", func() {
+			It("returns no error", func() {
+				Expect(helpers.ValidateFEN("4k3/8/8/8/8/8/8/4K2r w - - 0 1")).To(Succeed())
+			})
+		})
+		When("the castling rights do not match the rook placement", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("4k3/8/8/8/8/8/8/4K3 w K - 0 1")).ToNot(Succeed())
+			})
+		})
+		When("the en passant square has no pawn to capture", func() {
+			It("returns an error", func() {
+				Expect(helpers.ValidateFEN("4k3/8/8/8/8/8/8/4K3 b - e3 0 1")).ToNot(Succeed())
+			})
+		})
+		When("the en passant square follows a double pawn push", func() {
+			It("returns no error", func() {
+				Expect(helpers.ValidateFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")).To(Succeed())
+			})
+		})
+	})
+})
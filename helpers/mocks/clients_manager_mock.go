@@ -11,7 +11,9 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
+	clients_manager "github.com/CameronHonis/chess-arbitrator/clients_manager"
 	models "github.com/CameronHonis/chess-arbitrator/models"
 	service "github.com/CameronHonis/service"
 	websocket "github.com/gorilla/websocket"
@@ -103,6 +105,18 @@ func (mr *MockClientsManagerIMockRecorder) Build() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockClientsManagerI)(nil).Build))
 }
 
+// CloseStream mocks base method.
+func (m *MockClientsManagerI) CloseStream(stream *clients_manager.EventStream) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CloseStream", stream)
+}
+
+// CloseStream indicates an expected call of CloseStream.
+func (mr *MockClientsManagerIMockRecorder) CloseStream(stream any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseStream", reflect.TypeOf((*MockClientsManagerI)(nil).CloseStream), stream)
+}
+
 // Config mocks base method.
 func (m *MockClientsManagerI) Config() service.ConfigI {
 	m.ctrl.T.Helper()
@@ -157,6 +171,34 @@ func (mr *MockClientsManagerIMockRecorder) Dispatch(event any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockClientsManagerI)(nil).Dispatch), event)
 }
 
+// HasFeature mocks base method.
+func (m *MockClientsManagerI) HasFeature(clientKey models.Key, feature models.Feature) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasFeature", clientKey, feature)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasFeature indicates an expected call of HasFeature.
+func (mr *MockClientsManagerIMockRecorder) HasFeature(clientKey, feature any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFeature", reflect.TypeOf((*MockClientsManagerI)(nil).HasFeature), clientKey, feature)
+}
+
+// IsPublicTopic mocks base method.
+func (m *MockClientsManagerI) IsPublicTopic(topic models.MessageTopic) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsPublicTopic", topic)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsPublicTopic indicates an expected call of IsPublicTopic.
+func (mr *MockClientsManagerIMockRecorder) IsPublicTopic(topic any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPublicTopic", reflect.TypeOf((*MockClientsManagerI)(nil).IsPublicTopic), topic)
+}
+
 // OnBuild mocks base method.
 func (m *MockClientsManagerI) OnBuild() {
 	m.ctrl.T.Helper()
@@ -181,6 +223,50 @@ func (mr *MockClientsManagerIMockRecorder) OnStart() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnStart", reflect.TypeOf((*MockClientsManagerI)(nil).OnStart))
 }
 
+// OpenStream mocks base method.
+func (m *MockClientsManagerI) OpenStream(topic models.MessageTopic, lastEventId uint64) (*clients_manager.EventStream, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenStream", topic, lastEventId)
+	ret0, _ := ret[0].(*clients_manager.EventStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenStream indicates an expected call of OpenStream.
+func (mr *MockClientsManagerIMockRecorder) OpenStream(topic, lastEventId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenStream", reflect.TypeOf((*MockClientsManagerI)(nil).OpenStream), topic, lastEventId)
+}
+
+// OutboundQueueStats mocks base method.
+func (m *MockClientsManagerI) OutboundQueueStats(clientKey models.Key) (*clients_manager.OutboundQueueStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboundQueueStats", clientKey)
+	ret0, _ := ret[0].(*clients_manager.OutboundQueueStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OutboundQueueStats indicates an expected call of OutboundQueueStats.
+func (mr *MockClientsManagerIMockRecorder) OutboundQueueStats(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundQueueStats", reflect.TypeOf((*MockClientsManagerI)(nil).OutboundQueueStats), clientKey)
+}
+
+// ProtocolVersion mocks base method.
+func (m *MockClientsManagerI) ProtocolVersion(clientKey models.Key) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProtocolVersion", clientKey)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ProtocolVersion indicates an expected call of ProtocolVersion.
+func (mr *MockClientsManagerIMockRecorder) ProtocolVersion(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtocolVersion", reflect.TypeOf((*MockClientsManagerI)(nil).ProtocolVersion), clientKey)
+}
+
 // RemoveEventListener mocks base method.
 func (m *MockClientsManagerI) RemoveEventListener(eventId int) {
 	m.ctrl.T.Helper()
@@ -193,6 +279,33 @@ func (mr *MockClientsManagerIMockRecorder) RemoveEventListener(eventId any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveEventListener", reflect.TypeOf((*MockClientsManagerI)(nil).RemoveEventListener), eventId)
 }
 
+// RoundTripTime mocks base method.
+func (m *MockClientsManagerI) RoundTripTime(clientKey models.Key) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RoundTripTime", clientKey)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RoundTripTime indicates an expected call of RoundTripTime.
+func (mr *MockClientsManagerIMockRecorder) RoundTripTime(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RoundTripTime", reflect.TypeOf((*MockClientsManagerI)(nil).RoundTripTime), clientKey)
+}
+
+// ServeConn mocks base method.
+func (m *MockClientsManagerI) ServeConn(conn *websocket.Conn) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ServeConn", conn)
+}
+
+// ServeConn indicates an expected call of ServeConn.
+func (mr *MockClientsManagerIMockRecorder) ServeConn(conn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServeConn", reflect.TypeOf((*MockClientsManagerI)(nil).ServeConn), conn)
+}
+
 // SetParent mocks base method.
 func (m *MockClientsManagerI) SetParent(parent service.ServiceI) {
 	m.ctrl.T.Helper()
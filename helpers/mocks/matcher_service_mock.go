@@ -56,6 +56,20 @@ func (mr *MockMatcherServiceIMockRecorder) AcceptChallenge(challengedKey, challe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).AcceptChallenge), challengedKey, challengerKey)
 }
 
+// AcceptInviteChallenge mocks base method.
+func (m *MockMatcherServiceI) AcceptInviteChallenge(inviteToken string, acceptorKey models.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInviteChallenge", inviteToken, acceptorKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptInviteChallenge indicates an expected call of AcceptInviteChallenge.
+func (mr *MockMatcherServiceIMockRecorder) AcceptInviteChallenge(inviteToken, acceptorKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInviteChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).AcceptInviteChallenge), inviteToken, acceptorKey)
+}
+
 // AddDependency mocks base method.
 func (m *MockMatcherServiceI) AddDependency(service service.ServiceI) {
 	m.ctrl.T.Helper()
@@ -122,6 +136,20 @@ func (mr *MockMatcherServiceIMockRecorder) Build() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockMatcherServiceI)(nil).Build))
 }
 
+// ChallengePolicy mocks base method.
+func (m *MockMatcherServiceI) ChallengePolicy(clientKey models.Key) *models.ChallengePolicy {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChallengePolicy", clientKey)
+	ret0, _ := ret[0].(*models.ChallengePolicy)
+	return ret0
+}
+
+// ChallengePolicy indicates an expected call of ChallengePolicy.
+func (mr *MockMatcherServiceIMockRecorder) ChallengePolicy(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChallengePolicy", reflect.TypeOf((*MockMatcherServiceI)(nil).ChallengePolicy), clientKey)
+}
+
 // Config mocks base method.
 func (m *MockMatcherServiceI) Config() service.ConfigI {
 	m.ctrl.T.Helper()
@@ -176,6 +204,21 @@ func (mr *MockMatcherServiceIMockRecorder) Dispatch(event any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockMatcherServiceI)(nil).Dispatch), event)
 }
 
+// EndedMatchById mocks base method.
+func (m *MockMatcherServiceI) EndedMatchById(matchId string) (*models.Match, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndedMatchById", matchId)
+	ret0, _ := ret[0].(*models.Match)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndedMatchById indicates an expected call of EndedMatchById.
+func (mr *MockMatcherServiceIMockRecorder) EndedMatchById(matchId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndedMatchById", reflect.TypeOf((*MockMatcherServiceI)(nil).EndedMatchById), matchId)
+}
+
 // ExecuteMove mocks base method.
 func (m *MockMatcherServiceI) ExecuteMove(matchId string, move *chess.Move) error {
 	m.ctrl.T.Helper()
@@ -220,6 +263,64 @@ func (mr *MockMatcherServiceIMockRecorder) InboundChallenges(challengedKey any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InboundChallenges", reflect.TypeOf((*MockMatcherServiceI)(nil).InboundChallenges), challengedKey)
 }
 
+// InviteChallenge mocks base method.
+func (m *MockMatcherServiceI) InviteChallenge(inviteToken string) (*models.Challenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteChallenge", inviteToken)
+	ret0, _ := ret[0].(*models.Challenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteChallenge indicates an expected call of InviteChallenge.
+func (mr *MockMatcherServiceIMockRecorder) InviteChallenge(inviteToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).InviteChallenge), inviteToken)
+}
+
+// Leaderboard mocks base method.
+func (m *MockMatcherServiceI) Leaderboard(category models.RatingCategory, limit int) *models.Leaderboard {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Leaderboard", category, limit)
+	ret0, _ := ret[0].(*models.Leaderboard)
+	return ret0
+}
+
+// Leaderboard indicates an expected call of Leaderboard.
+func (mr *MockMatcherServiceIMockRecorder) Leaderboard(category, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leaderboard", reflect.TypeOf((*MockMatcherServiceI)(nil).Leaderboard), category, limit)
+}
+
+// LegalMoves mocks base method.
+func (m *MockMatcherServiceI) LegalMoves(matchId string) ([]*chess.Move, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LegalMoves", matchId)
+	ret0, _ := ret[0].([]*chess.Move)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LegalMoves indicates an expected call of LegalMoves.
+func (mr *MockMatcherServiceIMockRecorder) LegalMoves(matchId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LegalMoves", reflect.TypeOf((*MockMatcherServiceI)(nil).LegalMoves), matchId)
+}
+
+// LiveMatches mocks base method.
+func (m *MockMatcherServiceI) LiveMatches() []*models.Match {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiveMatches")
+	ret0, _ := ret[0].([]*models.Match)
+	return ret0
+}
+
+// LiveMatches indicates an expected call of LiveMatches.
+func (mr *MockMatcherServiceIMockRecorder) LiveMatches() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveMatches", reflect.TypeOf((*MockMatcherServiceI)(nil).LiveMatches))
+}
+
 // MatchByClientKey mocks base method.
 func (m *MockMatcherServiceI) MatchByClientKey(clientKey models.Key) (*models.Match, error) {
 	m.ctrl.T.Helper()
@@ -289,6 +390,20 @@ func (mr *MockMatcherServiceIMockRecorder) OutboundChallenges(challengerKey any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboundChallenges", reflect.TypeOf((*MockMatcherServiceI)(nil).OutboundChallenges), challengerKey)
 }
 
+// RecentMatches mocks base method.
+func (m *MockMatcherServiceI) RecentMatches(clientKey models.Key, limit int) []*models.Match {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecentMatches", clientKey, limit)
+	ret0, _ := ret[0].([]*models.Match)
+	return ret0
+}
+
+// RecentMatches indicates an expected call of RecentMatches.
+func (mr *MockMatcherServiceIMockRecorder) RecentMatches(clientKey, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecentMatches", reflect.TypeOf((*MockMatcherServiceI)(nil).RecentMatches), clientKey, limit)
+}
+
 // RemoveEventListener mocks base method.
 func (m *MockMatcherServiceI) RemoveEventListener(eventId int) {
 	m.ctrl.T.Helper()
@@ -315,6 +430,21 @@ func (mr *MockMatcherServiceIMockRecorder) RequestChallenge(challenge any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).RequestChallenge), challenge)
 }
 
+// RequestInviteChallenge mocks base method.
+func (m *MockMatcherServiceI) RequestInviteChallenge(challenge *models.Challenge) (*models.Challenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestInviteChallenge", challenge)
+	ret0, _ := ret[0].(*models.Challenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestInviteChallenge indicates an expected call of RequestInviteChallenge.
+func (mr *MockMatcherServiceIMockRecorder) RequestInviteChallenge(challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestInviteChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).RequestInviteChallenge), challenge)
+}
+
 // ResignMatch mocks base method.
 func (m *MockMatcherServiceI) ResignMatch(matchId string, clientKey models.Key) error {
 	m.ctrl.T.Helper()
@@ -329,6 +459,18 @@ func (mr *MockMatcherServiceIMockRecorder) ResignMatch(matchId, clientKey any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResignMatch", reflect.TypeOf((*MockMatcherServiceI)(nil).ResignMatch), matchId, clientKey)
 }
 
+// RevokeAllChallenges mocks base method.
+func (m *MockMatcherServiceI) RevokeAllChallenges(challengerKey models.Key) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RevokeAllChallenges", challengerKey)
+}
+
+// RevokeAllChallenges indicates an expected call of RevokeAllChallenges.
+func (mr *MockMatcherServiceIMockRecorder) RevokeAllChallenges(challengerKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllChallenges", reflect.TypeOf((*MockMatcherServiceI)(nil).RevokeAllChallenges), challengerKey)
+}
+
 // RevokeChallenge mocks base method.
 func (m *MockMatcherServiceI) RevokeChallenge(challengerKey, challengedKey models.Key) error {
 	m.ctrl.T.Helper()
@@ -343,6 +485,34 @@ func (mr *MockMatcherServiceIMockRecorder) RevokeChallenge(challengerKey, challe
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).RevokeChallenge), challengerKey, challengedKey)
 }
 
+// RevokeInviteChallenge mocks base method.
+func (m *MockMatcherServiceI) RevokeInviteChallenge(inviteToken string, challengerKey models.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInviteChallenge", inviteToken, challengerKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInviteChallenge indicates an expected call of RevokeInviteChallenge.
+func (mr *MockMatcherServiceIMockRecorder) RevokeInviteChallenge(inviteToken, challengerKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInviteChallenge", reflect.TypeOf((*MockMatcherServiceI)(nil).RevokeInviteChallenge), inviteToken, challengerKey)
+}
+
+// SetChallengePolicy mocks base method.
+func (m *MockMatcherServiceI) SetChallengePolicy(clientKey models.Key, policy *models.ChallengePolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChallengePolicy", clientKey, policy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChallengePolicy indicates an expected call of SetChallengePolicy.
+func (mr *MockMatcherServiceIMockRecorder) SetChallengePolicy(clientKey, policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChallengePolicy", reflect.TypeOf((*MockMatcherServiceI)(nil).SetChallengePolicy), clientKey, policy)
+}
+
 // SetParent mocks base method.
 func (m *MockMatcherServiceI) SetParent(parent service.ServiceI) {
 	m.ctrl.T.Helper()
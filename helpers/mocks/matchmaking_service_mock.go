@@ -41,17 +41,31 @@ func (m *MockMatchmakingServiceI) EXPECT() *MockMatchmakingServiceIMockRecorder
 }
 
 // AddClient mocks base method.
-func (m *MockMatchmakingServiceI) AddClient(client *models.ClientProfile, timeControl *models.TimeControl) error {
+func (m *MockMatchmakingServiceI) AddClient(client *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddClient", client, timeControl)
+	ret := m.ctrl.Call(m, "AddClient", client, timeControl, variant)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddClient indicates an expected call of AddClient.
-func (mr *MockMatchmakingServiceIMockRecorder) AddClient(client, timeControl any) *gomock.Call {
+func (mr *MockMatchmakingServiceIMockRecorder) AddClient(client, timeControl, variant any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClient", reflect.TypeOf((*MockMatchmakingServiceI)(nil).AddClient), client, timeControl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClient", reflect.TypeOf((*MockMatchmakingServiceI)(nil).AddClient), client, timeControl, variant)
+}
+
+// AddClientToQueues mocks base method.
+func (m *MockMatchmakingServiceI) AddClientToQueues(client *models.ClientProfile, queues []*models.MatchmakingQueue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClientToQueues", client, queues)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClientToQueues indicates an expected call of AddClientToQueues.
+func (mr *MockMatchmakingServiceIMockRecorder) AddClientToQueues(client, queues any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClientToQueues", reflect.TypeOf((*MockMatchmakingServiceI)(nil).AddClientToQueues), client, queues)
 }
 
 // AddDependency mocks base method.
@@ -80,6 +94,20 @@ func (mr *MockMatchmakingServiceIMockRecorder) AddEventListener(eventVariant, fn
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEventListener", reflect.TypeOf((*MockMatchmakingServiceI)(nil).AddEventListener), eventVariant, fn)
 }
 
+// AvoidList mocks base method.
+func (m *MockMatchmakingServiceI) AvoidList(clientKey models.Key) []models.Key {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvoidList", clientKey)
+	ret0, _ := ret[0].([]models.Key)
+	return ret0
+}
+
+// AvoidList indicates an expected call of AvoidList.
+func (mr *MockMatchmakingServiceIMockRecorder) AvoidList(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvoidList", reflect.TypeOf((*MockMatchmakingServiceI)(nil).AvoidList), clientKey)
+}
+
 // Build mocks base method.
 func (m *MockMatchmakingServiceI) Build() {
 	m.ctrl.T.Helper()
@@ -92,6 +120,21 @@ func (mr *MockMatchmakingServiceIMockRecorder) Build() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockMatchmakingServiceI)(nil).Build))
 }
 
+// ClientStatuses mocks base method.
+func (m *MockMatchmakingServiceI) ClientStatuses(clientKey models.Key) ([]*models.MatchmakingStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientStatuses", clientKey)
+	ret0, _ := ret[0].([]*models.MatchmakingStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientStatuses indicates an expected call of ClientStatuses.
+func (mr *MockMatchmakingServiceIMockRecorder) ClientStatuses(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientStatuses", reflect.TypeOf((*MockMatchmakingServiceI)(nil).ClientStatuses), clientKey)
+}
+
 // Config mocks base method.
 func (m *MockMatchmakingServiceI) Config() service.ConfigI {
 	m.ctrl.T.Helper()
@@ -106,6 +149,35 @@ func (mr *MockMatchmakingServiceIMockRecorder) Config() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockMatchmakingServiceI)(nil).Config))
 }
 
+// ConfirmMatch mocks base method.
+func (m *MockMatchmakingServiceI) ConfirmMatch(clientKey models.Key, readyCheckId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMatch", clientKey, readyCheckId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmMatch indicates an expected call of ConfirmMatch.
+func (mr *MockMatchmakingServiceIMockRecorder) ConfirmMatch(clientKey, readyCheckId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMatch", reflect.TypeOf((*MockMatchmakingServiceI)(nil).ConfirmMatch), clientKey, readyCheckId)
+}
+
+// CreateParty mocks base method.
+func (m *MockMatchmakingServiceI) CreateParty(hostKey models.Key, mode models.PartyMode, timeControl *models.TimeControl, variant models.Variant, isRated bool) (*models.Party, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateParty", hostKey, mode, timeControl, variant, isRated)
+	ret0, _ := ret[0].(*models.Party)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateParty indicates an expected call of CreateParty.
+func (mr *MockMatchmakingServiceIMockRecorder) CreateParty(hostKey, mode, timeControl, variant, isRated any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateParty", reflect.TypeOf((*MockMatchmakingServiceI)(nil).CreateParty), hostKey, mode, timeControl, variant, isRated)
+}
+
 // Dependencies mocks base method.
 func (m *MockMatchmakingServiceI) Dependencies() []service.ServiceI {
 	m.ctrl.T.Helper()
@@ -132,18 +204,58 @@ func (mr *MockMatchmakingServiceIMockRecorder) Dispatch(event any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockMatchmakingServiceI)(nil).Dispatch), event)
 }
 
+// ForgetClient mocks base method.
+func (m *MockMatchmakingServiceI) ForgetClient(clientKey models.Key) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ForgetClient", clientKey)
+}
+
+// ForgetClient indicates an expected call of ForgetClient.
+func (mr *MockMatchmakingServiceIMockRecorder) ForgetClient(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgetClient", reflect.TypeOf((*MockMatchmakingServiceI)(nil).ForgetClient), clientKey)
+}
+
 // GetClientCountByTimeControl mocks base method.
-func (m *MockMatchmakingServiceI) GetClientCountByTimeControl(timeControl *models.TimeControl) int {
+func (m *MockMatchmakingServiceI) GetClientCountByTimeControl(timeControl *models.TimeControl, variant models.Variant) int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetClientCountByTimeControl", timeControl)
+	ret := m.ctrl.Call(m, "GetClientCountByTimeControl", timeControl, variant)
 	ret0, _ := ret[0].(int)
 	return ret0
 }
 
 // GetClientCountByTimeControl indicates an expected call of GetClientCountByTimeControl.
-func (mr *MockMatchmakingServiceIMockRecorder) GetClientCountByTimeControl(timeControl any) *gomock.Call {
+func (mr *MockMatchmakingServiceIMockRecorder) GetClientCountByTimeControl(timeControl, variant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClientCountByTimeControl", reflect.TypeOf((*MockMatchmakingServiceI)(nil).GetClientCountByTimeControl), timeControl, variant)
+}
+
+// JoinMatchmaking mocks base method.
+func (m *MockMatchmakingServiceI) JoinMatchmaking(clientKey models.Key, findMatch *models.FindMatchMessageContent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JoinMatchmaking", clientKey, findMatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// JoinMatchmaking indicates an expected call of JoinMatchmaking.
+func (mr *MockMatchmakingServiceIMockRecorder) JoinMatchmaking(clientKey, findMatch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClientCountByTimeControl", reflect.TypeOf((*MockMatchmakingServiceI)(nil).GetClientCountByTimeControl), timeControl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JoinMatchmaking", reflect.TypeOf((*MockMatchmakingServiceI)(nil).JoinMatchmaking), clientKey, findMatch)
+}
+
+// LeaveParty mocks base method.
+func (m *MockMatchmakingServiceI) LeaveParty(clientKey models.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaveParty", clientKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LeaveParty indicates an expected call of LeaveParty.
+func (mr *MockMatchmakingServiceIMockRecorder) LeaveParty(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaveParty", reflect.TypeOf((*MockMatchmakingServiceI)(nil).LeaveParty), clientKey)
 }
 
 // OnBuild mocks base method.
@@ -170,18 +282,61 @@ func (mr *MockMatchmakingServiceIMockRecorder) OnStart() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnStart", reflect.TypeOf((*MockMatchmakingServiceI)(nil).OnStart))
 }
 
+// Party mocks base method.
+func (m *MockMatchmakingServiceI) Party(code string) (*models.Party, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Party", code)
+	ret0, _ := ret[0].(*models.Party)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Party indicates an expected call of Party.
+func (mr *MockMatchmakingServiceIMockRecorder) Party(code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Party", reflect.TypeOf((*MockMatchmakingServiceI)(nil).Party), code)
+}
+
+// PoolSizes mocks base method.
+func (m *MockMatchmakingServiceI) PoolSizes() []*models.PoolSize {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PoolSizes")
+	ret0, _ := ret[0].([]*models.PoolSize)
+	return ret0
+}
+
+// PoolSizes indicates an expected call of PoolSizes.
+func (mr *MockMatchmakingServiceIMockRecorder) PoolSizes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PoolSizes", reflect.TypeOf((*MockMatchmakingServiceI)(nil).PoolSizes))
+}
+
+// QueueInParty mocks base method.
+func (m *MockMatchmakingServiceI) QueueInParty(clientKey models.Key, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueueInParty", clientKey, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QueueInParty indicates an expected call of QueueInParty.
+func (mr *MockMatchmakingServiceIMockRecorder) QueueInParty(clientKey, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueInParty", reflect.TypeOf((*MockMatchmakingServiceI)(nil).QueueInParty), clientKey, code)
+}
+
 // RemoveClient mocks base method.
-func (m *MockMatchmakingServiceI) RemoveClient(clientKey models.Key) error {
+func (m *MockMatchmakingServiceI) RemoveClient(clientKey models.Key, reason models.MatchmakingLeftReason) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveClient", clientKey)
+	ret := m.ctrl.Call(m, "RemoveClient", clientKey, reason)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveClient indicates an expected call of RemoveClient.
-func (mr *MockMatchmakingServiceIMockRecorder) RemoveClient(clientKey any) *gomock.Call {
+func (mr *MockMatchmakingServiceIMockRecorder) RemoveClient(clientKey, reason any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClient", reflect.TypeOf((*MockMatchmakingServiceI)(nil).RemoveClient), clientKey)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClient", reflect.TypeOf((*MockMatchmakingServiceI)(nil).RemoveClient), clientKey, reason)
 }
 
 // RemoveEventListener mocks base method.
@@ -196,6 +351,18 @@ func (mr *MockMatchmakingServiceIMockRecorder) RemoveEventListener(eventId any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveEventListener", reflect.TypeOf((*MockMatchmakingServiceI)(nil).RemoveEventListener), eventId)
 }
 
+// SetAvoidList mocks base method.
+func (m *MockMatchmakingServiceI) SetAvoidList(clientKey models.Key, avoidedKeys []models.Key) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetAvoidList", clientKey, avoidedKeys)
+}
+
+// SetAvoidList indicates an expected call of SetAvoidList.
+func (mr *MockMatchmakingServiceIMockRecorder) SetAvoidList(clientKey, avoidedKeys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvoidList", reflect.TypeOf((*MockMatchmakingServiceI)(nil).SetAvoidList), clientKey, avoidedKeys)
+}
+
 // SetParent mocks base method.
 func (m *MockMatchmakingServiceI) SetParent(parent service.ServiceI) {
 	m.ctrl.T.Helper()
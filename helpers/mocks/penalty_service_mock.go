@@ -0,0 +1,250 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../penalty/penalty_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=../penalty/penalty_service.go -destination mocks/penalty_service_mock.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	models "github.com/CameronHonis/chess-arbitrator/models"
+	service "github.com/CameronHonis/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPenaltyServiceI is a mock of PenaltyServiceI interface.
+type MockPenaltyServiceI struct {
+	ctrl     *gomock.Controller
+	recorder *MockPenaltyServiceIMockRecorder
+}
+
+// MockPenaltyServiceIMockRecorder is the mock recorder for MockPenaltyServiceI.
+type MockPenaltyServiceIMockRecorder struct {
+	mock *MockPenaltyServiceI
+}
+
+// NewMockPenaltyServiceI creates a new mock instance.
+func NewMockPenaltyServiceI(ctrl *gomock.Controller) *MockPenaltyServiceI {
+	mock := &MockPenaltyServiceI{ctrl: ctrl}
+	mock.recorder = &MockPenaltyServiceIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPenaltyServiceI) EXPECT() *MockPenaltyServiceIMockRecorder {
+	return m.recorder
+}
+
+// AddDependency mocks base method.
+func (m *MockPenaltyServiceI) AddDependency(service service.ServiceI) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddDependency", service)
+}
+
+// AddDependency indicates an expected call of AddDependency.
+func (mr *MockPenaltyServiceIMockRecorder) AddDependency(service any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDependency", reflect.TypeOf((*MockPenaltyServiceI)(nil).AddDependency), service)
+}
+
+// AddEventListener mocks base method.
+func (m *MockPenaltyServiceI) AddEventListener(eventVariant service.EventVariant, fn service.EventHandler) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEventListener", eventVariant, fn)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// AddEventListener indicates an expected call of AddEventListener.
+func (mr *MockPenaltyServiceIMockRecorder) AddEventListener(eventVariant, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEventListener", reflect.TypeOf((*MockPenaltyServiceI)(nil).AddEventListener), eventVariant, fn)
+}
+
+// Build mocks base method.
+func (m *MockPenaltyServiceI) Build() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Build")
+}
+
+// Build indicates an expected call of Build.
+func (mr *MockPenaltyServiceIMockRecorder) Build() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockPenaltyServiceI)(nil).Build))
+}
+
+// Config mocks base method.
+func (m *MockPenaltyServiceI) Config() service.ConfigI {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Config")
+	ret0, _ := ret[0].(service.ConfigI)
+	return ret0
+}
+
+// Config indicates an expected call of Config.
+func (mr *MockPenaltyServiceIMockRecorder) Config() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockPenaltyServiceI)(nil).Config))
+}
+
+// CooldownRemaining mocks base method.
+func (m *MockPenaltyServiceI) CooldownRemaining(clientKey models.Key) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CooldownRemaining", clientKey)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// CooldownRemaining indicates an expected call of CooldownRemaining.
+func (mr *MockPenaltyServiceIMockRecorder) CooldownRemaining(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CooldownRemaining", reflect.TypeOf((*MockPenaltyServiceI)(nil).CooldownRemaining), clientKey)
+}
+
+// Dependencies mocks base method.
+func (m *MockPenaltyServiceI) Dependencies() []service.ServiceI {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dependencies")
+	ret0, _ := ret[0].([]service.ServiceI)
+	return ret0
+}
+
+// Dependencies indicates an expected call of Dependencies.
+func (mr *MockPenaltyServiceIMockRecorder) Dependencies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dependencies", reflect.TypeOf((*MockPenaltyServiceI)(nil).Dependencies))
+}
+
+// Dispatch mocks base method.
+func (m *MockPenaltyServiceI) Dispatch(event service.EventI) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Dispatch", event)
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockPenaltyServiceIMockRecorder) Dispatch(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockPenaltyServiceI)(nil).Dispatch), event)
+}
+
+// OnBuild mocks base method.
+func (m *MockPenaltyServiceI) OnBuild() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnBuild")
+}
+
+// OnBuild indicates an expected call of OnBuild.
+func (mr *MockPenaltyServiceIMockRecorder) OnBuild() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnBuild", reflect.TypeOf((*MockPenaltyServiceI)(nil).OnBuild))
+}
+
+// OnStart mocks base method.
+func (m *MockPenaltyServiceI) OnStart() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnStart")
+}
+
+// OnStart indicates an expected call of OnStart.
+func (mr *MockPenaltyServiceIMockRecorder) OnStart() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnStart", reflect.TypeOf((*MockPenaltyServiceI)(nil).OnStart))
+}
+
+// Penalties mocks base method.
+func (m *MockPenaltyServiceI) Penalties() []*models.Penalty {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Penalties")
+	ret0, _ := ret[0].([]*models.Penalty)
+	return ret0
+}
+
+// Penalties indicates an expected call of Penalties.
+func (mr *MockPenaltyServiceIMockRecorder) Penalties() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Penalties", reflect.TypeOf((*MockPenaltyServiceI)(nil).Penalties))
+}
+
+// Penalty mocks base method.
+func (m *MockPenaltyServiceI) Penalty(clientKey models.Key) *models.Penalty {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Penalty", clientKey)
+	ret0, _ := ret[0].(*models.Penalty)
+	return ret0
+}
+
+// Penalty indicates an expected call of Penalty.
+func (mr *MockPenaltyServiceIMockRecorder) Penalty(clientKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Penalty", reflect.TypeOf((*MockPenaltyServiceI)(nil).Penalty), clientKey)
+}
+
+// RecordMatchEnded mocks base method.
+func (m *MockPenaltyServiceI) RecordMatchEnded(match *models.Match, isLoserConnected bool) *models.Penalty {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordMatchEnded", match, isLoserConnected)
+	ret0, _ := ret[0].(*models.Penalty)
+	return ret0
+}
+
+// RecordMatchEnded indicates an expected call of RecordMatchEnded.
+func (mr *MockPenaltyServiceIMockRecorder) RecordMatchEnded(match, isLoserConnected any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMatchEnded", reflect.TypeOf((*MockPenaltyServiceI)(nil).RecordMatchEnded), match, isLoserConnected)
+}
+
+// RecordOffence mocks base method.
+func (m *MockPenaltyServiceI) RecordOffence(clientKey models.Key, kind models.OffenceKind, matchId string) *models.Penalty {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordOffence", clientKey, kind, matchId)
+	ret0, _ := ret[0].(*models.Penalty)
+	return ret0
+}
+
+// RecordOffence indicates an expected call of RecordOffence.
+func (mr *MockPenaltyServiceIMockRecorder) RecordOffence(clientKey, kind, matchId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordOffence", reflect.TypeOf((*MockPenaltyServiceI)(nil).RecordOffence), clientKey, kind, matchId)
+}
+
+// RemoveEventListener mocks base method.
+func (m *MockPenaltyServiceI) RemoveEventListener(eventId int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoveEventListener", eventId)
+}
+
+// RemoveEventListener indicates an expected call of RemoveEventListener.
+func (mr *MockPenaltyServiceIMockRecorder) RemoveEventListener(eventId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveEventListener", reflect.TypeOf((*MockPenaltyServiceI)(nil).RemoveEventListener), eventId)
+}
+
+// SetParent mocks base method.
+func (m *MockPenaltyServiceI) SetParent(parent service.ServiceI) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetParent", parent)
+}
+
+// SetParent indicates an expected call of SetParent.
+func (mr *MockPenaltyServiceIMockRecorder) SetParent(parent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetParent", reflect.TypeOf((*MockPenaltyServiceI)(nil).SetParent), parent)
+}
+
+// Start mocks base method.
+func (m *MockPenaltyServiceI) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockPenaltyServiceIMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockPenaltyServiceI)(nil).Start))
+}
@@ -0,0 +1,206 @@
+package helpers
+
+const STANDARD_STARTING_FEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+const HORDE_STARTING_FEN = "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+
+// FENMove is a move on a FENPosition with zero-indexed squares. Castling moves are encoded as the king moving onto
+// its own rook.
+type FENMove struct {
+	StartRank  int
+	StartFile  int
+	EndRank    int
+	EndFile    int
+	PromotedTo byte
+	IsCastle   bool
+}
+
+var knightOffsets = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+var kingOffsets = [][2]int{{1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+var bishopDirs = [][2]int{{1, 1}, {1, -1}, {-1, -1}, {-1, 1}}
+var rookDirs = [][2]int{{1, 0}, {0, -1}, {-1, 0}, {0, 1}}
+
+func (p *FENPosition) Play(move FENMove) error {
+	if move.IsCastle {
+		return p.Castle(move.EndFile > move.StartFile)
+	}
+	p.ApplyMove(move.StartRank, move.StartFile, move.EndRank, move.EndFile, move.PromotedTo)
+	return nil
+}
+
+// IsInCheck reports whether the side to move has a king under attack. Sides without a king (horde) are never in
+// check.
+func (p *FENPosition) IsInCheck() bool {
+	kingChar := byte('K')
+	if !p.IsWhiteTurn {
+		kingChar = 'k'
+	}
+	rank, file, ok := p.FindPiece(kingChar)
+	return ok && p.IsSquareAttacked(rank, file, !p.IsWhiteTurn)
+}
+
+func (p *FENPosition) PieceCount(isWhite bool) int {
+	count := 0
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			if piece := p.Squares[rank][file]; piece != EMPTY_SQUARE && isWhitePiece(piece) == isWhite {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func (p *FENPosition) IsLegalMove(move FENMove) bool {
+	for _, legalMove := range p.LegalMoves() {
+		if legalMove == move {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *FENPosition) LegalMoves() []FENMove {
+	legalMoves := make([]FENMove, 0)
+	for _, move := range p.pseudoLegalMoves() {
+		next := p.Copy()
+		if next.Play(move) != nil {
+			continue
+		}
+		// the mover's king must not be left attacked once the turn has passed
+		next.IsWhiteTurn = !next.IsWhiteTurn
+		if next.IsInCheck() {
+			continue
+		}
+		legalMoves = append(legalMoves, move)
+	}
+	return legalMoves
+}
+
+func (p *FENPosition) pseudoLegalMoves() []FENMove {
+	moves := make([]FENMove, 0)
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			piece := p.Squares[rank][file]
+			if piece == EMPTY_SQUARE || isWhitePiece(piece) != p.IsWhiteTurn {
+				continue
+			}
+			switch piece | 0x20 {
+			case 'p':
+				moves = append(moves, p.pawnMoves(rank, file)...)
+			case 'n':
+				moves = append(moves, p.stepMoves(rank, file, knightOffsets)...)
+			case 'b':
+				moves = append(moves, p.slideMoves(rank, file, bishopDirs)...)
+			case 'r':
+				moves = append(moves, p.slideMoves(rank, file, rookDirs)...)
+			case 'q':
+				moves = append(moves, p.slideMoves(rank, file, bishopDirs)...)
+				moves = append(moves, p.slideMoves(rank, file, rookDirs)...)
+			case 'k':
+				moves = append(moves, p.stepMoves(rank, file, kingOffsets)...)
+				moves = append(moves, p.castlingMoves(rank, file)...)
+			}
+		}
+	}
+	return moves
+}
+
+func (p *FENPosition) pawnMoves(rank, file int) []FENMove {
+	moves := make([]FENMove, 0)
+	dir, startRanks, lastRank, promotions := 1, []int{0, 1}, 7, "QRBN"
+	if !p.IsWhiteTurn {
+		dir, startRanks, lastRank, promotions = -1, []int{6}, 0, "qrbn"
+	}
+	addMove := func(endRank, endFile int) {
+		if endRank != lastRank {
+			moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: endRank, EndFile: endFile})
+			return
+		}
+		for i := 0; i < len(promotions); i++ {
+			moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: endRank, EndFile: endFile, PromotedTo: promotions[i]})
+		}
+	}
+
+	if p.isEmpty(rank+dir, file) {
+		addMove(rank+dir, file)
+		for _, startRank := range startRanks {
+			if rank == startRank && p.isEmpty(rank+2*dir, file) {
+				addMove(rank+2*dir, file)
+			}
+		}
+	}
+	for _, fileOffset := range []int{-1, 1} {
+		endRank, endFile := rank+dir, file+fileOffset
+		if !onBoard(endRank, endFile) {
+			continue
+		}
+		isEnPassant := p.EnPassant == string([]byte{'a' + byte(endFile), '1' + byte(endRank)})
+		if p.isEnemy(endRank, endFile) || isEnPassant {
+			addMove(endRank, endFile)
+		}
+	}
+	return moves
+}
+
+func (p *FENPosition) stepMoves(rank, file int, offsets [][2]int) []FENMove {
+	moves := make([]FENMove, 0)
+	for _, offset := range offsets {
+		endRank, endFile := rank+offset[0], file+offset[1]
+		if p.isEmpty(endRank, endFile) || p.isEnemy(endRank, endFile) {
+			moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: endRank, EndFile: endFile})
+		}
+	}
+	return moves
+}
+
+func (p *FENPosition) slideMoves(rank, file int, dirs [][2]int) []FENMove {
+	moves := make([]FENMove, 0)
+	for _, dir := range dirs {
+		for endRank, endFile := rank+dir[0], file+dir[1]; onBoard(endRank, endFile); endRank, endFile = endRank+dir[0], endFile+dir[1] {
+			if p.isEmpty(endRank, endFile) {
+				moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: endRank, EndFile: endFile})
+				continue
+			}
+			if p.isEnemy(endRank, endFile) {
+				moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: endRank, EndFile: endFile})
+			}
+			break
+		}
+	}
+	return moves
+}
+
+func (p *FENPosition) castlingMoves(rank, file int) []FENMove {
+	moves := make([]FENMove, 0)
+	if rank != p.homeRank() {
+		return moves
+	}
+	for _, isKingside := range []bool{true, false} {
+		rookFile, ok := p.CastlingRookFile(isKingside)
+		if !ok || p.Copy().Castle(isKingside) != nil {
+			continue
+		}
+		moves = append(moves, FENMove{StartRank: rank, StartFile: file, EndRank: rank, EndFile: rookFile, IsCastle: true})
+	}
+	return moves
+}
+
+func (p *FENPosition) isEmpty(rank, file int) bool {
+	return onBoard(rank, file) && p.Squares[rank][file] == EMPTY_SQUARE
+}
+
+func (p *FENPosition) isEnemy(rank, file int) bool {
+	if !onBoard(rank, file) {
+		return false
+	}
+	piece := p.Squares[rank][file]
+	return piece != EMPTY_SQUARE && isWhitePiece(piece) != p.IsWhiteTurn
+}
+
+func isWhitePiece(piece byte) bool {
+	return piece >= 'A' && piece <= 'Z'
+}
+
+func onBoard(rank, file int) bool {
+	return rank >= 0 && rank < 8 && file >= 0 && file < 8
+}
@@ -0,0 +1,57 @@
+package helpers_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func perft(position *helpers.FENPosition, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	nodeCount := 0
+	for _, move := range position.LegalMoves() {
+		next := position.Copy()
+		Expect(next.Play(move)).To(Succeed())
+		nodeCount += perft(next, depth-1)
+	}
+	return nodeCount
+}
+
+var _ = Describe("Move generation", func() {
+	When("the position is the standard starting position", func() {
+		It("generates the known number of move sequences", func() {
+			position, err := helpers.ParseFEN(helpers.STANDARD_STARTING_FEN)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(perft(position, 1)).To(Equal(20))
+			Expect(perft(position, 3)).To(Equal(8902))
+		})
+	})
+	When("the position has castling, en passant and promotions available", func() {
+		It("generates the known number of move sequences", func() {
+			position, err := helpers.ParseFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(perft(position, 1)).To(Equal(48))
+			Expect(perft(position, 2)).To(Equal(2039))
+		})
+	})
+	When("the side to move is checkmated", func() {
+		It("generates no moves", func() {
+			position, err := helpers.ParseFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(position.IsInCheck()).To(BeTrue())
+			Expect(position.LegalMoves()).To(BeEmpty())
+		})
+	})
+	When("white has no king", func() {
+		It("lets first rank pawns double step", func() {
+			position, err := helpers.ParseFEN("4k3/8/8/8/8/8/8/P7 w - - 0 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(position.LegalMoves()).To(ConsistOf(
+				helpers.FENMove{StartRank: 0, StartFile: 0, EndRank: 1, EndFile: 0},
+				helpers.FENMove{StartRank: 0, StartFile: 0, EndRank: 2, EndFile: 0},
+			))
+		})
+	})
+})
@@ -5,7 +5,19 @@ import (
 	"time"
 )
 
+// unambiguous characters for codes that are read out loud or typed in by hand
+const CODE_ALPHABET = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
 func RandomBool() bool {
 	rand.Seed(time.Now().UnixNano())
 	return rand.Intn(2) == 0
 }
+
+func RandomCode(length int) string {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = CODE_ALPHABET[rng.Intn(len(CODE_ALPHABET))]
+	}
+	return string(code)
+}
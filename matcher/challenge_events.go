@@ -12,6 +12,7 @@ const (
 	CHALLENGE_REQUEST_FAILED = "CHALLENGE_REQUEST_FAILED"
 	CHALLENGE_ACCEPTED       = "CHALLENGE_ACCEPTED"
 	CHALLENGE_ACCEPT_FAILED  = "CHALLENGE_ACCEPTED_FAILED"
+	CHALLENGE_EXPIRED        = "CHALLENGE_EXPIRED"
 )
 
 type ChallengeCreatedEventPayload struct {
@@ -101,3 +102,17 @@ func NewChallengeAcceptFailedEvent(challenge *models.Challenge, reason string) *
 		}),
 	}
 }
+
+type ChallengeExpiredEventPayload struct {
+	Challenge *models.Challenge
+}
+
+type ChallengeExpiredEvent struct{ Event }
+
+func NewChallengeExpiredEvent(challenge *models.Challenge) *ChallengeExpiredEvent {
+	return &ChallengeExpiredEvent{
+		Event: *NewEvent(CHALLENGE_EXPIRED, &ChallengeExpiredEventPayload{
+			Challenge: challenge,
+		}),
+	}
+}
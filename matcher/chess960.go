@@ -0,0 +1,57 @@
+package matcher
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	"github.com/CameronHonis/chess-arbitrator/models"
+)
+
+// Chess960Rules castles when the king moves onto one of its own castling rooks, as is conventional in chess960. All
+// other moves are delegated to the chess lib, which never sees castling rights for these matches.
+type Chess960Rules struct {
+	StandardRules
+}
+
+func (r *Chess960Rules) LegalMoves(match *models.Match) ([]*chess.Move, error) {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return nil, positionErr
+	}
+	return toChessMoves(position, position.LegalMoves(), true), nil
+}
+
+func (r *Chess960Rules) IsLegalMove(match *models.Match, move *chess.Move) bool {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return false
+	}
+	fenMove := toFENMove(position, move)
+	if fenMove.IsCastle {
+		return position.IsLegalMove(fenMove)
+	}
+	return r.StandardRules.IsLegalMove(match, move)
+}
+
+func (r *Chess960Rules) PlayMove(match *models.Match, move *chess.Move, matchBuilder *builders.MatchBuilder) error {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return positionErr
+	}
+	fenMove := toFENMove(position, move)
+	if !fenMove.IsCastle {
+		return r.StandardRules.PlayMove(match, move, matchBuilder)
+	}
+	if castleErr := position.Play(fenMove); castleErr != nil {
+		return fmt.Errorf("move %v is not legal: %s", move, castleErr)
+	}
+	newFEN := position.String()
+	board, boardErr := chess.BoardFromFEN(helpers.WithoutCastlingRights(newFEN))
+	if boardErr != nil {
+		return boardErr
+	}
+	matchBuilder.WithBoard(board)
+	matchBuilder.WithCurrentFEN(newFEN)
+	return nil
+}
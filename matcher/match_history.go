@@ -0,0 +1,107 @@
+package matcher
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"sort"
+)
+
+// matchHistory keeps the most recently ended matches, and tallies every rated result into the standings of its
+// rating category. Standings outlive the matches they were tallied from.
+type matchHistory struct {
+	maxSize             int
+	matches             []*models.Match
+	standingsByCategory map[models.RatingCategory]map[models.Key]*models.Standing
+}
+
+func newMatchHistory(maxSize int) *matchHistory {
+	return &matchHistory{
+		maxSize:             maxSize,
+		matches:             make([]*models.Match, 0),
+		standingsByCategory: make(map[models.RatingCategory]map[models.Key]*models.Standing),
+	}
+}
+
+func (h *matchHistory) record(match *models.Match) {
+	if match.Result == models.MATCH_RESULT_IN_PROGRESS {
+		return
+	}
+	h.matches = append(h.matches, match)
+	if len(h.matches) > h.maxSize {
+		h.matches = h.matches[len(h.matches)-h.maxSize:]
+	}
+	if match.IsRated {
+		h.tally(match)
+	}
+}
+
+func (h *matchHistory) tally(match *models.Match) {
+	category := match.RatingCategory()
+	standingByKey, ok := h.standingsByCategory[category]
+	if !ok {
+		standingByKey = make(map[models.Key]*models.Standing)
+		h.standingsByCategory[category] = standingByKey
+	}
+	for _, clientKey := range []models.Key{match.WhiteClientKey, match.BlackClientKey} {
+		standing, ok := standingByKey[clientKey]
+		if !ok {
+			standing = &models.Standing{ClientKey: clientKey}
+			standingByKey[clientKey] = standing
+		}
+		if match.IsDraw() {
+			standing.Draws++
+			standing.Points += 0.5
+		} else if match.WinnerKey() == clientKey {
+			standing.Wins++
+			standing.Points++
+		} else {
+			standing.Losses++
+		}
+	}
+}
+
+func (h *matchHistory) matchById(matchId string) (*models.Match, error) {
+	for _, match := range h.matches {
+		if match.Uuid == matchId {
+			return match, nil
+		}
+	}
+	return nil, fmt.Errorf("no ended match with id %s", matchId)
+}
+
+// recentMatches lists the client's ended matches, newest first
+func (h *matchHistory) recentMatches(clientKey models.Key, limit int) []*models.Match {
+	matches := make([]*models.Match, 0)
+	for i := len(h.matches) - 1; i >= 0 && len(matches) < limit; i-- {
+		match := h.matches[i]
+		if match.WhiteClientKey == clientKey || match.BlackClientKey == clientKey {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// leaderboard ranks by points, breaking ties by wins and then by client key so the order is stable
+func (h *matchHistory) leaderboard(category models.RatingCategory, limit int) *models.Leaderboard {
+	standings := make([]*models.Standing, 0, len(h.standingsByCategory[category]))
+	for _, standing := range h.standingsByCategory[category] {
+		standingCopy := *standing
+		standings = append(standings, &standingCopy)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		if standings[i].Wins != standings[j].Wins {
+			return standings[i].Wins > standings[j].Wins
+		}
+		return standings[i].ClientKey < standings[j].ClientKey
+	})
+	if len(standings) > limit {
+		standings = standings[:limit]
+	}
+	return &models.Leaderboard{
+		RatingCategory: category,
+		Standings:      standings,
+	}
+}
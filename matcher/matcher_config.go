@@ -2,12 +2,23 @@ package matcher
 
 import (
 	. "github.com/CameronHonis/service"
+	"time"
 )
 
 type MatcherServiceConfig struct {
 	ConfigI
+	ChallengeTTL          time.Duration
+	InviteChallengeTTL    time.Duration
+	MaxOutboundChallenges int
+	// how many ended matches are kept for lookup after they finish
+	MatchHistorySize int
 }
 
 func NewMatcherServiceConfig() *MatcherServiceConfig {
-	return &MatcherServiceConfig{}
+	return &MatcherServiceConfig{
+		ChallengeTTL:          5 * time.Minute,
+		InviteChallengeTTL:    30 * time.Minute,
+		MaxOutboundChallenges: 5,
+		MatchHistorySize:      1000,
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/CameronHonis/chess"
 	"github.com/CameronHonis/chess-arbitrator/auth"
 	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
 	"github.com/CameronHonis/chess-arbitrator/models"
 	"github.com/CameronHonis/chess-arbitrator/sub_service"
 	"github.com/CameronHonis/log"
@@ -12,6 +13,7 @@ import (
 	"github.com/CameronHonis/service"
 	"github.com/CameronHonis/set"
 	"math"
+	"sort"
 	"sync"
 	"time"
 )
@@ -20,18 +22,30 @@ type MatcherServiceI interface {
 	service.ServiceI
 	MatchById(matchId string) (*models.Match, error)
 	MatchByClientKey(clientKey models.Key) (*models.Match, error)
+	LiveMatches() []*models.Match
+	EndedMatchById(matchId string) (*models.Match, error)
+	RecentMatches(clientKey models.Key, limit int) []*models.Match
+	Leaderboard(category models.RatingCategory, limit int) *models.Leaderboard
 	InboundChallenges(challengedKey models.Key) (*set.Set[*models.Challenge], error)
 	OutboundChallenges(challengerKey models.Key) (*set.Set[*models.Challenge], error)
 	AllChallenges(clientKey models.Key) *set.Set[*models.Challenge]
 	GetChallenge(challengerKey, receivingClientKey models.Key) (*models.Challenge, error)
+	InviteChallenge(inviteToken string) (*models.Challenge, error)
 
+	LegalMoves(matchId string) ([]*chess.Move, error)
 	ExecuteMove(matchId string, move *chess.Move) error
 	ResignMatch(matchId string, clientKey models.Key) error
 
 	RequestChallenge(challenge *models.Challenge) error
 	AcceptChallenge(challengedKey, challengerKey models.Key) error
 	RevokeChallenge(challengerKey, challengedKey models.Key) error
+	RevokeAllChallenges(challengerKey models.Key)
 	DeclineChallenge(challengerKey, challengedKey models.Key) error
+	RequestInviteChallenge(challenge *models.Challenge) (*models.Challenge, error)
+	AcceptInviteChallenge(inviteToken string, acceptorKey models.Key) error
+	RevokeInviteChallenge(inviteToken string, challengerKey models.Key) error
+	ChallengePolicy(clientKey models.Key) *models.ChallengePolicy
+	SetChallengePolicy(clientKey models.Key, policy *models.ChallengePolicy) error
 
 	AddMatch(match *models.Match) error
 }
@@ -48,6 +62,10 @@ type MatcherService struct {
 	matchIdByClientKey   map[models.Key]string
 	outboundsByClientKey map[models.Key]*set.Set[*models.Challenge]
 	inboundsByClientKey  map[models.Key]*set.Set[*models.Challenge]
+	policyByClientKey    map[models.Key]*models.ChallengePolicy
+	inviteByToken        map[string]*models.Challenge
+	rulesByVariant       map[models.Variant]VariantRules
+	history              *matchHistory
 	mu                   sync.Mutex
 }
 
@@ -57,6 +75,10 @@ func NewMatcherService(config *MatcherServiceConfig) *MatcherService {
 		matchIdByClientKey:   make(map[models.Key]string),
 		outboundsByClientKey: make(map[models.Key]*set.Set[*models.Challenge]),
 		inboundsByClientKey:  make(map[models.Key]*set.Set[*models.Challenge]),
+		policyByClientKey:    make(map[models.Key]*models.ChallengePolicy),
+		inviteByToken:        make(map[string]*models.Challenge),
+		rulesByVariant:       DefaultVariantRules(),
+		history:              newMatchHistory(config.MatchHistorySize),
 	}
 	matchService.Service = *service.NewService(matchService, config)
 	return matchService
@@ -87,6 +109,40 @@ func (m *MatcherService) MatchByClientKey(clientKey models.Key) (*models.Match,
 	return m.MatchById(matchId)
 }
 
+// LiveMatches lists the matches in progress, ordered by id so the listing is stable
+func (m *MatcherService) LiveMatches() []*models.Match {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	matches := make([]*models.Match, 0, len(m.matchByMatchId))
+	for _, match := range m.matchByMatchId {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Uuid < matches[j].Uuid
+	})
+	return matches
+}
+
+func (m *MatcherService) EndedMatchById(matchId string) (*models.Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.history.matchById(matchId)
+}
+
+// RecentMatches lists the client's ended matches, newest first
+func (m *MatcherService) RecentMatches(clientKey models.Key, limit int) []*models.Match {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.history.recentMatches(clientKey, limit)
+}
+
+// Leaderboard ranks clients by their results in rated matches of the category
+func (m *MatcherService) Leaderboard(category models.RatingCategory, limit int) *models.Leaderboard {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.history.leaderboard(category, limit)
+}
+
 func (m *MatcherService) InboundChallenges(challengedKey models.Key) (*set.Set[*models.Challenge], error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -143,13 +199,55 @@ func (m *MatcherService) GetChallenge(challengerKey models.Key, receiverKey mode
 	return matches[0], nil
 }
 
+func (m *MatcherService) InviteChallenge(inviteToken string) (*models.Challenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	challenge, ok := m.inviteByToken[inviteToken]
+	if !ok {
+		return nil, fmt.Errorf("invite challenge not found")
+	}
+	return challenge, nil
+}
+
+func (m *MatcherService) RegisterVariantRules(variant models.Variant, rules VariantRules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rulesByVariant[variant] = rules
+}
+
+func (m *MatcherService) VariantRules(variant models.Variant) (VariantRules, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules, ok := m.rulesByVariant[variant.OrStandard()]
+	if !ok {
+		return nil, fmt.Errorf("no rules registered for variant %s", variant)
+	}
+	return rules, nil
+}
+
+func (m *MatcherService) LegalMoves(matchId string) ([]*chess.Move, error) {
+	match, getMatchErr := m.MatchById(matchId)
+	if getMatchErr != nil {
+		return nil, getMatchErr
+	}
+	rules, rulesErr := m.VariantRules(match.Variant)
+	if rulesErr != nil {
+		return nil, rulesErr
+	}
+	return rules.LegalMoves(match)
+}
+
 func (m *MatcherService) ExecuteMove(matchId string, move *chess.Move) error {
 	m.Logger.Log(models.ENV_MATCHER_SERVICE, "executing move on match ", matchId)
 	match, getMatchErr := m.MatchById(matchId)
 	if getMatchErr != nil {
 		return getMatchErr
 	}
-	if !chess.IsLegalMove(match.Board, move) {
+	rules, rulesErr := m.VariantRules(match.Variant)
+	if rulesErr != nil {
+		return rulesErr
+	}
+	if !rules.IsLegalMove(match, move) {
 		return fmt.Errorf("move %v is not legal", move)
 	}
 
@@ -157,7 +255,7 @@ func (m *MatcherService) ExecuteMove(matchId string, move *chess.Move) error {
 	currTime := time.Now()
 	matchBuilder.WithLastMoveTime(&currTime)
 	secondsSinceLastMove := math.Max(currTime.Sub(*match.LastMoveTime).Seconds(), 0.1)
-	if match.Board.IsWhiteTurn {
+	if match.IsWhiteTurn() {
 		newWhiteTimeRemaining := match.WhiteTimeRemainingSec - math.Max(0.1, secondsSinceLastMove)
 		matchBuilder.WithWhiteTimeRemainingSec(math.Max(0, newWhiteTimeRemaining))
 		if newWhiteTimeRemaining == 0 {
@@ -170,9 +268,15 @@ func (m *MatcherService) ExecuteMove(matchId string, move *chess.Move) error {
 			matchBuilder.WithResult(models.MATCH_RESULT_WHITE_WINS_BY_TIMEOUT)
 		}
 	}
-	newBoard := chess.GetBoardFromMove(match.Board, move)
-	matchBuilder.WithBoard(newBoard)
+	if playErr := rules.PlayMove(match, move, matchBuilder); playErr != nil {
+		return playErr
+	}
 	matchBuilder.WithLastMove(move)
+	matchBuilder.WithPlyCount(match.PlyCount + 1)
+	matchBuilder.WithMoveHistory(append(append(make([]*chess.Move, 0, len(match.MoveHistory)+1), match.MoveHistory...), move))
+	if result := rules.Result(matchBuilder.Build()); result != models.MATCH_RESULT_IN_PROGRESS {
+		matchBuilder.WithResult(result)
+	}
 	newMatch := matchBuilder.Build()
 
 	setMatchErr := m.SetMatch(newMatch)
@@ -190,10 +294,13 @@ func (m *MatcherService) ResignMatch(matchId string, clientKey models.Key) error
 	if matchErr != nil {
 		return matchErr
 	}
+	if clientKey != match.WhiteClientKey && clientKey != match.BlackClientKey {
+		return models.NewProtocolError(models.ERROR_CODE_UNAUTHORIZED, "client %s is not playing in match %s", clientKey, matchId)
+	}
 
 	matchBuilder := builders.NewMatchBuilder().FromMatch(match)
 	secSinceLastMove := time.Now().Sub(*match.LastMoveTime).Seconds()
-	if match.Board.IsWhiteTurn {
+	if match.IsWhiteTurn() {
 		secRemaining := match.WhiteTimeRemainingSec - secSinceLastMove
 		matchBuilder.WithWhiteTimeRemainingSec(secRemaining)
 	} else {
@@ -243,6 +350,10 @@ func (m *MatcherService) RequestChallenge(_challenge *models.Challenge) error {
 	challengerOutbounds.Add(challenge)
 	challengedInbounds.Add(challenge)
 
+	if config := m.Config().(*MatcherServiceConfig); config.ChallengeTTL > 0 {
+		go m.StartChallengeTimer(challenge, config.ChallengeTTL)
+	}
+
 	go m.Dispatch(NewChallengeCreatedEvent(challenge))
 	return nil
 }
@@ -260,7 +371,12 @@ func (m *MatcherService) AcceptChallenge(challengerKey, challengedKey models.Key
 	m.outboundsByClientKey[challengerKey].Remove(challenge)
 	m.mu.Unlock()
 
-	match := builders.NewMatchBuilder().FromChallenge(challenge).Build()
+	matchBuilder, buildErr := builders.NewMatchBuilder().FromChallenge(challenge)
+	if buildErr != nil {
+		go m.Dispatch(NewChallengeAcceptFailedEvent(challenge, fmt.Sprintf("could not build match: %s", buildErr)))
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not build match: %s", buildErr)
+	}
+	match := matchBuilder.Build()
 	if addMatchErr := m.AddMatch(match); addMatchErr != nil {
 		go m.Dispatch(NewChallengeAcceptFailedEvent(challenge, fmt.Sprintf("could not add match: %s", addMatchErr)))
 		return addMatchErr
@@ -286,6 +402,25 @@ func (m *MatcherService) RevokeChallenge(challengerKey, challengedKey models.Key
 	return nil
 }
 
+func (m *MatcherService) RevokeAllChallenges(challengerKey models.Key) {
+	m.mu.Lock()
+	outbounds := make([]*models.Challenge, 0)
+	if challenges, ok := m.outboundsByClientKey[challengerKey]; ok {
+		outbounds = challenges.Flatten()
+	}
+	m.mu.Unlock()
+	for _, challenge := range outbounds {
+		if revokeErr := m.RevokeChallenge(challenge.ChallengerKey, challenge.ChallengedKey); revokeErr != nil {
+			m.Logger.LogRed(models.ENV_MATCHER_SERVICE, fmt.Sprintf("could not revoke challenge %s: %s", challenge.Uuid, revokeErr))
+		}
+	}
+	for _, invite := range m.invitesByChallenger(challengerKey) {
+		if revokeErr := m.RevokeInviteChallenge(invite.InviteToken, challengerKey); revokeErr != nil {
+			m.Logger.LogRed(models.ENV_MATCHER_SERVICE, fmt.Sprintf("could not revoke invite challenge %s: %s", invite.Uuid, revokeErr))
+		}
+	}
+}
+
 func (m *MatcherService) DeclineChallenge(challengerKey, challengedKey models.Key) error {
 	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("declining challenge from %s to %s", challengerKey, challengedKey))
 	challenge, challengeErr := m.GetChallenge(challengerKey, challengedKey)
@@ -302,6 +437,111 @@ func (m *MatcherService) DeclineChallenge(challengerKey, challengedKey models.Ke
 	return nil
 }
 
+func (m *MatcherService) RequestInviteChallenge(_challenge *models.Challenge) (*models.Challenge, error) {
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("client %s creating invite challenge", _challenge.ChallengerKey))
+
+	now := time.Now()
+	challengeBuilder := builders.NewChallengeBuilder()
+	challengeBuilder.FromChallenge(_challenge)
+	challengeBuilder.WithRandomUuid()
+	challengeBuilder.WithRandomInviteToken()
+	challengeBuilder.WithChallengedKey("")
+	challengeBuilder.WithIsActive(true)
+	challengeBuilder.WithTimeCreated(&now)
+	challenge := challengeBuilder.Build()
+	if challengeErr := m.validateInviteChallenge(challenge); challengeErr != nil {
+		go m.Dispatch(NewChallengeRequestFailedEvent(challenge, challengeErr.Error()))
+		return nil, challengeErr
+	}
+
+	m.mu.Lock()
+	m.inviteByToken[challenge.InviteToken] = challenge
+	m.mu.Unlock()
+
+	if config := m.Config().(*MatcherServiceConfig); config.InviteChallengeTTL > 0 {
+		go m.StartInviteChallengeTimer(challenge, config.InviteChallengeTTL)
+	}
+
+	go m.Dispatch(NewChallengeCreatedEvent(challenge))
+	return challenge, nil
+}
+
+func (m *MatcherService) AcceptInviteChallenge(inviteToken string, acceptorKey models.Key) error {
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("client %s accepting invite challenge", acceptorKey))
+	invite, inviteErr := m.InviteChallenge(inviteToken)
+	if inviteErr != nil {
+		return inviteErr
+	}
+	if invite.ChallengerKey == acceptorKey {
+		return fmt.Errorf("cannot accept own invite challenge")
+	}
+	if acceptorAvailableErr := m.validateClientAvailable(acceptorKey); acceptorAvailableErr != nil {
+		return fmt.Errorf("client %s unavailable for matcher: %s", acceptorKey, acceptorAvailableErr)
+	}
+
+	// NOTE: invites are single use, so the first acceptor to claim the token wins
+	m.mu.Lock()
+	if _, ok := m.inviteByToken[inviteToken]; !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("invite challenge already claimed")
+	}
+	delete(m.inviteByToken, inviteToken)
+	m.mu.Unlock()
+
+	challenge := builders.NewChallengeBuilder().FromChallenge(invite).WithChallengedKey(acceptorKey).Build()
+	matchBuilder, buildErr := builders.NewMatchBuilder().FromChallenge(challenge)
+	if buildErr != nil {
+		// NOTE: the invite is not put back, since no acceptor could ever get a match out of it
+		go m.Dispatch(NewChallengeAcceptFailedEvent(challenge, fmt.Sprintf("could not build match: %s", buildErr)))
+		return models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "could not build match: %s", buildErr)
+	}
+	match := matchBuilder.Build()
+	if addMatchErr := m.AddMatch(match); addMatchErr != nil {
+		// NOTE: the invite stays open for others, since the acceptor never got a match out of it
+		m.mu.Lock()
+		m.inviteByToken[inviteToken] = invite
+		m.mu.Unlock()
+		go m.Dispatch(NewChallengeAcceptFailedEvent(challenge, fmt.Sprintf("could not add match: %s", addMatchErr)))
+		return addMatchErr
+	}
+
+	go m.Dispatch(NewChallengeAcceptedEvent(challenge))
+	return nil
+}
+
+func (m *MatcherService) RevokeInviteChallenge(inviteToken string, challengerKey models.Key) error {
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("client %s revoking invite challenge", challengerKey))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	challenge, ok := m.inviteByToken[inviteToken]
+	if !ok || challenge.ChallengerKey != challengerKey {
+		return fmt.Errorf("invite challenge not found")
+	}
+	delete(m.inviteByToken, inviteToken)
+
+	go m.Dispatch(NewChallengeRevokedEvent(challenge))
+	return nil
+}
+
+func (m *MatcherService) ChallengePolicy(clientKey models.Key) *models.ChallengePolicy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.policyByClientKey[clientKey]
+}
+
+// SetChallengePolicy replaces the client's policy, or clears it when nil
+func (m *MatcherService) SetChallengePolicy(clientKey models.Key, policy *models.ChallengePolicy) error {
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("setting challenge policy for client %s", clientKey))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if policy == nil {
+		delete(m.policyByClientKey, clientKey)
+	} else {
+		m.policyByClientKey[clientKey] = policy
+	}
+	return nil
+}
+
 func (m *MatcherService) AddMatch(match *models.Match) error {
 	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("adding match %s", match.Uuid))
 	if whiteAvailableErr := m.validateClientAvailable(match.WhiteClientKey); whiteAvailableErr != nil {
@@ -353,6 +593,7 @@ func (m *MatcherService) RemoveMatch(match *models.Match) error {
 	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("removing match %s", match.Uuid))
 	m.mu.Lock()
 	if _, ok := m.matchByMatchId[match.Uuid]; !ok {
+		m.mu.Unlock()
 		return fmt.Errorf("match with id %s doesn't exist", match.Uuid)
 	}
 	if match.WhiteClientKey != "" {
@@ -362,6 +603,7 @@ func (m *MatcherService) RemoveMatch(match *models.Match) error {
 		delete(m.matchIdByClientKey, match.BlackClientKey)
 	}
 	delete(m.matchByMatchId, match.Uuid)
+	m.history.record(match)
 	m.mu.Unlock()
 
 	go m.Dispatch(NewMatchEndedEvent(match))
@@ -401,6 +643,9 @@ func (m *MatcherService) ValidateChallenge(challenge *models.Challenge) error {
 	if challenge.ChallengerKey == challenge.ChallengedKey {
 		return fmt.Errorf("cannot challenge self")
 	}
+	if fenErr := validateStartingFEN(challenge); fenErr != nil {
+		return fenErr
+	}
 
 	if challengerAvailableErr := m.validateClientAvailable(challenge.ChallengerKey); challengerAvailableErr != nil {
 		return fmt.Errorf("challenger %s unavailable for matcher", challenge.ChallengerKey)
@@ -408,12 +653,119 @@ func (m *MatcherService) ValidateChallenge(challenge *models.Challenge) error {
 	if challengeDuplicate, _ := m.GetChallenge(challenge.ChallengerKey, challenge.ChallengedKey); challengeDuplicate != nil {
 		return fmt.Errorf("challenge already exists")
 	}
+	config := m.Config().(*MatcherServiceConfig)
+	if outbounds, _ := m.OutboundChallenges(challenge.ChallengerKey); config.MaxOutboundChallenges > 0 && outbounds.Size() >= config.MaxOutboundChallenges {
+		return fmt.Errorf("challenger %s has too many outstanding challenges", challenge.ChallengerKey)
+	}
+	if policyErr := m.validateChallengePolicy(challenge); policyErr != nil {
+		return policyErr
+	}
+	return nil
+}
+
+func (m *MatcherService) validateChallengePolicy(challenge *models.Challenge) error {
+	policy := m.ChallengePolicy(challenge.ChallengedKey)
+	if policy == nil {
+		return nil
+	}
+	if policy.FriendsOnly && !policy.IsFriend(challenge.ChallengerKey) {
+		return fmt.Errorf("challenged client only accepts challenges from friends")
+	}
+	if policy.RatedOnly && !challenge.IsRated {
+		return fmt.Errorf("challenged client only accepts rated challenges")
+	}
 	return nil
 }
 
+func (m *MatcherService) validateInviteChallenge(challenge *models.Challenge) error {
+	if challenge.BotName != "" {
+		return fmt.Errorf("invite challenges cannot target a bot")
+	}
+	if challenge.TimeControl == nil {
+		return fmt.Errorf("invite challenges require a time control")
+	}
+	if fenErr := validateStartingFEN(challenge); fenErr != nil {
+		return fenErr
+	}
+	if challengerAvailableErr := m.validateClientAvailable(challenge.ChallengerKey); challengerAvailableErr != nil {
+		return fmt.Errorf("challenger %s unavailable for matcher", challenge.ChallengerKey)
+	}
+	config := m.Config().(*MatcherServiceConfig)
+	if config.MaxOutboundChallenges > 0 && len(m.invitesByChallenger(challenge.ChallengerKey)) >= config.MaxOutboundChallenges {
+		return fmt.Errorf("challenger %s has too many outstanding invite challenges", challenge.ChallengerKey)
+	}
+	return nil
+}
+
+func (m *MatcherService) invitesByChallenger(challengerKey models.Key) []*models.Challenge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	invites := make([]*models.Challenge, 0)
+	for _, invite := range m.inviteByToken {
+		if invite.ChallengerKey == challengerKey {
+			invites = append(invites, invite)
+		}
+	}
+	return invites
+}
+
+func validateStartingFEN(challenge *models.Challenge) error {
+	if !challenge.Variant.IsValid() {
+		return fmt.Errorf("unknown variant %s", challenge.Variant)
+	}
+	if challenge.StartingFEN == "" {
+		return nil
+	}
+	if !challenge.Variant.IsStandard() {
+		return fmt.Errorf("custom starting positions are only supported for standard chess")
+	}
+	if challenge.IsRated {
+		return fmt.Errorf("challenges from a custom starting position cannot be rated")
+	}
+	if fenErr := helpers.ValidateFEN(challenge.StartingFEN); fenErr != nil {
+		return fmt.Errorf("invalid starting fen: %s", fenErr)
+	}
+	if _, boardErr := chess.BoardFromFEN(challenge.StartingFEN); boardErr != nil {
+		return fmt.Errorf("invalid starting fen: %s", boardErr)
+	}
+	return nil
+}
+
+func (m *MatcherService) StartChallengeTimer(challenge *models.Challenge, ttl time.Duration) {
+	time.Sleep(ttl)
+	// NOTE: the challenge may be accepted, declined or revoked right up until it's removed here
+	m.mu.Lock()
+	outbounds, inbounds := m.outboundsByClientKey[challenge.ChallengerKey], m.inboundsByClientKey[challenge.ChallengedKey]
+	if outbounds == nil || inbounds == nil || !outbounds.Has(challenge) || !inbounds.Has(challenge) {
+		m.mu.Unlock()
+		return
+	}
+	inbounds.Remove(challenge)
+	outbounds.Remove(challenge)
+	m.mu.Unlock()
+
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("challenge from %s to %s expired", challenge.ChallengerKey, challenge.ChallengedKey))
+	go m.Dispatch(NewChallengeExpiredEvent(challenge))
+}
+
+func (m *MatcherService) StartInviteChallengeTimer(challenge *models.Challenge, ttl time.Duration) {
+	time.Sleep(ttl)
+	m.mu.Lock()
+	currChallenge, ok := m.inviteByToken[challenge.InviteToken]
+	if !ok || currChallenge.Uuid != challenge.Uuid {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.inviteByToken, challenge.InviteToken)
+	m.mu.Unlock()
+
+	m.Logger.Log(models.ENV_MATCHER_SERVICE, fmt.Sprintf("invite challenge from %s expired", challenge.ChallengerKey))
+	go m.Dispatch(NewChallengeExpiredEvent(currChallenge))
+}
+
 func (m *MatcherService) StartTimer(match *models.Match) {
 	var waitTime time.Duration
-	if match.Board.IsWhiteTurn {
+	if match.IsWhiteTurn() {
 		waitTime = time.Duration(match.WhiteTimeRemainingSec) * time.Second
 	} else {
 		waitTime = time.Duration(match.BlackTimeRemainingSec) * time.Second
@@ -427,7 +779,7 @@ func (m *MatcherService) StartTimer(match *models.Match) {
 	}
 	if currMatch.LastMoveTime.Equal(*match.LastMoveTime) {
 		matchBuilder := builders.NewMatchBuilder().FromMatch(match)
-		if match.Board.IsWhiteTurn {
+		if match.IsWhiteTurn() {
 			matchBuilder.WithWhiteTimeRemainingSec(0)
 		} else {
 			matchBuilder.WithBlackTimeRemainingSec(0)
@@ -442,6 +794,8 @@ var OnMatchCreated = func(s service.ServiceI, ev service.EventI) bool {
 	match := ev.Payload().(*MatchCreatedEventPayload).Match
 
 	go matcher.StartTimer(match)
+	go matcher.RevokeAllChallenges(match.WhiteClientKey)
+	go matcher.RevokeAllChallenges(match.BlackClientKey)
 	return true
 }
 
@@ -123,6 +123,62 @@ var _ = Describe("MatcherService", func() {
 			It("returns an error", func() {
 				Expect(matcherService.RemoveMatch(match)).To(HaveOccurred())
 			})
+			It("stays usable afterwards", func() {
+				Expect(matcherService.RemoveMatch(match)).To(HaveOccurred())
+				added := make(chan error, 1)
+				go func() {
+					added <- matcherService.AddMatch(match)
+				}()
+				Eventually(added).Should(Receive(BeNil()))
+			})
+		})
+	})
+	Describe("match history", func() {
+		var match *models.Match
+		BeforeEach(func() {
+			match = builders.NewMatch("client1", "client2", builders.NewBulletTimeControl(), models.MATCH_RESULT_IN_PROGRESS)
+			match.IsRated = true
+			Expect(matcherService.AddMatch(match)).To(Succeed())
+		})
+		It("lists the match as live until it ends", func() {
+			Expect(matcherService.LiveMatches()).To(ConsistOf(match))
+			endedMatch := *match
+			endedMatch.Result = models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION
+			Expect(matcherService.RemoveMatch(&endedMatch)).To(Succeed())
+			Expect(matcherService.LiveMatches()).To(BeEmpty())
+		})
+		When("the match ends", func() {
+			var endedMatch *models.Match
+			BeforeEach(func() {
+				matchCopy := *match
+				endedMatch = &matchCopy
+				endedMatch.Result = models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION
+				Expect(matcherService.RemoveMatch(endedMatch)).To(Succeed())
+			})
+			It("can still be looked up by id", func() {
+				Expect(matcherService.EndedMatchById(match.Uuid)).To(Equal(endedMatch))
+			})
+			It("is listed in both players' recent matches", func() {
+				Expect(matcherService.RecentMatches("client1", 10)).To(ConsistOf(endedMatch))
+				Expect(matcherService.RecentMatches("client2", 10)).To(ConsistOf(endedMatch))
+				Expect(matcherService.RecentMatches("client3", 10)).To(BeEmpty())
+			})
+			It("ranks the winner above the loser", func() {
+				leaderboard := matcherService.Leaderboard(match.RatingCategory(), 10)
+				Expect(leaderboard.Standings).To(Equal([]*models.Standing{
+					{ClientKey: "client1", Wins: 1, Points: 1},
+					{ClientKey: "client2", Losses: 1},
+				}))
+			})
+		})
+		When("an unrated match ends", func() {
+			It("leaves the leaderboard alone", func() {
+				endedMatch := *match
+				endedMatch.IsRated = false
+				endedMatch.Result = models.MATCH_RESULT_DRAW_BY_STALEMATE
+				Expect(matcherService.RemoveMatch(&endedMatch)).To(Succeed())
+				Expect(matcherService.Leaderboard(match.RatingCategory(), 10).Standings).To(BeEmpty())
+			})
 		})
 	})
 	Describe("SetMatch", func() {
@@ -222,6 +278,23 @@ var _ = Describe("MatcherService", func() {
 				Expect(newMatch.Result).To(Equal(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION))
 			})
 		})
+		When("the client isn't playing in the match", func() {
+			It("returns an unauthorized error", func() {
+				err := matcherService.ResignMatch(match.Uuid, "client3")
+				Expect(models.AsProtocolError(err, models.ERROR_CODE_INVALID_STATE).Code).To(Equal(models.ERROR_CODE_UNAUTHORIZED))
+			})
+			It("leaves the match in progress", func() {
+				_ = matcherService.ResignMatch(match.Uuid, "client3")
+				newMatch, _ := matcherService.MatchById(match.Uuid)
+				Expect(newMatch.Result).To(Equal(models.MATCH_RESULT_IN_PROGRESS))
+			})
+			It("does not emit a match update event", func() {
+				_ = matcherService.ResignMatch(match.Uuid, "client3")
+				Consistently(func() int {
+					return eventCatcher.EventsByVariantCount(matcher.MATCH_UPDATED)
+				}, 50*time.Millisecond).Should(BeZero())
+			})
+		})
 		It("emits a match update event", func() {
 			_ = matcherService.ResignMatch(match.Uuid, "client1")
 			Eventually(func() int {
@@ -292,6 +365,92 @@ var _ = Describe("MatcherService", func() {
 					Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
 				})
 			})
+			Describe("when the challenger has reached the outbound challenge cap", func() {
+				BeforeEach(func() {
+					matcherService.Config().(*matcher.MatcherServiceConfig).MaxOutboundChallenges = 1
+					otherChallenge := builders.NewChallengeBuilder().FromChallenge(challenge).WithChallengedKey("client3").Build()
+					Expect(matcherService.RequestChallenge(otherChallenge)).ToNot(HaveOccurred())
+				})
+				It("returns an error", func() {
+					Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
+				})
+			})
+			Describe("when the challenged client only accepts rated challenges", func() {
+				BeforeEach(func() {
+					Expect(matcherService.SetChallengePolicy("client2", &models.ChallengePolicy{RatedOnly: true})).To(Succeed())
+				})
+				It("returns an error for an unrated challenge", func() {
+					Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
+				})
+				It("stores a rated challenge", func() {
+					ratedChallenge := builders.NewChallengeBuilder().FromChallenge(challenge).WithIsRated(true).Build()
+					Expect(matcherService.RequestChallenge(ratedChallenge)).ToNot(HaveOccurred())
+				})
+			})
+			Describe("when the challenged client only accepts challenges from friends", func() {
+				BeforeEach(func() {
+					Expect(matcherService.SetChallengePolicy("client2", &models.ChallengePolicy{
+						FriendsOnly: true,
+						FriendKeys:  []models.Key{"client3"},
+					})).To(Succeed())
+				})
+				It("returns an error", func() {
+					Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
+				})
+			})
+			Describe("when the challenge has a starting fen", func() {
+				When("the fen is valid", func() {
+					BeforeEach(func() {
+						challenge.StartingFEN = "k7/8/8/8/8/8/8/K5Q1 w - - 0 1"
+					})
+					It("stores the challenge", func() {
+						Expect(matcherService.RequestChallenge(challenge)).ToNot(HaveOccurred())
+					})
+					When("the challenge is rated", func() {
+						BeforeEach(func() {
+							challenge.IsRated = true
+						})
+						It("returns an error", func() {
+							Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
+						})
+					})
+				})
+				When("the fen is illegal", func() {
+					BeforeEach(func() {
+						challenge.StartingFEN = "k7/8/8/8/8/8/8/7Q w - - 0 1"
+					})
+					It("returns an error", func() {
+						Expect(matcherService.RequestChallenge(challenge)).To(HaveOccurred())
+					})
+				})
+			})
+			Describe("when the challenge outlives its ttl", func() {
+				BeforeEach(func() {
+					matcherService.Config().(*matcher.MatcherServiceConfig).ChallengeTTL = 10 * time.Millisecond
+					Expect(matcherService.RequestChallenge(challenge)).ToNot(HaveOccurred())
+				})
+				It("removes the challenge", func() {
+					Eventually(func() error {
+						_, err := matcherService.GetChallenge("client1", "client2")
+						return err
+					}).Should(HaveOccurred())
+				})
+				It("emits a challenge expired event", func() {
+					Eventually(func() int {
+						return eventCatcher.EventsByVariantCount(matcher.CHALLENGE_EXPIRED)
+					}).Should(Equal(1))
+				})
+				When("the challenge is revoked before it expires", func() {
+					BeforeEach(func() {
+						Expect(matcherService.RevokeChallenge("client1", "client2")).To(Succeed())
+					})
+					It("does not emit a challenge expired event", func() {
+						Consistently(func() int {
+							return eventCatcher.EventsByVariantCount(matcher.CHALLENGE_EXPIRED)
+						}, 50*time.Millisecond).Should(Equal(0))
+					})
+				})
+			})
 		})
 		Describe("when the challenge is directed to a bot client", func() {
 			BeforeEach(func() {
@@ -408,6 +567,16 @@ var _ = Describe("MatcherService", func() {
 				newMatch, _ := matcherService.MatchById(match.Uuid)
 				Expect(newMatch.LastMove).To(Equal(&move))
 			})
+			It("counts the move", func() {
+				Expect(matcherService.ExecuteMove(match.Uuid, &move)).ToNot(HaveOccurred())
+				newMatch, _ := matcherService.MatchById(match.Uuid)
+				Expect(newMatch.PlyCount).To(Equal(match.PlyCount + 1))
+			})
+			It("appends the move to the move history", func() {
+				Expect(matcherService.ExecuteMove(match.Uuid, &move)).ToNot(HaveOccurred())
+				newMatch, _ := matcherService.MatchById(match.Uuid)
+				Expect(newMatch.MoveHistory).To(Equal(append(match.MoveHistory, &move)))
+			})
 		})
 	})
 	Describe("RevokeChallenge", func() {
@@ -435,6 +604,28 @@ var _ = Describe("MatcherService", func() {
 			}).Should(Equal(1))
 		})
 	})
+	Describe("RevokeAllChallenges", func() {
+		BeforeEach(func() {
+			Expect(matcherService.RequestChallenge(builders.NewChallenge(
+				"client1", "client2", true, false, builders.NewBlitzTimeControl(), "", false,
+			))).ToNot(HaveOccurred())
+			Expect(matcherService.RequestChallenge(builders.NewChallenge(
+				"client1", "client3", true, false, builders.NewBlitzTimeControl(), "", false,
+			))).ToNot(HaveOccurred())
+		})
+		It("removes every outbound challenge from the challenger", func() {
+			matcherService.RevokeAllChallenges("client1")
+			Expect(matcherService.OutboundChallenges("client1")).To(WithTransform(func(challenges *set.Set[*models.Challenge]) int {
+				return challenges.Size()
+			}, Equal(0)))
+		})
+		It("emits a challenge revoked event per challenge", func() {
+			matcherService.RevokeAllChallenges("client1")
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matcher.CHALLENGE_REVOKED)
+			}).Should(Equal(2))
+		})
+	})
 	Describe("DeclineChallenge", func() {
 		var challenge *models.Challenge
 		BeforeEach(func() {
@@ -460,6 +651,63 @@ var _ = Describe("MatcherService", func() {
 			}).Should(Equal(1))
 		})
 	})
+	Describe("RequestInviteChallenge", func() {
+		var invite *models.Challenge
+		BeforeEach(func() {
+			challenge := builders.NewChallenge("client1", "", false, false, builders.NewBlitzTimeControl(), "", false)
+			var inviteErr error
+			invite, inviteErr = matcherService.RequestInviteChallenge(challenge)
+			Expect(inviteErr).ToNot(HaveOccurred())
+		})
+		It("issues an invite token", func() {
+			Expect(invite.InviteToken).ToNot(BeEmpty())
+			Expect(matcherService.InviteChallenge(invite.InviteToken)).To(Equal(invite))
+		})
+		When("another client accepts the invite", func() {
+			BeforeEach(func() {
+				Expect(matcherService.AcceptInviteChallenge(invite.InviteToken, "client2")).To(Succeed())
+			})
+			It("creates a match against the acceptor", func() {
+				match, matchErr := matcherService.MatchByClientKey("client2")
+				Expect(matchErr).ToNot(HaveOccurred())
+				Expect([]models.Key{match.WhiteClientKey, match.BlackClientKey}).To(ConsistOf(models.Key("client1"), models.Key("client2")))
+			})
+			It("consumes the invite", func() {
+				Expect(matcherService.InviteChallenge(invite.InviteToken)).Error().To(HaveOccurred())
+				Expect(matcherService.AcceptInviteChallenge(invite.InviteToken, "client3")).ToNot(Succeed())
+			})
+		})
+		When("the match can't be created", func() {
+			BeforeEach(func() {
+				busyMatch := builders.NewMatch("client1", "client3", builders.NewBlitzTimeControl(), models.MATCH_RESULT_IN_PROGRESS)
+				Expect(matcherService.AddMatch(busyMatch)).To(Succeed())
+			})
+			It("keeps the invite open", func() {
+				Expect(matcherService.AcceptInviteChallenge(invite.InviteToken, "client2")).ToNot(Succeed())
+				Expect(matcherService.InviteChallenge(invite.InviteToken)).To(Equal(invite))
+			})
+		})
+		When("the challenger accepts their own invite", func() {
+			It("returns an error", func() {
+				Expect(matcherService.AcceptInviteChallenge(invite.InviteToken, "client1")).ToNot(Succeed())
+			})
+		})
+		When("the invite outlives its ttl", func() {
+			BeforeEach(func() {
+				matcherService.Config().(*matcher.MatcherServiceConfig).InviteChallengeTTL = 10 * time.Millisecond
+				challenge := builders.NewChallenge("client2", "", false, false, builders.NewBlitzTimeControl(), "", false)
+				var inviteErr error
+				invite, inviteErr = matcherService.RequestInviteChallenge(challenge)
+				Expect(inviteErr).ToNot(HaveOccurred())
+			})
+			It("removes the invite", func() {
+				Eventually(func() error {
+					_, err := matcherService.InviteChallenge(invite.InviteToken)
+					return err
+				}).Should(HaveOccurred())
+			})
+		})
+	})
 	Describe("AcceptChallenge", func() {
 		When("the challenge already exists", func() {
 			var challenge *models.Challenge
@@ -0,0 +1,167 @@
+package matcher
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	"github.com/CameronHonis/chess-arbitrator/models"
+)
+
+// VariantRules decides which moves are legal in a match and when the match ends, for a single chess variant
+type VariantRules interface {
+	LegalMoves(match *models.Match) ([]*chess.Move, error)
+	IsLegalMove(match *models.Match, move *chess.Move) bool
+	// PlayMove applies a legal move through the match builder, along with any per-match state the variant tracks
+	PlayMove(match *models.Match, move *chess.Move, matchBuilder *builders.MatchBuilder) error
+	// Result inspects the match after a move, returning MATCH_RESULT_IN_PROGRESS unless the variant ends it
+	Result(match *models.Match) models.MatchResult
+}
+
+func DefaultVariantRules() map[models.Variant]VariantRules {
+	return map[models.Variant]VariantRules{
+		models.VARIANT_STANDARD:         &StandardRules{},
+		models.VARIANT_CHESS960:         &Chess960Rules{},
+		models.VARIANT_KING_OF_THE_HILL: &KingOfTheHillRules{},
+		models.VARIANT_THREE_CHECK:      &ThreeCheckRules{},
+		models.VARIANT_HORDE:            &HordeRules{},
+	}
+}
+
+type StandardRules struct{}
+
+func (r *StandardRules) LegalMoves(match *models.Match) ([]*chess.Move, error) {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return nil, positionErr
+	}
+	return toChessMoves(position, position.LegalMoves(), false), nil
+}
+
+func (r *StandardRules) IsLegalMove(match *models.Match, move *chess.Move) bool {
+	return match.Board != nil && chess.IsLegalMove(match.Board, move)
+}
+
+func (r *StandardRules) PlayMove(match *models.Match, move *chess.Move, matchBuilder *builders.MatchBuilder) error {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return positionErr
+	}
+	if playErr := position.Play(toFENMove(position, move)); playErr != nil {
+		return playErr
+	}
+	matchBuilder.WithBoard(chess.GetBoardFromMove(match.Board, move))
+	matchBuilder.WithCurrentFEN(position.String())
+	return nil
+}
+
+func (r *StandardRules) Result(match *models.Match) models.MatchResult {
+	// standard results are read off the board by MatchBuilder.WithBoard
+	return models.MATCH_RESULT_IN_PROGRESS
+}
+
+var pieceByFENChar = map[byte]chess.Piece{
+	'P': chess.WHITE_PAWN,
+	'N': chess.WHITE_KNIGHT,
+	'B': chess.WHITE_BISHOP,
+	'R': chess.WHITE_ROOK,
+	'Q': chess.WHITE_QUEEN,
+	'K': chess.WHITE_KING,
+	'p': chess.BLACK_PAWN,
+	'n': chess.BLACK_KNIGHT,
+	'b': chess.BLACK_BISHOP,
+	'r': chess.BLACK_ROOK,
+	'q': chess.BLACK_QUEEN,
+	'k': chess.BLACK_KING,
+}
+
+var fenCharByPiece = map[chess.Piece]byte{
+	chess.WHITE_KNIGHT: 'N',
+	chess.WHITE_BISHOP: 'B',
+	chess.WHITE_ROOK:   'R',
+	chess.WHITE_QUEEN:  'Q',
+	chess.BLACK_KNIGHT: 'n',
+	chess.BLACK_BISHOP: 'b',
+	chess.BLACK_ROOK:   'r',
+	chess.BLACK_QUEEN:  'q',
+}
+
+// currentPosition reads the match position off the fen tracked alongside the board, falling back to the starting
+// position for matches that have not had a move played yet
+func currentPosition(match *models.Match) (*helpers.FENPosition, error) {
+	fen := match.CurrentFEN
+	if fen == "" {
+		fen = match.StartingFEN
+	}
+	if fen == "" {
+		fen = helpers.STANDARD_STARTING_FEN
+	}
+	position, fenErr := helpers.ParseFEN(fen)
+	if fenErr != nil {
+		return nil, fmt.Errorf("could not parse match position: %s", fenErr)
+	}
+	return position, nil
+}
+
+// toFENMove converts a move with one-indexed squares. Castling is recognized both as the king moving two files and
+// as the king moving onto one of its castling rooks.
+func toFENMove(position *helpers.FENPosition, move *chess.Move) helpers.FENMove {
+	fenMove := helpers.FENMove{PromotedTo: fenCharByPiece[move.PawnUpgradedTo]}
+	if move.StartSquare != nil {
+		fenMove.StartRank, fenMove.StartFile = int(move.StartSquare.Rank)-1, int(move.StartSquare.File)-1
+	}
+	if move.EndSquare != nil {
+		fenMove.EndRank, fenMove.EndFile = int(move.EndSquare.Rank)-1, int(move.EndSquare.File)-1
+	}
+	if move.Piece != chess.WHITE_KING && move.Piece != chess.BLACK_KING {
+		return fenMove
+	}
+	if fenMove.StartRank != fenMove.EndRank || fenMove.EndFile < 0 || fenMove.EndFile > 7 {
+		return fenMove
+	}
+	isKingside := fenMove.EndFile > fenMove.StartFile
+	rookFile, hasRight := position.CastlingRookFile(isKingside)
+	if !hasRight {
+		return fenMove
+	}
+	isOntoRook := rookFile == fenMove.EndFile
+	isTwoFileStep := fenMove.EndFile-fenMove.StartFile == 2 || fenMove.StartFile-fenMove.EndFile == 2
+	if isOntoRook || isTwoFileStep {
+		fenMove.EndFile = rookFile
+		fenMove.IsCastle = true
+	}
+	return fenMove
+}
+
+func toChessMoves(position *helpers.FENPosition, fenMoves []helpers.FENMove, isCastleOntoRook bool) []*chess.Move {
+	moves := make([]*chess.Move, 0, len(fenMoves))
+	for _, fenMove := range fenMoves {
+		moves = append(moves, toChessMove(position, fenMove, isCastleOntoRook))
+	}
+	return moves
+}
+
+func toChessMove(position *helpers.FENPosition, fenMove helpers.FENMove, isCastleOntoRook bool) *chess.Move {
+	endFile := fenMove.EndFile
+	capturedPiece := chess.EMPTY
+	if fenMove.IsCastle && !isCastleOntoRook {
+		endFile = 2
+		if fenMove.EndFile > fenMove.StartFile {
+			endFile = 6
+		}
+	} else if captured := position.Squares[fenMove.EndRank][fenMove.EndFile]; captured != helpers.EMPTY_SQUARE {
+		capturedPiece = pieceByFENChar[captured]
+	}
+	upgradedTo := chess.EMPTY
+	if fenMove.PromotedTo != 0 {
+		upgradedTo = pieceByFENChar[fenMove.PromotedTo]
+	}
+	return &chess.Move{
+		Piece:               pieceByFENChar[position.Squares[fenMove.StartRank][fenMove.StartFile]],
+		StartSquare:         &chess.Square{Rank: uint8(fenMove.StartRank + 1), File: uint8(fenMove.StartFile + 1)},
+		EndSquare:           &chess.Square{Rank: uint8(fenMove.EndRank + 1), File: uint8(endFile + 1)},
+		CapturedPiece:       capturedPiece,
+		KingCheckingSquares: make([]*chess.Square, 0),
+		PawnUpgradedTo:      upgradedTo,
+	}
+}
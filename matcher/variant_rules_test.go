@@ -0,0 +1,83 @@
+package matcher_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	"github.com/CameronHonis/chess-arbitrator/matcher"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VariantRules", func() {
+	var match *models.Match
+	BeforeEach(func() {
+		match = builders.NewMatch("client-a", "client-b", builders.NewBlitzTimeControl(), models.MATCH_RESULT_IN_PROGRESS)
+	})
+	Describe("KingOfTheHillRules", func() {
+		rules := &matcher.KingOfTheHillRules{}
+		When("a king stands on a center square", func() {
+			It("awards the win to that king's side", func() {
+				match.CurrentFEN = "4k3/8/8/8/3K4/8/8/8 b - - 1 1"
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_WHITE_WINS_BY_KING_OF_THE_HILL))
+			})
+		})
+		When("neither king is on the hill", func() {
+			It("keeps the match in progress", func() {
+				match.CurrentFEN = helpers.STANDARD_STARTING_FEN
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_IN_PROGRESS))
+			})
+		})
+	})
+	Describe("ThreeCheckRules", func() {
+		rules := &matcher.ThreeCheckRules{}
+		When("black has given three checks", func() {
+			It("awards black the win", func() {
+				match.BlackCheckCount = 3
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_BLACK_WINS_BY_THREE_CHECK))
+			})
+		})
+		When("neither side has given three checks", func() {
+			It("keeps the match in progress", func() {
+				match.WhiteCheckCount = 2
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_IN_PROGRESS))
+			})
+		})
+	})
+	Describe("HordeRules", func() {
+		rules := &matcher.HordeRules{}
+		When("the match is created", func() {
+			It("starts from the horde position", func() {
+				match = builders.NewMatchBuilder().WithVariant(models.VARIANT_HORDE).Build()
+				Expect(match.CurrentFEN).To(Equal(helpers.HORDE_STARTING_FEN))
+				moves, err := rules.LegalMoves(match)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(moves).ToNot(BeEmpty())
+			})
+		})
+		When("the chess lib has no board for the position", func() {
+			It("plays the match out on the fen alone", func() {
+				match = builders.NewMatchBuilder().WithVariant(models.VARIANT_HORDE).WithBoard(nil).Build()
+				moves, err := rules.LegalMoves(match)
+				Expect(err).ToNot(HaveOccurred())
+				matchBuilder := builders.NewMatchBuilder().FromMatch(match)
+				Expect(rules.PlayMove(match, moves[0], matchBuilder)).To(Succeed())
+				newMatch := matchBuilder.Build()
+				Expect(newMatch.CurrentFEN).ToNot(Equal(helpers.HORDE_STARTING_FEN))
+				Expect(newMatch.IsWhiteTurn()).To(BeFalse())
+			})
+		})
+		When("white has no pieces left", func() {
+			It("awards black the win", func() {
+				match.CurrentFEN = "4k3/8/8/8/8/8/8/8 w - - 0 40"
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_BLACK_WINS_BY_HORDE_CAPTURED))
+			})
+		})
+		When("black is checkmated", func() {
+			It("awards white the win", func() {
+				match.CurrentFEN = "k7/PP6/PPP5/8/8/8/8/8 b - - 0 40"
+				Expect(rules.Result(match)).To(Equal(models.MATCH_RESULT_WHITE_WINS_BY_CHECKMATE))
+			})
+		})
+	})
+})
@@ -0,0 +1,126 @@
+package matcher
+
+import (
+	"github.com/CameronHonis/chess"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/models"
+)
+
+// KingOfTheHillRules play like standard chess, except that a king reaching one of the four center squares wins
+type KingOfTheHillRules struct {
+	StandardRules
+}
+
+func (r *KingOfTheHillRules) Result(match *models.Match) models.MatchResult {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return models.MATCH_RESULT_IN_PROGRESS
+	}
+	isOnHill := func(rank, file int) bool {
+		return (rank == 3 || rank == 4) && (file == 3 || file == 4)
+	}
+	if rank, file, ok := position.FindPiece('K'); ok && isOnHill(rank, file) {
+		return models.MATCH_RESULT_WHITE_WINS_BY_KING_OF_THE_HILL
+	}
+	if rank, file, ok := position.FindPiece('k'); ok && isOnHill(rank, file) {
+		return models.MATCH_RESULT_BLACK_WINS_BY_KING_OF_THE_HILL
+	}
+	return models.MATCH_RESULT_IN_PROGRESS
+}
+
+// ThreeCheckRules play like standard chess, except that the third check given by a side wins
+type ThreeCheckRules struct {
+	StandardRules
+}
+
+func (r *ThreeCheckRules) PlayMove(match *models.Match, move *chess.Move, matchBuilder *builders.MatchBuilder) error {
+	if playErr := r.StandardRules.PlayMove(match, move, matchBuilder); playErr != nil {
+		return playErr
+	}
+	newMatch := matchBuilder.Build()
+	position, positionErr := currentPosition(newMatch)
+	if positionErr != nil {
+		return positionErr
+	}
+	if !position.IsInCheck() {
+		return nil
+	}
+	if match.IsWhiteTurn() {
+		matchBuilder.WithCheckCounts(match.WhiteCheckCount+1, match.BlackCheckCount)
+	} else {
+		matchBuilder.WithCheckCounts(match.WhiteCheckCount, match.BlackCheckCount+1)
+	}
+	return nil
+}
+
+func (r *ThreeCheckRules) Result(match *models.Match) models.MatchResult {
+	if match.WhiteCheckCount >= 3 {
+		return models.MATCH_RESULT_WHITE_WINS_BY_THREE_CHECK
+	}
+	if match.BlackCheckCount >= 3 {
+		return models.MATCH_RESULT_BLACK_WINS_BY_THREE_CHECK
+	}
+	return models.MATCH_RESULT_IN_PROGRESS
+}
+
+// HordeRules pit white's 36 pawns and no king against black's standard army. White wins by checkmate, black by
+// capturing every white piece. The chess lib cannot be trusted with a kingless side, so horde matches are played out
+// on CurrentFEN. The board is kept in step where the lib accepts the position and is nil where it doesn't.
+type HordeRules struct{}
+
+func (r *HordeRules) LegalMoves(match *models.Match) ([]*chess.Move, error) {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return nil, positionErr
+	}
+	return toChessMoves(position, position.LegalMoves(), false), nil
+}
+
+func (r *HordeRules) IsLegalMove(match *models.Match, move *chess.Move) bool {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return false
+	}
+	return position.IsLegalMove(toFENMove(position, move))
+}
+
+func (r *HordeRules) PlayMove(match *models.Match, move *chess.Move, matchBuilder *builders.MatchBuilder) error {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return positionErr
+	}
+	if playErr := position.Play(toFENMove(position, move)); playErr != nil {
+		return playErr
+	}
+	newFEN := position.String()
+	board, boardErr := chess.BoardFromFEN(newFEN)
+	if boardErr != nil {
+		board = nil
+	}
+	matchBuilder.WithBoard(board)
+	matchBuilder.WithCurrentFEN(newFEN)
+	return nil
+}
+
+func (r *HordeRules) Result(match *models.Match) models.MatchResult {
+	position, positionErr := currentPosition(match)
+	if positionErr != nil {
+		return models.MATCH_RESULT_IN_PROGRESS
+	}
+	if position.PieceCount(true) == 0 {
+		return models.MATCH_RESULT_BLACK_WINS_BY_HORDE_CAPTURED
+	}
+	if len(position.LegalMoves()) == 0 {
+		if !position.IsInCheck() {
+			return models.MATCH_RESULT_DRAW_BY_STALEMATE
+		}
+		if position.IsWhiteTurn {
+			return models.MATCH_RESULT_BLACK_WINS_BY_CHECKMATE
+		}
+		return models.MATCH_RESULT_WHITE_WINS_BY_CHECKMATE
+	}
+	if position.HalfmoveClock >= 100 {
+		return models.MATCH_RESULT_DRAW_BY_FIFTY_MOVE_RULE
+	}
+	return models.MATCH_RESULT_IN_PROGRESS
+}
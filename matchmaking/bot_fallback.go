@@ -0,0 +1,114 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"time"
+)
+
+type BotTier struct {
+	// highest elo (inclusive) served by this tier, 0 for no limit
+	MaxElo  int
+	BotName string
+}
+
+type BotFallbackConfig struct {
+	// how long a client that opted in waits without a match before being given a bot instead
+	Wait time.Duration
+	// tiers ordered by ascending MaxElo, bot names must match those served by the bot server
+	Tiers []*BotTier
+}
+
+func NewBotFallbackConfig() *BotFallbackConfig {
+	return &BotFallbackConfig{
+		Wait: 90 * time.Second,
+		Tiers: []*BotTier{
+			{MaxElo: 1000, BotName: "bot-beginner"},
+			{MaxElo: 1400, BotName: "bot-intermediate"},
+			{MaxElo: 1800, BotName: "bot-advanced"},
+			{MaxElo: 0, BotName: "bot-expert"},
+		},
+	}
+}
+
+// BotNameForElo picks the weakest tier that still covers the elo, falling back to the strongest tier
+func (c *BotFallbackConfig) BotNameForElo(elo int) string {
+	for _, tier := range c.Tiers {
+		if tier.MaxElo == 0 || elo <= tier.MaxElo {
+			return tier.BotName
+		}
+	}
+	if len(c.Tiers) == 0 {
+		return ""
+	}
+	return c.Tiers[len(c.Tiers)-1].BotName
+}
+
+func (mm *MatchmakingService) botFallbackConfig() *BotFallbackConfig {
+	config := mm.Config().(*MatchmakingConfig)
+	if config.BotFallback == nil {
+		return NewBotFallbackConfig()
+	}
+	return config.BotFallback
+}
+
+// FallBackToBots challenges the bot server on behalf of every opted in client that has waited too long. Each client
+// is taken out of matchmaking first, and put back if the bot challenge cannot be made.
+func (mm *MatchmakingService) FallBackToBots() {
+	config := mm.botFallbackConfig()
+	for _, fallback := range mm.claimBotFallbacks(config.Wait) {
+		client := fallback.profile
+		queue := fallback.queues[0]
+		botName := config.BotNameForElo(client.Elo)
+		challenge := builders.NewChallengeBuilder().
+			WithChallengerKey(client.ClientKey).
+			WithBotName(botName).
+			WithTimeControl(queue.TimeControl).
+			WithVariant(queue.Variant).
+			WithIsChallengerWhite(client.ColourPreference == models.COLOUR_WHITE).
+			WithIsChallengerBlack(client.ColourPreference == models.COLOUR_BLACK).
+			WithIsRated(false).
+			Build()
+		challengeErr := mm.MatchService.RequestChallenge(challenge)
+		if challengeErr == nil {
+			mm.LogService.LogGreen(models.ENV_MATCHMAKING, fmt.Sprintf("challenged %s on behalf of client %s", botName, client.ClientKey))
+			continue
+		}
+
+		mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not fall back to a bot for client %s: %s", client.ClientKey, challengeErr))
+		// NOTE: requeue without the fallback so an offline bot server isn't retried every tick
+		requeuedClient := *client
+		requeuedClient.IsBotFallbackAllowed = false
+		if requeueErr := mm.requeue(&requeuedClient, fallback.queues, fallback.timeJoined); requeueErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not requeue client %s: %s", client.ClientKey, requeueErr))
+		}
+	}
+}
+
+// requeue puts the client back at the front of its queues, keeping its place, unless it has since queued again
+func (mm *MatchmakingService) requeue(client *models.ClientProfile, queues []*models.MatchmakingQueue, timeJoined int64) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if _, ok := mm.poolsByClientKey[client.ClientKey]; ok {
+		return fmt.Errorf("client with key %s already in matchmaking", client.ClientKey)
+	}
+	return mm.enqueue(client, queues, timeJoined, true)
+}
+
+func (mm *MatchmakingService) claimBotFallbacks(wait time.Duration) []*queuedClient {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	now := time.Now().Unix()
+	fallbacks := make([]*queuedClient, 0)
+	for clientKey := range mm.poolsByClientKey {
+		queued := mm.queuedClientByKey(clientKey)
+		if queued == nil || !queued.profile.IsBotFallbackAllowed || now-queued.timeJoined < int64(wait/time.Second) {
+			continue
+		}
+		_ = mm.removeClient(clientKey, models.MATCHMAKING_LEFT_REASON_BOT_FALLBACK)
+		fallbacks = append(fallbacks, queued)
+	}
+	return fallbacks
+}
@@ -0,0 +1,104 @@
+package matchmaking_test
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+var _ = Describe("BotFallbackConfig", func() {
+	Describe("::BotNameForElo", func() {
+		var config *matchmaking.BotFallbackConfig
+		BeforeEach(func() {
+			config = matchmaking.NewBotFallbackConfig()
+		})
+		It("picks the weakest tier covering the elo", func() {
+			Expect(config.BotNameForElo(700)).To(Equal("bot-beginner"))
+			Expect(config.BotNameForElo(1000)).To(Equal("bot-beginner"))
+			Expect(config.BotNameForElo(1001)).To(Equal("bot-intermediate"))
+			Expect(config.BotNameForElo(1700)).To(Equal("bot-advanced"))
+		})
+		It("uses the unbounded tier for the strongest clients", func() {
+			Expect(config.BotNameForElo(2600)).To(Equal("bot-expert"))
+		})
+	})
+})
+
+var _ = Describe("MatchmakingService bot fallback", func() {
+	var matchmakingService *matchmaking.MatchmakingService
+	var matchServiceMock *mocks.MockMatcherServiceI
+	var timeControl *models.TimeControl
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		matchmakingService = CreateServices(ctrl)
+		matchmakingService.Config().(*matchmaking.MatchmakingConfig).BotFallback.Wait = 0
+		matchServiceMock = matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+		timeControl = builders.NewBlitzTimeControl()
+	})
+	When("the client opted in", func() {
+		var client *models.ClientProfile
+		BeforeEach(func() {
+			client = models.NewClientProfile("client-a", 1500)
+			client.IsBotFallbackAllowed = true
+			client.ColourPreference = models.COLOUR_BLACK
+			Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+		})
+		It("challenges a bot matching the client's elo and leaves matchmaking", func() {
+			var challenge *models.Challenge
+			matchServiceMock.EXPECT().RequestChallenge(gomock.Any()).DoAndReturn(func(c *models.Challenge) error {
+				challenge = c
+				return nil
+			})
+			matchmakingService.FallBackToBots()
+			Expect(challenge).ToNot(BeNil())
+			Expect(challenge.ChallengerKey).To(Equal(client.ClientKey))
+			Expect(challenge.BotName).To(Equal("bot-advanced"))
+			Expect(challenge.TimeControl).To(Equal(timeControl))
+			Expect(challenge.IsChallengerBlack).To(BeTrue())
+			Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(0))
+		})
+		When("the bot challenge fails", func() {
+			BeforeEach(func() {
+				matchServiceMock.EXPECT().RequestChallenge(gomock.Any()).Return(fmt.Errorf("bot server offline")).Times(1)
+			})
+			It("puts the client back in matchmaking without falling back again", func() {
+				matchmakingService.FallBackToBots()
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+				matchmakingService.FallBackToBots()
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+			})
+			It("keeps the client's place ahead of clients that joined later", func() {
+				Expect(matchmakingService.AddClient(models.NewClientProfile("client-b", 1500), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				matchmakingService.FallBackToBots()
+				statuses, statusErr := matchmakingService.ClientStatuses(client.ClientKey)
+				Expect(statusErr).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveLen(1))
+				Expect(statuses[0].QueuePosition).To(Equal(1))
+			})
+		})
+		When("the client has not waited long enough", func() {
+			BeforeEach(func() {
+				matchmakingService.Config().(*matchmaking.MatchmakingConfig).BotFallback.Wait = time.Hour
+			})
+			It("keeps the client waiting", func() {
+				matchServiceMock.EXPECT().RequestChallenge(gomock.Any()).Times(0)
+				matchmakingService.FallBackToBots()
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+			})
+		})
+	})
+	When("the client did not opt in", func() {
+		It("keeps the client waiting", func() {
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-a", 1500), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			matchServiceMock.EXPECT().RequestChallenge(gomock.Any()).Times(0)
+			matchmakingService.FallBackToBots()
+			Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	"github.com/CameronHonis/chess-arbitrator/models"
+)
+
+const COLOUR_HISTORY_LENGTH = 10
+
+type ColourAllocation struct {
+	WhiteClientKey models.Key
+	BlackClientKey models.Key
+	Reason         string
+}
+
+// AllocateColours picks colours for a pairing. Avoiding a third game in a row with the same colour comes first, then
+// evening out each player's white/black counts, and only when neither player is worse off either way are colour
+// preferences honoured.
+func AllocateColours(clientA, clientB *models.ClientProfile, historyA, historyB []models.Colour) *ColourAllocation {
+	aWhite := &ColourAllocation{WhiteClientKey: clientA.ClientKey, BlackClientKey: clientB.ClientKey}
+	aBlack := &ColourAllocation{WhiteClientKey: clientB.ClientKey, BlackClientKey: clientA.ClientKey}
+
+	aWhiteRepeats := repeatPenalty(historyA, models.COLOUR_WHITE) + repeatPenalty(historyB, models.COLOUR_BLACK)
+	aBlackRepeats := repeatPenalty(historyA, models.COLOUR_BLACK) + repeatPenalty(historyB, models.COLOUR_WHITE)
+	if aWhiteRepeats != aBlackRepeats {
+		return pickAllocation(aWhiteRepeats < aBlackRepeats, aWhite, aBlack, "avoids a third game in a row with the same colour")
+	}
+
+	aWhiteImbalance := imbalanceAfter(historyA, models.COLOUR_WHITE) + imbalanceAfter(historyB, models.COLOUR_BLACK)
+	aBlackImbalance := imbalanceAfter(historyA, models.COLOUR_BLACK) + imbalanceAfter(historyB, models.COLOUR_WHITE)
+	if aWhiteImbalance != aBlackImbalance {
+		return pickAllocation(aWhiteImbalance < aBlackImbalance, aWhite, aBlack, "balances white and black counts")
+	}
+
+	aWhiteHonoured := honouredCount(clientA, models.COLOUR_WHITE) + honouredCount(clientB, models.COLOUR_BLACK)
+	aBlackHonoured := honouredCount(clientA, models.COLOUR_BLACK) + honouredCount(clientB, models.COLOUR_WHITE)
+	if aWhiteHonoured != aBlackHonoured {
+		return pickAllocation(aWhiteHonoured > aBlackHonoured, aWhite, aBlack, "honours colour preference")
+	}
+
+	return pickAllocation(helpers.RandomBool(), aWhite, aBlack, "random")
+}
+
+func (a *ColourAllocation) String() string {
+	return fmt.Sprintf("white %s, black %s (%s)", a.WhiteClientKey, a.BlackClientKey, a.Reason)
+}
+
+func pickAllocation(isFirst bool, first, second *ColourAllocation, reason string) *ColourAllocation {
+	allocation := second
+	if isFirst {
+		allocation = first
+	}
+	allocation.Reason = reason
+	return allocation
+}
+
+// repeatPenalty is 1 when the colour would make three in a row, given history ordered oldest to newest
+func repeatPenalty(history []models.Colour, colour models.Colour) int {
+	if len(history) < 2 {
+		return 0
+	}
+	if history[len(history)-1] == colour && history[len(history)-2] == colour {
+		return 1
+	}
+	return 0
+}
+
+func imbalanceAfter(history []models.Colour, colour models.Colour) int {
+	balance := 1
+	if colour == models.COLOUR_BLACK {
+		balance = -1
+	}
+	for _, pastColour := range history {
+		if pastColour == models.COLOUR_WHITE {
+			balance++
+		} else if pastColour == models.COLOUR_BLACK {
+			balance--
+		}
+	}
+	if balance < 0 {
+		return -balance
+	}
+	return balance
+}
+
+func honouredCount(client *models.ClientProfile, colour models.Colour) int {
+	if client.ColourPreference == colour {
+		return 1
+	}
+	return 0
+}
+
+func appendColourHistory(history []models.Colour, colour models.Colour) []models.Colour {
+	history = append(history, colour)
+	if len(history) > COLOUR_HISTORY_LENGTH {
+		history = history[len(history)-COLOUR_HISTORY_LENGTH:]
+	}
+	return history
+}
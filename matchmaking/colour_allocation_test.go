@@ -0,0 +1,49 @@
+package matchmaking_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AllocateColours", func() {
+	var clientA, clientB *models.ClientProfile
+	BeforeEach(func() {
+		clientA = models.NewClientProfile("client-a", 1000)
+		clientB = models.NewClientProfile("client-b", 1000)
+	})
+	When("a client has played white twice in a row", func() {
+		It("gives that client black", func() {
+			historyA := []models.Colour{models.COLOUR_BLACK, models.COLOUR_WHITE, models.COLOUR_WHITE}
+			historyB := []models.Colour{models.COLOUR_WHITE, models.COLOUR_WHITE, models.COLOUR_BLACK}
+			allocation := matchmaking.AllocateColours(clientA, clientB, historyA, historyB)
+			Expect(allocation.BlackClientKey).To(Equal(clientA.ClientKey))
+			Expect(allocation.Reason).To(Equal("avoids a third game in a row with the same colour"))
+		})
+	})
+	When("a client has played black more often", func() {
+		It("gives that client white", func() {
+			historyB := []models.Colour{models.COLOUR_BLACK, models.COLOUR_WHITE, models.COLOUR_BLACK}
+			allocation := matchmaking.AllocateColours(clientA, clientB, nil, historyB)
+			Expect(allocation.WhiteClientKey).To(Equal(clientB.ClientKey))
+			Expect(allocation.Reason).To(Equal("balances white and black counts"))
+		})
+	})
+	When("the histories are even and a client prefers a colour", func() {
+		It("honours the preference", func() {
+			clientB.ColourPreference = models.COLOUR_WHITE
+			allocation := matchmaking.AllocateColours(clientA, clientB, nil, nil)
+			Expect(allocation.WhiteClientKey).To(Equal(clientB.ClientKey))
+			Expect(allocation.Reason).To(Equal("honours colour preference"))
+		})
+	})
+	When("honouring a preference would unbalance a history", func() {
+		It("ignores the preference", func() {
+			clientA.ColourPreference = models.COLOUR_WHITE
+			historyA := []models.Colour{models.COLOUR_WHITE}
+			allocation := matchmaking.AllocateColours(clientA, clientB, historyA, nil)
+			Expect(allocation.BlackClientKey).To(Equal(clientA.ClientKey))
+		})
+	})
+})
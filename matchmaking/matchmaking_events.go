@@ -0,0 +1,132 @@
+package matchmaking
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/CameronHonis/service"
+)
+
+const (
+	MATCHMAKING_JOINED         = "MATCHMAKING_JOINED"
+	MATCHMAKING_LEFT           = "MATCHMAKING_LEFT"
+	MATCHMAKING_STATUS_UPDATED = "MATCHMAKING_STATUS_UPDATED"
+	MATCH_FOUND                = "MATCH_FOUND"
+	MATCH_CANCELLED            = "MATCH_CANCELLED"
+	PARTY_UPDATED              = "PARTY_UPDATED"
+	PARTY_DISBANDED            = "PARTY_DISBANDED"
+)
+
+type MatchmakingJoinedEventPayload struct {
+	ClientKey models.Key
+	Queues    []*models.MatchmakingQueue
+}
+
+type MatchmakingJoinedEvent struct{ Event }
+
+func NewMatchmakingJoinedEvent(clientKey models.Key, queues []*models.MatchmakingQueue) *MatchmakingJoinedEvent {
+	return &MatchmakingJoinedEvent{
+		Event: *NewEvent(MATCHMAKING_JOINED, &MatchmakingJoinedEventPayload{
+			ClientKey: clientKey,
+			Queues:    queues,
+		}),
+	}
+}
+
+type MatchmakingLeftEventPayload struct {
+	ClientKey models.Key
+	Reason    models.MatchmakingLeftReason
+}
+
+type MatchmakingLeftEvent struct{ Event }
+
+func NewMatchmakingLeftEvent(clientKey models.Key, reason models.MatchmakingLeftReason) *MatchmakingLeftEvent {
+	return &MatchmakingLeftEvent{
+		Event: *NewEvent(MATCHMAKING_LEFT, &MatchmakingLeftEventPayload{
+			ClientKey: clientKey,
+			Reason:    reason,
+		}),
+	}
+}
+
+type MatchmakingStatusUpdatedEventPayload struct {
+	ClientKey models.Key
+	Statuses  []*models.MatchmakingStatus
+}
+
+type MatchmakingStatusUpdatedEvent struct{ Event }
+
+func NewMatchmakingStatusUpdatedEvent(clientKey models.Key, statuses []*models.MatchmakingStatus) *MatchmakingStatusUpdatedEvent {
+	return &MatchmakingStatusUpdatedEvent{
+		Event: *NewEvent(MATCHMAKING_STATUS_UPDATED, &MatchmakingStatusUpdatedEventPayload{
+			ClientKey: clientKey,
+			Statuses:  statuses,
+		}),
+	}
+}
+
+type MatchFoundEventPayload struct {
+	ClientKey  models.Key
+	ReadyCheck *models.ReadyCheck
+}
+
+type MatchFoundEvent struct{ Event }
+
+func NewMatchFoundEvent(clientKey models.Key, readyCheck *models.ReadyCheck) *MatchFoundEvent {
+	return &MatchFoundEvent{
+		Event: *NewEvent(MATCH_FOUND, &MatchFoundEventPayload{
+			ClientKey:  clientKey,
+			ReadyCheck: readyCheck,
+		}),
+	}
+}
+
+type MatchCancelledEventPayload struct {
+	ClientKey    models.Key
+	ReadyCheckId string
+	Reason       models.ReadyCheckCancelledReason
+}
+
+type MatchCancelledEvent struct{ Event }
+
+func NewMatchCancelledEvent(clientKey models.Key, readyCheckId string, reason models.ReadyCheckCancelledReason) *MatchCancelledEvent {
+	return &MatchCancelledEvent{
+		Event: *NewEvent(MATCH_CANCELLED, &MatchCancelledEventPayload{
+			ClientKey:    clientKey,
+			ReadyCheckId: readyCheckId,
+			Reason:       reason,
+		}),
+	}
+}
+
+type PartyUpdatedEventPayload struct {
+	ClientKey models.Key
+	Party     *models.Party
+}
+
+type PartyUpdatedEvent struct{ Event }
+
+func NewPartyUpdatedEvent(clientKey models.Key, party *models.Party) *PartyUpdatedEvent {
+	return &PartyUpdatedEvent{
+		Event: *NewEvent(PARTY_UPDATED, &PartyUpdatedEventPayload{
+			ClientKey: clientKey,
+			Party:     party,
+		}),
+	}
+}
+
+type PartyDisbandedEventPayload struct {
+	ClientKey models.Key
+	Code      string
+	Reason    models.PartyDisbandedReason
+}
+
+type PartyDisbandedEvent struct{ Event }
+
+func NewPartyDisbandedEvent(clientKey models.Key, code string, reason models.PartyDisbandedReason) *PartyDisbandedEvent {
+	return &PartyDisbandedEvent{
+		Event: *NewEvent(PARTY_DISBANDED, &PartyDisbandedEventPayload{
+			ClientKey: clientKey,
+			Code:      code,
+			Reason:    reason,
+		}),
+	}
+}
@@ -0,0 +1,159 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MatchmakingPolicy decides who may be paired with whom, and how good a pairing is
+type MatchmakingPolicy interface {
+	// EloWindow is the range of (streak adjusted) elos the client is currently matchable with
+	EloWindow(client *models.ClientProfile, waitSecs int64) (minElo int, maxElo int)
+	IsMatchable(clientA *models.ClientProfile, clientB *models.ClientProfile, longestWaitSecs int64) bool
+	// PairingCost ranks matchable pairings against each other, lower is better
+	PairingCost(clientA *models.ClientProfile, clientB *models.ClientProfile) float64
+	// TickInterval is how long the matchmaking loop sleeps between rounds
+	TickInterval() time.Duration
+}
+
+type WindowCurve string
+
+const (
+	WINDOW_CURVE_LINEAR      WindowCurve = "linear"
+	WINDOW_CURVE_EXPONENTIAL WindowCurve = "exponential"
+)
+
+// WindowPolicy widens the accepted elo gap the longer the longest waiting client of a pair has waited. Clients on a
+// streak are treated as if rated StreakEloStep higher (or lower) per game of the streak.
+type WindowPolicy struct {
+	// elo gap accepted as soon as a client joins
+	InitialWindow float64
+	Curve         WindowCurve
+	// elo per second for linear curves, fractional growth per second for exponential curves
+	Growth float64
+	// widest elo gap ever accepted, 0 for no limit
+	MaxWindow          float64
+	StreakEloStep      int
+	MaxStreakEloAdjust int
+	Tick               time.Duration
+}
+
+// NewDefaultWindowPolicy accepts an elo gap of wait+50, the same as the original weighting of 100/(wait+50) per elo
+// against a threshold of 100
+func NewDefaultWindowPolicy() *WindowPolicy {
+	return &WindowPolicy{
+		InitialWindow: 50,
+		Curve:         WINDOW_CURVE_LINEAR,
+		Growth:        1,
+		Tick:          time.Second,
+	}
+}
+
+// WindowPolicyFromEnv overrides the default policy with any MATCHMAKING_* env vars that are set
+func WindowPolicyFromEnv() (*WindowPolicy, error) {
+	policy := NewDefaultWindowPolicy()
+	floatVars := map[string]*float64{
+		"MATCHMAKING_INITIAL_WINDOW": &policy.InitialWindow,
+		"MATCHMAKING_WINDOW_GROWTH":  &policy.Growth,
+		"MATCHMAKING_MAX_WINDOW":     &policy.MaxWindow,
+	}
+	for name, field := range floatVars {
+		if envVal, ok := os.LookupEnv(name); ok {
+			num, parseErr := strconv.ParseFloat(envVal, 64)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, parseErr)
+			}
+			*field = num
+		}
+	}
+	intVars := map[string]*int{
+		"MATCHMAKING_STREAK_ELO_STEP":       &policy.StreakEloStep,
+		"MATCHMAKING_MAX_STREAK_ELO_ADJUST": &policy.MaxStreakEloAdjust,
+	}
+	for name, field := range intVars {
+		if envVal, ok := os.LookupEnv(name); ok {
+			num, parseErr := strconv.Atoi(envVal)
+			if parseErr != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, parseErr)
+			}
+			*field = num
+		}
+	}
+	if envVal, ok := os.LookupEnv("MATCHMAKING_WINDOW_CURVE"); ok {
+		policy.Curve = WindowCurve(envVal)
+	}
+	if envVal, ok := os.LookupEnv("MATCHMAKING_TICK_INTERVAL"); ok {
+		tick, parseErr := time.ParseDuration(envVal)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid MATCHMAKING_TICK_INTERVAL: %s", parseErr)
+		}
+		policy.Tick = tick
+	}
+	if validateErr := policy.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+	return policy, nil
+}
+
+func (p *WindowPolicy) Validate() error {
+	if p.Curve != WINDOW_CURVE_LINEAR && p.Curve != WINDOW_CURVE_EXPONENTIAL {
+		return fmt.Errorf("unknown window curve %s", p.Curve)
+	}
+	if p.InitialWindow < 0 || p.Growth < 0 || p.MaxWindow < 0 {
+		return fmt.Errorf("window sizes and growth must not be negative")
+	}
+	if p.Tick <= 0 {
+		return fmt.Errorf("tick interval must be positive")
+	}
+	return nil
+}
+
+// Window is the widest elo gap accepted after waiting waitSecs
+func (p *WindowPolicy) Window(waitSecs int64) float64 {
+	var window float64
+	switch p.Curve {
+	case WINDOW_CURVE_EXPONENTIAL:
+		window = p.InitialWindow * math.Pow(1+p.Growth, float64(waitSecs))
+	default:
+		window = p.InitialWindow + p.Growth*float64(waitSecs)
+	}
+	if p.MaxWindow > 0 && window > p.MaxWindow {
+		return p.MaxWindow
+	}
+	return window
+}
+
+// AdjustedElo shifts the client's elo up for a win streak and down for a loss streak
+func (p *WindowPolicy) AdjustedElo(client *models.ClientProfile) int {
+	adjust := p.StreakEloStep * (client.WinStreak - client.LossStreak)
+	if p.MaxStreakEloAdjust > 0 {
+		if adjust > p.MaxStreakEloAdjust {
+			adjust = p.MaxStreakEloAdjust
+		} else if adjust < -p.MaxStreakEloAdjust {
+			adjust = -p.MaxStreakEloAdjust
+		}
+	}
+	return client.Elo + adjust
+}
+
+func (p *WindowPolicy) EloWindow(client *models.ClientProfile, waitSecs int64) (minElo int, maxElo int) {
+	window := int(p.Window(waitSecs))
+	elo := p.AdjustedElo(client)
+	return elo - window, elo + window
+}
+
+func (p *WindowPolicy) IsMatchable(clientA *models.ClientProfile, clientB *models.ClientProfile, longestWaitSecs int64) bool {
+	return p.PairingCost(clientA, clientB) <= p.Window(longestWaitSecs)
+}
+
+func (p *WindowPolicy) PairingCost(clientA *models.ClientProfile, clientB *models.ClientProfile) float64 {
+	return math.Abs(float64(p.AdjustedElo(clientA) - p.AdjustedElo(clientB)))
+}
+
+func (p *WindowPolicy) TickInterval() time.Duration {
+	return p.Tick
+}
@@ -0,0 +1,128 @@
+package matchmaking_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+	"time"
+)
+
+var _ = Describe("WindowPolicy", func() {
+	var policy *matchmaking.WindowPolicy
+	var clientA, clientB *models.ClientProfile
+	BeforeEach(func() {
+		policy = matchmaking.NewDefaultWindowPolicy()
+		clientA = models.NewClientProfile("client-a", 1000)
+		clientB = models.NewClientProfile("client-b", 1000)
+	})
+	Describe("the default policy", func() {
+		It("matches the original weighting of 100/(wait+50) against a threshold of 100", func() {
+			for _, waitSecs := range []int64{0, 1, 10, 50, 100, 300} {
+				for eloDiff := 0; eloDiff < 500; eloDiff += 7 {
+					clientB.Elo = clientA.Elo + eloDiff
+					originalWeight := float64(eloDiff) * 100 / (float64(waitSecs) + 50)
+					Expect(policy.IsMatchable(clientA, clientB, waitSecs)).To(Equal(originalWeight <= 100))
+				}
+			}
+		})
+		It("reports a window of wait+50 either side", func() {
+			minElo, maxElo := policy.EloWindow(clientA, 10)
+			Expect(minElo).To(Equal(940))
+			Expect(maxElo).To(Equal(1060))
+		})
+		It("ticks every second", func() {
+			Expect(policy.TickInterval()).To(Equal(time.Second))
+		})
+	})
+	When("the window has a maximum", func() {
+		BeforeEach(func() {
+			policy.MaxWindow = 200
+		})
+		It("stops growing at the maximum", func() {
+			Expect(policy.Window(1000)).To(Equal(200.0))
+			clientB.Elo = 1250
+			Expect(policy.IsMatchable(clientA, clientB, 1000)).To(BeFalse())
+		})
+	})
+	When("the window grows exponentially", func() {
+		BeforeEach(func() {
+			policy.Curve = matchmaking.WINDOW_CURVE_EXPONENTIAL
+			policy.Growth = 0.1
+		})
+		It("compounds the growth every second", func() {
+			Expect(policy.Window(0)).To(BeNumerically("~", 50, 0.001))
+			Expect(policy.Window(10)).To(BeNumerically("~", 50*2.5937, 0.01))
+		})
+	})
+	When("streaks adjust elos", func() {
+		BeforeEach(func() {
+			policy.StreakEloStep = 20
+			policy.MaxStreakEloAdjust = 60
+		})
+		It("rates a client on a win streak higher", func() {
+			clientA.WinStreak = 2
+			Expect(policy.AdjustedElo(clientA)).To(Equal(1040))
+		})
+		It("rates a client on a loss streak lower, up to the maximum adjustment", func() {
+			clientA.LossStreak = 5
+			Expect(policy.AdjustedElo(clientA)).To(Equal(940))
+		})
+		It("pairs a streaking client with stronger opponents", func() {
+			clientA.WinStreak = 3
+			clientB.Elo = 1100
+			Expect(policy.IsMatchable(clientA, clientB, 0)).To(BeTrue())
+			Expect(matchmaking.NewDefaultWindowPolicy().IsMatchable(clientA, clientB, 0)).To(BeFalse())
+		})
+	})
+	Describe("WindowPolicyFromEnv", func() {
+		AfterEach(func() {
+			_ = os.Unsetenv("MATCHMAKING_MAX_WINDOW")
+			_ = os.Unsetenv("MATCHMAKING_TICK_INTERVAL")
+			_ = os.Unsetenv("MATCHMAKING_WINDOW_CURVE")
+		})
+		It("overrides the defaults with the env vars that are set", func() {
+			Expect(os.Setenv("MATCHMAKING_MAX_WINDOW", "300")).To(Succeed())
+			Expect(os.Setenv("MATCHMAKING_TICK_INTERVAL", "250ms")).To(Succeed())
+			envPolicy, err := matchmaking.WindowPolicyFromEnv()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envPolicy.MaxWindow).To(Equal(300.0))
+			Expect(envPolicy.TickInterval()).To(Equal(250 * time.Millisecond))
+			Expect(envPolicy.InitialWindow).To(Equal(50.0))
+		})
+		It("rejects unknown curves", func() {
+			Expect(os.Setenv("MATCHMAKING_WINDOW_CURVE", "sideways")).To(Succeed())
+			_, err := matchmaking.WindowPolicyFromEnv()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Simulate", func() {
+	var arrivals []*matchmaking.SimulatedArrival
+	BeforeEach(func() {
+		arrivals = matchmaking.SyntheticArrivals(42, 500, 3, 1200, 250)
+	})
+	It("accounts for every arrival", func() {
+		report := matchmaking.Simulate(matchmaking.NewDefaultWindowPolicy(), arrivals, 3600)
+		Expect(report.PairedCount + report.UnpairedCount).To(Equal(len(arrivals)))
+		Expect(report.WaitSecs.Count).To(Equal(report.PairedCount))
+		Expect(report.EloGaps.Count).To(Equal(report.PairedCount / 2))
+	})
+	It("trades wait times for rating gaps between policies", func() {
+		narrow := matchmaking.NewDefaultWindowPolicy()
+		narrow.MaxWindow = 100
+		wide := matchmaking.NewDefaultWindowPolicy()
+		wide.InitialWindow = 200
+		summary, reportByName := matchmaking.SimulatePolicies(map[string]matchmaking.MatchmakingPolicy{
+			"narrow": narrow,
+			"wide":   wide,
+		}, arrivals, 3600)
+		GinkgoWriter.Println(summary)
+		Expect(summary).To(ContainSubstring("narrow: "))
+		Expect(summary).To(ContainSubstring("wide: "))
+		Expect(reportByName["wide"].WaitSecs.Mean).To(BeNumerically("<", reportByName["narrow"].WaitSecs.Mean))
+		Expect(reportByName["wide"].EloGaps.Mean).To(BeNumerically(">", reportByName["narrow"].EloGaps.Mean))
+	})
+})
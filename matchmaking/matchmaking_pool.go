@@ -3,7 +3,6 @@ package matchmaking
 import (
 	"fmt"
 	"github.com/CameronHonis/chess-arbitrator/models"
-	"math"
 	"sync"
 	"time"
 )
@@ -13,13 +12,15 @@ type MMPoolNode struct {
 	prev          *MMPoolNode
 	clientProfile *models.ClientProfile
 	timeControl   *models.TimeControl
+	variant       models.Variant
 	timeJoined    int64
 }
 
-func NewMMPoolNode(profile *models.ClientProfile, timeControl *models.TimeControl) *MMPoolNode {
+func NewMMPoolNode(profile *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) *MMPoolNode {
 	return &MMPoolNode{
 		clientProfile: profile,
 		timeControl:   timeControl,
+		variant:       variant,
 		timeJoined:    time.Now().Unix(),
 	}
 }
@@ -43,7 +44,9 @@ type MatchmakingPool struct {
 	tail *MMPoolNode
 	// map to allow for O(1) lookup time of nodes by client key
 	nodeByClientKey map[models.Key]*MMPoolNode
-	mu              sync.Mutex
+	// how long recently paired clients waited, oldest first
+	recentWaitSecs []int64
+	mu             sync.Mutex
 }
 
 func NewMatchmakingPool() *MatchmakingPool {
@@ -72,13 +75,61 @@ func (mmp *MatchmakingPool) NodeByClientKey(clientKey models.Key) *MMPoolNode {
 	return node
 }
 
-func (mmp *MatchmakingPool) AddClient(client *models.ClientProfile, timeControl *models.TimeControl) error {
+// Position is the client's 1-indexed place in the queue, or 0 if the client is not in the pool
+func (mmp *MatchmakingPool) Position(clientKey models.Key) int {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	position := 1
+	for node := mmp.head; node != nil; node = node.next {
+		if node.clientProfile.ClientKey == clientKey {
+			return position
+		}
+		position++
+	}
+	return 0
+}
+
+func (mmp *MatchmakingPool) Size() int {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	return len(mmp.nodeByClientKey)
+}
+
+func (mmp *MatchmakingPool) RecordPairingWait(waitSecs int64, maxRecorded int) {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	mmp.recentWaitSecs = append(mmp.recentWaitSecs, waitSecs)
+	if len(mmp.recentWaitSecs) > maxRecorded {
+		mmp.recentWaitSecs = mmp.recentWaitSecs[len(mmp.recentWaitSecs)-maxRecorded:]
+	}
+}
+
+// EstimatedWaitSecs averages the recent pairing waits and subtracts the time already waited. Returns nil if no
+// pairings have been made yet.
+func (mmp *MatchmakingPool) EstimatedWaitSecs(waitedSecs int64) *int64 {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	if len(mmp.recentWaitSecs) == 0 {
+		return nil
+	}
+	var totalWaitSecs int64
+	for _, waitSecs := range mmp.recentWaitSecs {
+		totalWaitSecs += waitSecs
+	}
+	estimatedSecs := totalWaitSecs/int64(len(mmp.recentWaitSecs)) - waitedSecs
+	if estimatedSecs < 0 {
+		estimatedSecs = 0
+	}
+	return &estimatedSecs
+}
+
+func (mmp *MatchmakingPool) AddClient(client *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error {
 	mmp.mu.Lock()
 	defer mmp.mu.Unlock()
 	if _, ok := mmp.nodeByClientKey[client.ClientKey]; ok {
 		return fmt.Errorf("client with key %s already in pool", client.ClientKey)
 	}
-	node := NewMMPoolNode(client, timeControl)
+	node := NewMMPoolNode(client, timeControl, variant)
 	if mmp.tail == nil {
 		mmp.tail = node
 		mmp.head = node
@@ -91,6 +142,27 @@ func (mmp *MatchmakingPool) AddClient(client *models.ClientProfile, timeControl
 	return nil
 }
 
+// AddClientAtFront puts the client ahead of everyone else in the pool, keeping the time they originally joined
+func (mmp *MatchmakingPool) AddClientAtFront(client *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant, timeJoined int64) error {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	if _, ok := mmp.nodeByClientKey[client.ClientKey]; ok {
+		return fmt.Errorf("client with key %s already in pool", client.ClientKey)
+	}
+	node := NewMMPoolNode(client, timeControl, variant)
+	node.timeJoined = timeJoined
+	if mmp.head == nil {
+		mmp.tail = node
+		mmp.head = node
+	} else {
+		mmp.head.prev = node
+		node.next = mmp.head
+		mmp.head = node
+	}
+	mmp.nodeByClientKey[client.ClientKey] = node
+	return nil
+}
+
 func (mmp *MatchmakingPool) RemoveClient(clientKey models.Key) error {
 	mmp.mu.Lock()
 	defer mmp.mu.Unlock()
@@ -112,37 +184,18 @@ func (mmp *MatchmakingPool) RemoveClient(clientKey models.Key) error {
 	return nil
 }
 
-func (mmp *MatchmakingPool) GetBestMatch(node *MMPoolNode, waitTime int64) (*models.ClientProfile, error) {
-	bestMatchPoolNode := node.next
-	bestMatchWeight := float64(10000000000)
-	nextPoolNode := node.next
-	for nextPoolNode != nil {
-		nextPoolNodeMatchWeight := weightProfileDiff(node.clientProfile, nextPoolNode.clientProfile, waitTime)
-
-		if !IsMatchable(node.clientProfile, nextPoolNode.clientProfile, waitTime) {
-			nextPoolNode = nextPoolNode.next
-			continue
-		}
-
-		if nextPoolNodeMatchWeight < bestMatchWeight {
-			bestMatchPoolNode = nextPoolNode
-			bestMatchWeight = nextPoolNodeMatchWeight
-		}
-		nextPoolNode = nextPoolNode.next
-	}
-
-	if bestMatchPoolNode == nil {
-		return nil, fmt.Errorf("no possible matches found")
+// Snapshot copies out the pool's clients, oldest first, so they can be paired without holding the pool lock
+func (mmp *MatchmakingPool) Snapshot(now int64) []*PairingCandidate {
+	mmp.mu.Lock()
+	defer mmp.mu.Unlock()
+	candidates := make([]*PairingCandidate, 0, len(mmp.nodeByClientKey))
+	for node := mmp.head; node != nil; node = node.next {
+		candidates = append(candidates, &PairingCandidate{
+			ClientProfile: node.clientProfile,
+			TimeControl:   node.timeControl,
+			Variant:       node.variant,
+			WaitSecs:      now - node.timeJoined,
+		})
 	}
-	return bestMatchPoolNode.clientProfile, nil
-}
-
-func weightProfileDiff(p1 *models.ClientProfile, p2 *models.ClientProfile, longestWaitSeconds int64) float64 {
-	eloDiff := math.Abs(float64(p1.Elo - p2.Elo))
-	eloCoeff := 100 / (float64(longestWaitSeconds) + 50) // asymptotic curve approaches 0, 2 @ t=0, 1 @ t=50, 0.5 @ t=100
-	return eloDiff * eloCoeff
-}
-
-func IsMatchable(clientA *models.ClientProfile, clientB *models.ClientProfile, longestWaitSeconds int64) bool {
-	return weightProfileDiff(clientA, clientB, longestWaitSeconds) <= 100
+	return candidates
 }
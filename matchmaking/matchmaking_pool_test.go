@@ -7,6 +7,7 @@ import (
 	"github.com/CameronHonis/chess-arbitrator/models"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"time"
 )
 
 var _ = Describe("MatchmakingPool", func() {
@@ -21,7 +22,7 @@ var _ = Describe("MatchmakingPool", func() {
 		})
 		When("the client is not already in the pool", func() {
 			It("should add the client to the pool", func() {
-				err := matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl())
+				err := matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)
 				Expect(err).To(BeNil())
 				Expect(matchmakingPool.Head()).To(Equal(matchmakingPool.Tail()))
 				Expect(matchmakingPool.NodeByClientKey(clientProfile.ClientKey)).To(Equal(matchmakingPool.Head()))
@@ -29,10 +30,10 @@ var _ = Describe("MatchmakingPool", func() {
 		})
 		When("the client is already in the pool", func() {
 			BeforeEach(func() {
-				Expect(matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
+				Expect(matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
 			})
 			It("should return an error", func() {
-				err := matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl())
+				err := matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)
 				Expect(err).To(Equal(fmt.Errorf("client with key %s already in pool", clientProfile.ClientKey)))
 			})
 		})
@@ -40,10 +41,10 @@ var _ = Describe("MatchmakingPool", func() {
 			var otherClientProfile *models.ClientProfile
 			BeforeEach(func() {
 				otherClientProfile = models.NewClientProfile("some-other-client-key", 1000)
-				Expect(matchmakingPool.AddClient(otherClientProfile, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
+				Expect(matchmakingPool.AddClient(otherClientProfile, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
 			})
 			It("should add the client to the pool", func() {
-				Expect(matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
+				Expect(matchmakingPool.AddClient(clientProfile, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
 				Expect(matchmakingPool.Head().ClientProfile()).To(Equal(otherClientProfile))
 				Expect(matchmakingPool.Head().Next().ClientProfile()).To(Equal(clientProfile))
 				Expect(matchmakingPool.Tail().ClientProfile()).To(Equal(clientProfile))
@@ -58,9 +59,9 @@ var _ = Describe("MatchmakingPool", func() {
 			clientA = models.NewClientProfile("client-key-a", 1000)
 			clientB = models.NewClientProfile("client-key-b", 1000)
 			clientC = models.NewClientProfile("client-key-c", 1000)
-			Expect(matchmakingPool.AddClient(clientA, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
-			Expect(matchmakingPool.AddClient(clientB, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
-			Expect(matchmakingPool.AddClient(clientC, builders.NewBlitzTimeControl())).ToNot(HaveOccurred())
+			Expect(matchmakingPool.AddClient(clientA, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
+			Expect(matchmakingPool.AddClient(clientB, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
+			Expect(matchmakingPool.AddClient(clientC, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).ToNot(HaveOccurred())
 		})
 		Context("when the client is the head of the pool", func() {
 			It("removes the client and re-assign the head", func() {
@@ -94,4 +95,14 @@ var _ = Describe("MatchmakingPool", func() {
 			})
 		})
 	})
+	Describe("Snapshot", func() {
+		It("lists the clients oldest first", func() {
+			Expect(matchmakingPool.AddClient(models.NewClientProfile("client-key-a", 1000), builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingPool.AddClient(models.NewClientProfile("client-key-b", 1000), builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).To(Succeed())
+			snapshot := matchmakingPool.Snapshot(time.Now().Unix())
+			Expect(snapshot).To(HaveLen(2))
+			Expect(snapshot[0].ClientProfile.ClientKey).To(Equal(models.Key("client-key-a")))
+			Expect(snapshot[1].ClientProfile.ClientKey).To(Equal(models.Key("client-key-b")))
+		})
+	})
 })
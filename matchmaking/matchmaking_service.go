@@ -5,18 +5,32 @@ import (
 	"github.com/CameronHonis/chess-arbitrator/builders"
 	"github.com/CameronHonis/chess-arbitrator/matcher"
 	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/chess-arbitrator/penalty"
 	"github.com/CameronHonis/log"
 	"github.com/CameronHonis/marker"
 	"github.com/CameronHonis/service"
+	"sort"
 	"sync"
 	"time"
 )
 
 type MatchmakingServiceI interface {
 	service.ServiceI
-	AddClient(client *models.ClientProfile, timeControl *models.TimeControl) error
-	RemoveClient(clientKey models.Key) error
-	GetClientCountByTimeControl(timeControl *models.TimeControl) int
+	AddClient(client *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error
+	AddClientToQueues(client *models.ClientProfile, queues []*models.MatchmakingQueue) error
+	JoinMatchmaking(clientKey models.Key, findMatch *models.FindMatchMessageContent) error
+	RemoveClient(clientKey models.Key, reason models.MatchmakingLeftReason) error
+	GetClientCountByTimeControl(timeControl *models.TimeControl, variant models.Variant) int
+	PoolSizes() []*models.PoolSize
+	ClientStatuses(clientKey models.Key) ([]*models.MatchmakingStatus, error)
+	SetAvoidList(clientKey models.Key, avoidedKeys []models.Key)
+	AvoidList(clientKey models.Key) []models.Key
+	ForgetClient(clientKey models.Key)
+	ConfirmMatch(clientKey models.Key, readyCheckId string) error
+	CreateParty(hostKey models.Key, mode models.PartyMode, timeControl *models.TimeControl, variant models.Variant, isRated bool) (*models.Party, error)
+	Party(code string) (*models.Party, error)
+	QueueInParty(clientKey models.Key, code string) error
+	LeaveParty(clientKey models.Key) error
 }
 
 type MatchmakingService struct {
@@ -25,19 +39,36 @@ type MatchmakingService struct {
 	__dependencies__ marker.Marker
 	LogService       log.LoggerServiceI
 	MatchService     matcher.MatcherServiceI
+	PenaltyService   penalty.PenaltyServiceI
 
-	__state__             marker.Marker
-	poolByTimeControlHash map[string]*MatchmakingPool
-	poolByClientKey       map[models.Key]*MatchmakingPool
-	mu                    sync.Mutex
+	__state__               marker.Marker
+	poolByQueueKey          map[string]*MatchmakingPool
+	poolsByClientKey        map[models.Key][]*MatchmakingPool
+	coloursByClientKey      map[models.Key][]models.Colour
+	opponentMemory          *OpponentMemory
+	readyChecksByUuid       map[string]*pendingReadyCheck
+	readyCheckIdByClientKey map[models.Key]string
+	partiesByCode           map[string]*partyState
+	partyCodeByClientKey    map[models.Key]string
+	mu                      sync.Mutex
 }
 
 func NewMatchmakingService(config *MatchmakingConfig) *MatchmakingService {
 	matchmakingService := &MatchmakingService{
-		poolByTimeControlHash: make(map[string]*MatchmakingPool),
-		poolByClientKey:       make(map[models.Key]*MatchmakingPool),
-		mu:                    sync.Mutex{},
+		poolByQueueKey:          make(map[string]*MatchmakingPool),
+		poolsByClientKey:        make(map[models.Key][]*MatchmakingPool),
+		coloursByClientKey:      make(map[models.Key][]models.Colour),
+		readyChecksByUuid:       make(map[string]*pendingReadyCheck),
+		readyCheckIdByClientKey: make(map[models.Key]string),
+		partiesByCode:           make(map[string]*partyState),
+		partyCodeByClientKey:    make(map[models.Key]string),
+		mu:                      sync.Mutex{},
 	}
+	opponentMemoryConfig := config.OpponentMemory
+	if opponentMemoryConfig == nil {
+		opponentMemoryConfig = NewOpponentMemoryConfig()
+	}
+	matchmakingService.opponentMemory = NewOpponentMemory(opponentMemoryConfig)
 	matchmakingService.Service = *service.NewService(matchmakingService, config)
 
 	return matchmakingService
@@ -45,112 +76,447 @@ func NewMatchmakingService(config *MatchmakingConfig) *MatchmakingService {
 
 func (mm *MatchmakingService) OnStart() {
 	go mm.loopMatchmaking()
+	go mm.loopStatusUpdates()
+}
+
+func (mm *MatchmakingService) Policy() MatchmakingPolicy {
+	config := mm.Config().(*MatchmakingConfig)
+	if config.Policy == nil {
+		return NewDefaultWindowPolicy()
+	}
+	return config.Policy
 }
 
-func (mm *MatchmakingService) AddClient(client *models.ClientProfile, timeControl *models.TimeControl) error {
-	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("adding client %s to matchmaking pool", client.ClientKey))
+func (mm *MatchmakingService) AddClient(client *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error {
+	return mm.AddClientToQueues(client, []*models.MatchmakingQueue{models.NewMatchmakingQueue(timeControl, variant)})
+}
+
+// JoinMatchmaking queues the client as a FIND_MATCH asks, in every queue it names or within the party it names
+func (mm *MatchmakingService) JoinMatchmaking(clientKey models.Key, findMatch *models.FindMatchMessageContent) error {
+	if findMatch.PartyCode != "" {
+		return mm.QueueInParty(clientKey, findMatch.PartyCode)
+	}
+	// TODO: query for elo, winStreak, lossStreak within the variant's rating category
+	return mm.AddClientToQueues(&models.ClientProfile{
+		ClientKey:            clientKey,
+		Elo:                  1000,
+		WinStreak:            0,
+		LossStreak:           0,
+		ColourPreference:     findMatch.ColourPreference,
+		IsBotFallbackAllowed: findMatch.BotFallback,
+	}, findMatch.AllQueues())
+}
 
-	timeControlHash := timeControl.Hash()
+// AddClientToQueues places the client in the pool for each queue at once. Either every queue is joined or none are.
+func (mm *MatchmakingService) AddClientToQueues(client *models.ClientProfile, queues []*models.MatchmakingQueue) error {
+	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("adding client %s to %d matchmaking pool(s)", client.ClientKey, len(queues)))
+	if len(queues) == 0 {
+		return fmt.Errorf("no queues given")
+	}
+	uniqueQueues := make([]*models.MatchmakingQueue, 0, len(queues))
+	seenQueueKeys := make(map[string]bool)
+	for _, queue := range queues {
+		if queue == nil || queue.TimeControl == nil {
+			return fmt.Errorf("queue is missing a time control")
+		}
+		variant := queue.Variant.OrStandard()
+		if !variant.IsValid() {
+			return fmt.Errorf("unknown variant %s", variant)
+		}
+		poolKey := queueKey(queue.TimeControl, variant)
+		if seenQueueKeys[poolKey] {
+			continue
+		}
+		seenQueueKeys[poolKey] = true
+		uniqueQueues = append(uniqueQueues, models.NewMatchmakingQueue(queue.TimeControl, variant))
+	}
 
+	if mm.isInMatch(client.ClientKey) {
+		return fmt.Errorf("client with key %s is already in a match", client.ClientKey)
+	}
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
-	if _, ok := mm.poolByClientKey[client.ClientKey]; ok {
-		return fmt.Errorf("client with key %s already in a different timeControl pool", client.ClientKey)
+	if _, ok := mm.poolsByClientKey[client.ClientKey]; ok {
+		return fmt.Errorf("client with key %s already in matchmaking", client.ClientKey)
 	}
-	pool := mm.poolByTimeControlHash[timeControlHash]
-	if pool == nil {
-		pool = NewMatchmakingPool()
-		mm.poolByTimeControlHash[timeControlHash] = pool
+	if mm.isInPartyQueue(client.ClientKey) {
+		return fmt.Errorf("client with key %s already queued in a party", client.ClientKey)
+	}
+	if vetErr := mm.vetQueueing(client.ClientKey); vetErr != nil {
+		return vetErr
 	}
+	return mm.enqueue(client, uniqueQueues, time.Now().Unix(), false)
+}
+
+// isInMatch asks the matcher whether the client is playing. Callers must not hold mm.mu.
+func (mm *MatchmakingService) isInMatch(clientKey models.Key) bool {
+	_, matchErr := mm.MatchService.MatchByClientKey(clientKey)
+	return matchErr == nil
+}
+
+// vetQueueing turns away clients with a match waiting to be confirmed or a cooldown still running, whether they queue
+// for matchmaking or within a party. Callers must hold mm.mu.
+func (mm *MatchmakingService) vetQueueing(clientKey models.Key) error {
+	if _, ok := mm.readyCheckIdByClientKey[clientKey]; ok {
+		return fmt.Errorf("client with key %s has a match waiting to be confirmed", clientKey)
+	}
+	if cooldown := mm.PenaltyService.CooldownRemaining(clientKey); cooldown > 0 {
+		return fmt.Errorf("client with key %s is on a matchmaking cooldown for leaving or stalling recent matches, try again in %s", clientKey, cooldown.Round(time.Second))
+	}
+	return nil
+}
 
-	mm.poolByClientKey[client.ClientKey] = pool
-	addErr := pool.AddClient(client, timeControl)
-	if addErr != nil {
-		return addErr
+// enqueue adds the client to the pool for each queue, either at the back or, for clients being returned to the queue,
+// at the front. Callers must hold mm.mu.
+func (mm *MatchmakingService) enqueue(client *models.ClientProfile, queues []*models.MatchmakingQueue, timeJoined int64, isAtFront bool) error {
+	pools := make([]*MatchmakingPool, 0, len(queues))
+	for _, queue := range queues {
+		poolKey := queueKey(queue.TimeControl, queue.Variant)
+		pool := mm.poolByQueueKey[poolKey]
+		if pool == nil {
+			pool = NewMatchmakingPool()
+			mm.poolByQueueKey[poolKey] = pool
+		}
+		var addErr error
+		if isAtFront {
+			addErr = pool.AddClientAtFront(client, queue.TimeControl, queue.Variant, timeJoined)
+		} else {
+			addErr = pool.AddClient(client, queue.TimeControl, queue.Variant)
+		}
+		if addErr != nil {
+			for _, addedPool := range pools {
+				_ = addedPool.RemoveClient(client.ClientKey)
+			}
+			return addErr
+		}
+		pools = append(pools, pool)
 	}
-	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("%d clients in pool", len(pool.nodeByClientKey)))
+	mm.poolsByClientKey[client.ClientKey] = pools
+
+	go mm.Dispatch(NewMatchmakingJoinedEvent(client.ClientKey, queues))
 	return nil
 }
 
-func (mm *MatchmakingService) RemoveClient(clientKey models.Key) error {
+// queuedClient is a client as they stood in matchmaking, kept so they can be put back where they were
+type queuedClient struct {
+	profile    *models.ClientProfile
+	queues     []*models.MatchmakingQueue
+	timeJoined int64
+}
+
+// queuedClientByKey callers must hold mm.mu
+func (mm *MatchmakingService) queuedClientByKey(clientKey models.Key) *queuedClient {
+	var queued *queuedClient
+	for _, pool := range mm.poolsByClientKey[clientKey] {
+		node := pool.NodeByClientKey(clientKey)
+		if node == nil {
+			continue
+		}
+		if queued == nil {
+			queued = &queuedClient{profile: node.clientProfile, timeJoined: node.timeJoined}
+		}
+		queued.queues = append(queued.queues, models.NewMatchmakingQueue(node.timeControl, node.variant))
+	}
+	return queued
+}
+
+// RemoveClient takes the client out of matchmaking, out of their party's queue if they are waiting in one, or out of
+// the ready check for a match they were found
+func (mm *MatchmakingService) RemoveClient(clientKey models.Key, reason models.MatchmakingLeftReason) error {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
+	if party, ok := mm.removeFromPartyQueue(clientKey); ok {
+		mm.dispatchPartyUpdated(party)
+		return nil
+	}
+	if mm.cancelReadyCheckFor(clientKey) {
+		return nil
+	}
+	return mm.removeClient(clientKey, reason)
+}
 
-	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("removing client %s from matchmaking pool", clientKey))
-	pool := mm.poolByClientKey[clientKey]
-	if pool == nil {
+// removeClient pulls the client out of every pool it is queued in. Callers must hold mm.mu.
+func (mm *MatchmakingService) removeClient(clientKey models.Key, reason models.MatchmakingLeftReason) error {
+	if !mm.unqueueClient(clientKey) {
 		return fmt.Errorf("no pool found for client %s", clientKey)
 	}
+	go mm.Dispatch(NewMatchmakingLeftEvent(clientKey, reason))
+	return nil
+}
 
-	delete(mm.poolByClientKey, clientKey)
-	removeErr := pool.RemoveClient(clientKey)
-	if removeErr != nil {
-		return removeErr
+// unqueueClient pulls the client out of every pool it is queued in without reporting why, returning whether it was
+// queued at all. Callers must hold mm.mu.
+func (mm *MatchmakingService) unqueueClient(clientKey models.Key) bool {
+	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("removing client %s from matchmaking", clientKey))
+	pools, ok := mm.poolsByClientKey[clientKey]
+	if !ok {
+		return false
 	}
 
-	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("%d clients in pool", len(pool.nodeByClientKey)))
-	return nil
+	delete(mm.poolsByClientKey, clientKey)
+	for _, pool := range pools {
+		if removeErr := pool.RemoveClient(clientKey); removeErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not remove client %s from pool: %s", clientKey, removeErr))
+		}
+	}
+	return true
 }
 
-func (mm *MatchmakingService) GetClientCountByTimeControl(timeControl *models.TimeControl) int {
+func (mm *MatchmakingService) GetClientCountByTimeControl(timeControl *models.TimeControl, variant models.Variant) int {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
-	pool := mm.poolByTimeControlHash[timeControl.Hash()]
+	pool := mm.poolByQueueKey[queueKey(timeControl, variant.OrStandard())]
 	if pool == nil {
 		return 0
 	}
-	return len(pool.nodeByClientKey)
+	return pool.Size()
 }
 
-func (mm *MatchmakingService) loopMatchmaking() {
+// PoolSizes counts the clients waiting in each pool that isn't empty
+func (mm *MatchmakingService) PoolSizes() []*models.PoolSize {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	poolKeys := make([]string, 0, len(mm.poolByQueueKey))
+	for poolKey := range mm.poolByQueueKey {
+		poolKeys = append(poolKeys, poolKey)
+	}
+	sort.Strings(poolKeys)
+	poolSizes := make([]*models.PoolSize, 0, len(poolKeys))
+	for _, poolKey := range poolKeys {
+		pool := mm.poolByQueueKey[poolKey]
+		head := pool.Head()
+		if head == nil {
+			continue
+		}
+		poolSizes = append(poolSizes, &models.PoolSize{
+			TimeControl: head.timeControl,
+			Variant:     head.variant,
+			ClientCount: pool.Size(),
+		})
+	}
+	return poolSizes
+}
+
+// ClientStatuses describes the client's standing in each pool it is queued in
+func (mm *MatchmakingService) ClientStatuses(clientKey models.Key) ([]*models.MatchmakingStatus, error) {
+	mm.mu.Lock()
+	pools, ok := mm.poolsByClientKey[clientKey]
+	mm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no pool found for client %s", clientKey)
+	}
+
+	statuses := make([]*models.MatchmakingStatus, 0, len(pools))
+	for _, pool := range pools {
+		node := pool.NodeByClientKey(clientKey)
+		if node == nil {
+			continue
+		}
+		waitedSecs := time.Now().Unix() - node.timeJoined
+		minElo, maxElo := mm.Policy().EloWindow(node.clientProfile, waitedSecs)
+		statuses = append(statuses, &models.MatchmakingStatus{
+			TimeControl:      node.timeControl,
+			Variant:          node.variant,
+			QueuePosition:    pool.Position(clientKey),
+			PoolSize:         mm.GetClientCountByTimeControl(node.timeControl, node.variant),
+			MinElo:           minElo,
+			MaxElo:           maxElo,
+			WaitedSec:        waitedSecs,
+			EstimatedWaitSec: pool.EstimatedWaitSecs(waitedSecs),
+		})
+	}
+	return statuses, nil
+}
+
+func (mm *MatchmakingService) loopStatusUpdates() {
+	config := mm.Config().(*MatchmakingConfig)
 	for {
-		time.Sleep(time.Second)
-		for _, pool := range mm.poolByTimeControlHash {
-			mm.mu.Lock()
-			head := pool.Head()
-			tail := pool.Tail()
-			mm.mu.Unlock()
-
-			if head == tail {
+		time.Sleep(config.StatusInterval)
+		mm.mu.Lock()
+		clientKeys := make([]models.Key, 0, len(mm.poolsByClientKey))
+		for clientKey := range mm.poolsByClientKey {
+			clientKeys = append(clientKeys, clientKey)
+		}
+		mm.mu.Unlock()
+
+		for _, clientKey := range clientKeys {
+			statuses, statusErr := mm.ClientStatuses(clientKey)
+			if statusErr != nil {
 				continue
 			}
-			currPoolNode := head
-			for currPoolNode != nil && currPoolNode.next != nil {
-				waitTime := time.Now().Unix() - currPoolNode.timeJoined
-
-				clientA := currPoolNode.clientProfile
-				clientB, _ := pool.GetBestMatch(currPoolNode, waitTime)
-				if clientB == nil {
-					continue
-				}
-
-				matchErr := mm.MatchClient(clientA, clientB, currPoolNode.timeControl)
-				if matchErr != nil {
-					mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("error matching clients %s and %s: %s\n", clientA.ClientKey, clientB.ClientKey, matchErr))
-				} else {
-					mm.LogService.LogGreen(models.ENV_MATCHMAKING, fmt.Sprintf("matched clients %s and %s\n", clientA.ClientKey, clientB.ClientKey))
-				}
-
-				currPoolNode = currPoolNode.next
-			}
+			go mm.Dispatch(NewMatchmakingStatusUpdatedEvent(clientKey, statuses))
+		}
+	}
+}
+
+func (mm *MatchmakingService) loopMatchmaking() {
+	for {
+		time.Sleep(mm.Policy().TickInterval())
+		mm.RunMatchmakingRound()
+		mm.FallBackToBots()
+	}
+}
+
+// RunMatchmakingRound pairs every pool once. Pairings are planned from a snapshot of all pools taken under a single
+// lock, and each pairing is claimed again by MatchClient, so clients that leave mid-round are simply skipped.
+func (mm *MatchmakingService) RunMatchmakingRound() {
+	for _, pairing := range mm.planPairings() {
+		clientA, clientB := pairing.ClientA, pairing.ClientB
+		matchErr := mm.MatchClient(clientA, clientB, pairing.TimeControl, pairing.Variant)
+		if matchErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("error matching clients %s and %s: %s\n", clientA.ClientKey, clientB.ClientKey, matchErr))
+		} else {
+			mm.LogService.LogGreen(models.ENV_MATCHMAKING, fmt.Sprintf("matched clients %s and %s\n", clientA.ClientKey, clientB.ClientKey))
 		}
 	}
 }
 
-func (mm *MatchmakingService) MatchClient(clientA *models.ClientProfile, clientB *models.ClientProfile, timeControl *models.TimeControl) error {
-	removeErr := mm.RemoveClient(clientA.ClientKey)
-	if removeErr != nil {
-		return fmt.Errorf("error removing client %s from matchmaking pool: %s", clientA.ClientKey, removeErr)
+func (mm *MatchmakingService) planPairings() []*Pairing {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	poolKeys := make([]string, 0, len(mm.poolByQueueKey))
+	for poolKey := range mm.poolByQueueKey {
+		poolKeys = append(poolKeys, poolKey)
 	}
-	removeErr = mm.RemoveClient(clientB.ClientKey)
-	if removeErr != nil {
-		return fmt.Errorf("error removing client %s from matchmaking pool: %s", clientB.ClientKey, removeErr)
+	sort.Strings(poolKeys)
+
+	// every pool is paired at once, so a client queued in several pools gets its best partner across all of them
+	now := time.Now().Unix()
+	candidates := make([]*PairingCandidate, 0)
+	for _, poolKey := range poolKeys {
+		candidates = append(candidates, mm.poolByQueueKey[poolKey].Snapshot(now)...)
 	}
-	match := builders.NewMatch(clientA.ClientKey, clientB.ClientKey, timeControl, models.MATCH_RESULT_IN_PROGRESS)
+	return PairGreedily(candidates, mm.Policy(), mm.opponentMemory)
+}
+
+func (mm *MatchmakingService) MatchClient(clientA *models.ClientProfile, clientB *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error {
+	claimed, claimErr := mm.claimPair(clientA.ClientKey, clientB.ClientKey, queueKey(timeControl, variant.OrStandard()))
+	if claimErr != nil {
+		return claimErr
+	}
+	if mm.readyCheckConfig().IsEnabled {
+		mm.startReadyCheck(claimed, timeControl, variant)
+		return nil
+	}
+	return mm.startClaimedMatch(claimed, timeControl, variant)
+}
+
+// startClaimedMatch starts the match for a claimed pair and only then reports both clients as matched. If the match
+// can't start, both clients go back to the front of the queues they were in.
+func (mm *MatchmakingService) startClaimedMatch(claimed []*queuedClient, timeControl *models.TimeControl, variant models.Variant) error {
+	matchErr := mm.startMatch(claimed[0].profile, claimed[1].profile, timeControl, variant)
+	if matchErr != nil {
+		mm.requeueClaimed(claimed)
+		return matchErr
+	}
+	for _, client := range claimed {
+		go mm.Dispatch(NewMatchmakingLeftEvent(client.profile.ClientKey, models.MATCHMAKING_LEFT_REASON_MATCHED))
+	}
+	return nil
+}
+
+// requeueClaimed puts claimed clients whose match could not start back at the front of the queues they were in,
+// keeping their place, unless they have since started playing or queued again
+func (mm *MatchmakingService) requeueClaimed(claimed []*queuedClient) {
+	for _, client := range claimed {
+		clientKey := client.profile.ClientKey
+		if mm.isInMatch(clientKey) {
+			continue
+		}
+		if requeueErr := mm.requeue(client.profile, client.queues, client.timeJoined); requeueErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not requeue client %s: %s", clientKey, requeueErr))
+		}
+	}
+}
+
+func (mm *MatchmakingService) startMatch(clientA *models.ClientProfile, clientB *models.ClientProfile, timeControl *models.TimeControl, variant models.Variant) error {
+	allocation := AllocateColours(clientA, clientB, mm.ColourHistory(clientA.ClientKey), mm.ColourHistory(clientB.ClientKey))
+	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("allocated colours: %s", allocation))
+	match := builders.NewMatchBuilder().
+		FromMatch(builders.NewMatch(allocation.WhiteClientKey, allocation.BlackClientKey, timeControl, models.MATCH_RESULT_IN_PROGRESS)).
+		WithVariant(variant).
+		Build()
 	addMatchErr := mm.MatchService.AddMatch(match)
 	if addMatchErr != nil {
 		return fmt.Errorf("error adding match %s: %s", match.Uuid, addMatchErr)
 	}
+	mm.recordColour(allocation.WhiteClientKey, models.COLOUR_WHITE)
+	mm.recordColour(allocation.BlackClientKey, models.COLOUR_BLACK)
+	mm.opponentMemory.RecordPairing(clientA.ClientKey, clientB.ClientKey, time.Now())
 	return nil
 }
+
+func (mm *MatchmakingService) SetAvoidList(clientKey models.Key, avoidedKeys []models.Key) {
+	mm.opponentMemory.SetAvoidList(clientKey, avoidedKeys)
+}
+
+func (mm *MatchmakingService) AvoidList(clientKey models.Key) []models.Key {
+	return mm.opponentMemory.AvoidList(clientKey)
+}
+
+// ForgetClient drops what matchmaking keeps on a disconnected client, like its avoid list and colour history
+func (mm *MatchmakingService) ForgetClient(clientKey models.Key) {
+	mm.opponentMemory.ForgetAvoidList(clientKey)
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	delete(mm.coloursByClientKey, clientKey)
+}
+
+func (mm *MatchmakingService) RecentOpponents(clientKey models.Key) []models.Key {
+	return mm.opponentMemory.RecentOpponents(clientKey)
+}
+
+// claimPair removes both clients from every pool they are queued in under a single lock, so that a client queued in
+// several pools can never be paired twice. They are not reported as matched until their match exists.
+func (mm *MatchmakingService) claimPair(clientAKey, clientBKey models.Key, poolKey string) ([]*queuedClient, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if clientAKey == clientBKey {
+		return nil, fmt.Errorf("cannot pair client %s with itself", clientAKey)
+	}
+	claimed := make([]*queuedClient, 0, 2)
+	for _, clientKey := range []models.Key{clientAKey, clientBKey} {
+		queued := mm.queuedClientByKey(clientKey)
+		if queued == nil {
+			return nil, fmt.Errorf("client %s is no longer in matchmaking", clientKey)
+		}
+		claimed = append(claimed, queued)
+	}
+	if mm.opponentMemory.IsBlocked(clientAKey, clientBKey) {
+		return nil, fmt.Errorf("clients %s and %s avoid each other", clientAKey, clientBKey)
+	}
+
+	if pool := mm.poolByQueueKey[poolKey]; pool != nil {
+		config := mm.Config().(*MatchmakingConfig)
+		for _, clientKey := range []models.Key{clientAKey, clientBKey} {
+			if node := pool.NodeByClientKey(clientKey); node != nil {
+				pool.RecordPairingWait(time.Now().Unix()-node.timeJoined, config.RecentPairingsCount)
+			}
+		}
+	}
+	mm.unqueueClient(clientAKey)
+	mm.unqueueClient(clientBKey)
+	return claimed, nil
+}
+
+// ColourHistory returns the colours the client played in its recent matchmade games, oldest first
+func (mm *MatchmakingService) ColourHistory(clientKey models.Key) []models.Colour {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	history := mm.coloursByClientKey[clientKey]
+	return append(make([]models.Colour, 0, len(history)), history...)
+}
+
+func (mm *MatchmakingService) recordColour(clientKey models.Key, colour models.Colour) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.coloursByClientKey[clientKey] = appendColourHistory(mm.coloursByClientKey[clientKey], colour)
+}
+
+// queueKey separates pools by variant as well as time control, since each variant is rated independently
+func queueKey(timeControl *models.TimeControl, variant models.Variant) string {
+	return string(variant) + ":" + timeControl.Hash()
+}
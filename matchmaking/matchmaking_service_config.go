@@ -2,12 +2,32 @@ package matchmaking
 
 import (
 	"github.com/CameronHonis/service"
+	"time"
 )
 
 type MatchmakingConfig struct {
 	service.ConfigI
+	StatusInterval time.Duration
+	// number of recent pairings per pool used to estimate wait times
+	RecentPairingsCount int
+	Policy              MatchmakingPolicy
+	OpponentMemory      *OpponentMemoryConfig
+	BotFallback         *BotFallbackConfig
+	ReadyCheck          *ReadyCheckConfig
 }
 
 func NewMatchmakingConfig() *MatchmakingConfig {
-	return &MatchmakingConfig{}
+	// NOTE: malformed policy env vars fall back to the default policy, as malformed ports do in the router config
+	var policy MatchmakingPolicy = NewDefaultWindowPolicy()
+	if envPolicy, envErr := WindowPolicyFromEnv(); envErr == nil {
+		policy = envPolicy
+	}
+	return &MatchmakingConfig{
+		StatusInterval:      5 * time.Second,
+		RecentPairingsCount: 20,
+		Policy:              policy,
+		OpponentMemory:      NewOpponentMemoryConfig(),
+		BotFallback:         NewBotFallbackConfig(),
+		ReadyCheck:          NewReadyCheckConfig(),
+	}
 }
@@ -1,26 +1,39 @@
 package matchmaking_test
 
 import (
+	"fmt"
 	"github.com/CameronHonis/chess-arbitrator/builders"
 	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
 	"github.com/CameronHonis/chess-arbitrator/matchmaking"
 	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/CameronHonis/service/test_helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"sync"
+	"time"
 )
 
 func CreateServices(ctrl *gomock.Controller) *matchmaking.MatchmakingService {
 	logServiceMock := mocks.NewMockLoggerServiceI(ctrl)
 	logServiceMock.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+	logServiceMock.EXPECT().LogGreen(gomock.Any(), gomock.Any()).AnyTimes()
+	logServiceMock.EXPECT().LogRed(gomock.Any(), gomock.Any()).AnyTimes()
 	logServiceMock.EXPECT().SetParent(gomock.Any()).AnyTimes()
 
 	matchServiceMock := mocks.NewMockMatcherServiceI(ctrl)
 	matchServiceMock.EXPECT().SetParent(gomock.Any()).AnyTimes()
+	matchServiceMock.EXPECT().MatchByClientKey(gomock.Any()).Return(nil, fmt.Errorf("not in a match")).AnyTimes()
+
+	penaltyServiceMock := mocks.NewMockPenaltyServiceI(ctrl)
+	penaltyServiceMock.EXPECT().SetParent(gomock.Any()).AnyTimes()
+	penaltyServiceMock.EXPECT().CooldownRemaining(gomock.Any()).Return(time.Duration(0)).AnyTimes()
+	penaltyServiceMock.EXPECT().RecordOffence(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 	matchmakingService := matchmaking.NewMatchmakingService(matchmaking.NewMatchmakingConfig())
 	matchmakingService.AddDependency(logServiceMock)
 	matchmakingService.AddDependency(matchServiceMock)
+	matchmakingService.AddDependency(penaltyServiceMock)
 	return matchmakingService
 }
 
@@ -39,27 +52,126 @@ var _ = Describe("MatchmakingService", func() {
 		})
 		When("the client is not already in the pool", func() {
 			It("should add the client to the pool dedicated to the time control", func() {
-				Expect(matchmakingService.AddClient(client, timeControl)).To(Succeed())
-				Expect(matchmakingService.GetClientCountByTimeControl(timeControl)).To(Equal(1))
-				Expect(matchmakingService.GetClientCountByTimeControl(builders.NewBulletTimeControl())).To(Equal(0))
+				Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+				Expect(matchmakingService.GetClientCountByTimeControl(builders.NewBulletTimeControl(), models.VARIANT_STANDARD)).To(Equal(0))
+			})
+			It("should keep the client out of the pools for other variants", func() {
+				Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_CHESS960)).To(Equal(0))
+			})
+		})
+		When("the variant is unknown", func() {
+			It("should return an error", func() {
+				Expect(matchmakingService.AddClient(client, timeControl, "bughouse")).To(HaveOccurred())
+			})
+		})
+		When("the client is on a matchmaking cooldown", func() {
+			BeforeEach(func() {
+				penaltyServiceMock := matchmakingService.PenaltyService.(*mocks.MockPenaltyServiceI)
+				penaltyServiceMock.EXPECT().CooldownRemaining(client.ClientKey).Return(90 * time.Second).AnyTimes()
+			})
+			It("should return an error saying how long the client must wait", func() {
+				err := matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("1m30s"))
 			})
 		})
 		When("the client already exists in a pool", func() {
 			BeforeEach(func() {
-				Expect(matchmakingService.AddClient(client, timeControl)).To(Succeed())
+				Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
 			})
 			Context("and the time control is the same as before", func() {
 				It("should return an error", func() {
-					Expect(matchmakingService.AddClient(client, timeControl)).To(HaveOccurred())
+					Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
 				})
 			})
 			Context("and the time control is different than before", func() {
 				It("should return an error", func() {
-					Expect(matchmakingService.AddClient(client, builders.NewBulletTimeControl())).To(HaveOccurred())
+					Expect(matchmakingService.AddClient(client, builders.NewBulletTimeControl(), models.VARIANT_STANDARD)).To(HaveOccurred())
 				})
 			})
 		})
 	})
+	Describe("::AddClientToQueues", func() {
+		var client *models.ClientProfile
+		var queues []*models.MatchmakingQueue
+		BeforeEach(func() {
+			client = models.NewClientProfile("some-client-key", 1000)
+			queues = []*models.MatchmakingQueue{
+				models.NewMatchmakingQueue(builders.NewBlitzTimeControl(), models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(builders.NewBulletTimeControl(), models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(builders.NewBlitzTimeControl(), models.VARIANT_CHESS960),
+			}
+		})
+		It("adds the client to every pool", func() {
+			Expect(matchmakingService.AddClientToQueues(client, queues)).To(Succeed())
+			for _, queue := range queues {
+				Expect(matchmakingService.GetClientCountByTimeControl(queue.TimeControl, queue.Variant)).To(Equal(1))
+			}
+			statuses, err := matchmakingService.ClientStatuses(client.ClientKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(3))
+		})
+		When("the same queue is given twice", func() {
+			It("joins the pool once", func() {
+				queues = append(queues, models.NewMatchmakingQueue(builders.NewBlitzTimeControl(), models.VARIANT_STANDARD))
+				Expect(matchmakingService.AddClientToQueues(client, queues)).To(Succeed())
+				statuses, err := matchmakingService.ClientStatuses(client.ClientKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveLen(3))
+			})
+		})
+		When("one of the queues has an unknown variant", func() {
+			It("joins none of the pools", func() {
+				queues = append(queues, models.NewMatchmakingQueue(builders.NewRapidTimeControl(), "bughouse"))
+				Expect(matchmakingService.AddClientToQueues(client, queues)).To(HaveOccurred())
+				for _, queue := range queues[:3] {
+					Expect(matchmakingService.GetClientCountByTimeControl(queue.TimeControl, queue.Variant)).To(Equal(0))
+				}
+			})
+		})
+		When("no queues are given", func() {
+			It("returns an error", func() {
+				Expect(matchmakingService.AddClientToQueues(client, nil)).To(HaveOccurred())
+			})
+		})
+		When("the client is removed", func() {
+			It("leaves every pool", func() {
+				Expect(matchmakingService.AddClientToQueues(client, queues)).To(Succeed())
+				Expect(matchmakingService.RemoveClient(client.ClientKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)).To(Succeed())
+				for _, queue := range queues {
+					Expect(matchmakingService.GetClientCountByTimeControl(queue.TimeControl, queue.Variant)).To(Equal(0))
+				}
+			})
+		})
+	})
+	Describe("::JoinMatchmaking", func() {
+		It("queues the client in the time control and variant, and in every extra queue", func() {
+			findMatch := &models.FindMatchMessageContent{
+				TimeControl: builders.NewBlitzTimeControl(),
+				Variant:     models.VARIANT_STANDARD,
+				Queues: []*models.MatchmakingQueue{
+					models.NewMatchmakingQueue(builders.NewBulletTimeControl(), models.VARIANT_STANDARD),
+				},
+				BotFallback: true,
+			}
+			Expect(matchmakingService.JoinMatchmaking("some-client-key", findMatch)).To(Succeed())
+			Expect(matchmakingService.GetClientCountByTimeControl(builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).To(Equal(1))
+			Expect(matchmakingService.GetClientCountByTimeControl(builders.NewBulletTimeControl(), models.VARIANT_STANDARD)).To(Equal(1))
+		})
+		When("the request names a party", func() {
+			It("queues the client within the party instead", func() {
+				party, createErr := matchmakingService.CreateParty("host", models.PARTY_MODE_VS_HOST, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD, false)
+				Expect(createErr).ToNot(HaveOccurred())
+				findMatch := &models.FindMatchMessageContent{PartyCode: party.Code}
+				Expect(matchmakingService.JoinMatchmaking("some-client-key", findMatch)).To(Succeed())
+				updatedParty, _ := matchmakingService.Party(party.Code)
+				Expect(updatedParty.WaitingKeys).To(ConsistOf(models.Key("some-client-key")))
+				Expect(matchmakingService.PoolSizes()).To(BeEmpty())
+			})
+		})
+	})
 	Describe("::RemoveClient", func() {
 		var client *models.ClientProfile
 		var timeControl *models.TimeControl
@@ -69,17 +181,360 @@ var _ = Describe("MatchmakingService", func() {
 		})
 		When("the client is in the pool", func() {
 			BeforeEach(func() {
-				Expect(matchmakingService.AddClient(client, timeControl)).To(Succeed())
-				Expect(matchmakingService.GetClientCountByTimeControl(timeControl)).To(Equal(1))
+				Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
 			})
 			It("removes the client from the pool", func() {
-				Expect(matchmakingService.RemoveClient(client.ClientKey)).To(Succeed())
-				Expect(matchmakingService.GetClientCountByTimeControl(timeControl)).To(Equal(0))
+				Expect(matchmakingService.RemoveClient(client.ClientKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)).To(Succeed())
+				Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(0))
+			})
+		})
+		When("the client's connection drops", func() {
+			It("reports why the client left", func() {
+				eventCatcher := NewEventCatcher()
+				eventCatcher.AddDependency(matchmakingService)
+				Expect(matchmakingService.AddClient(client, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.RemoveClient(client.ClientKey, models.MATCHMAKING_LEFT_REASON_DISCONNECTED)).To(Succeed())
+				Eventually(func() int {
+					return eventCatcher.EventsByVariantCount(matchmaking.MATCHMAKING_LEFT)
+				}).Should(Equal(1))
+				payload := eventCatcher.LastEventByVariant(matchmaking.MATCHMAKING_LEFT).Payload().(*matchmaking.MatchmakingLeftEventPayload)
+				Expect(payload.Reason).To(Equal(models.MATCHMAKING_LEFT_REASON_DISCONNECTED))
 			})
 		})
 		When("the client is not in the pool", func() {
 			It("returns an error", func() {
-				Expect(matchmakingService.RemoveClient(client.ClientKey)).To(HaveOccurred())
+				Expect(matchmakingService.RemoveClient(client.ClientKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)).To(HaveOccurred())
+			})
+		})
+	})
+	Describe("::PoolSizes", func() {
+		It("counts the clients waiting in each pool", func() {
+			blitz := builders.NewBlitzTimeControl()
+			bullet := builders.NewBulletTimeControl()
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-a", 1000), blitz, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-b", 1000), bullet, models.VARIANT_CHESS960)).To(Succeed())
+			Expect(matchmakingService.PoolSizes()).To(ConsistOf(
+				&models.PoolSize{TimeControl: blitz, Variant: models.VARIANT_STANDARD, ClientCount: 1},
+				&models.PoolSize{TimeControl: bullet, Variant: models.VARIANT_CHESS960, ClientCount: 1},
+			))
+		})
+		It("leaves out pools that have emptied", func() {
+			client := models.NewClientProfile("client-a", 1000)
+			Expect(matchmakingService.AddClient(client, builders.NewBlitzTimeControl(), models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.RemoveClient(client.ClientKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)).To(Succeed())
+			Expect(matchmakingService.PoolSizes()).To(BeEmpty())
+		})
+	})
+	Describe("::MatchClient", func() {
+		var clientA, clientB *models.ClientProfile
+		var timeControl *models.TimeControl
+		BeforeEach(func() {
+			clientA = models.NewClientProfile("client-a", 1000)
+			clientB = models.NewClientProfile("client-b", 1000)
+			timeControl = builders.NewBlitzTimeControl()
+			matchServiceMock := matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(nil).AnyTimes()
+		})
+		It("records the colours each client was given", func() {
+			Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			historyA := matchmakingService.ColourHistory(clientA.ClientKey)
+			historyB := matchmakingService.ColourHistory(clientB.ClientKey)
+			Expect(historyA).To(HaveLen(1))
+			Expect(historyB).To(Equal([]models.Colour{historyA[0].Opposite()}))
+		})
+		It("keeps colours balanced between repeat opponents", func() {
+			for i := 0; i < 6; i++ {
+				Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			}
+			historyA := matchmakingService.ColourHistory(clientA.ClientKey)
+			Expect(historyA).To(HaveLen(6))
+			whiteCount := 0
+			for i, colour := range historyA {
+				if colour == models.COLOUR_WHITE {
+					whiteCount++
+				}
+				if i%2 == 1 {
+					Expect(colour).To(Equal(historyA[i-1].Opposite()))
+				}
+			}
+			Expect(whiteCount).To(Equal(3))
+		})
+		When("the match can't start", func() {
+			var matchServiceMock *mocks.MockMatcherServiceI
+			var eventCatcher *EventCatcher
+			BeforeEach(func() {
+				matchServiceMock = matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+				matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(fmt.Errorf("client is already in a match")).AnyTimes()
+				eventCatcher = NewEventCatcher()
+				eventCatcher.AddDependency(matchmakingService)
+				Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1000), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			})
+			It("returns both clients to the front of the queue without reporting them as matched", func() {
+				Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
+				for _, clientKey := range []models.Key{clientA.ClientKey, clientB.ClientKey} {
+					statuses, err := matchmakingService.ClientStatuses(clientKey)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(statuses).To(HaveLen(1))
+					Expect(statuses[0].QueuePosition).To(BeNumerically("<=", 2))
+				}
+				Consistently(func() int {
+					return eventCatcher.EventsByVariantCount(matchmaking.MATCHMAKING_LEFT)
+				}, 50*time.Millisecond).Should(Equal(0))
+			})
+			It("leaves out a client that has started playing since", func() {
+				matchServiceMock.EXPECT().MatchByClientKey(gomock.Any()).DoAndReturn(func(clientKey models.Key) (*models.Match, error) {
+					if clientKey == clientB.ClientKey {
+						return builders.NewMatch(clientB.ClientKey, "client-d", timeControl, models.MATCH_RESULT_IN_PROGRESS), nil
+					}
+					return nil, fmt.Errorf("not in a match")
+				}).AnyTimes()
+				Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
+				statuses, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses[0].QueuePosition).To(Equal(1))
+				_, err = matchmakingService.ClientStatuses(clientB.ClientKey)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		It("reports both clients as matched once the match starts", func() {
+			eventCatcher := NewEventCatcher()
+			eventCatcher.AddDependency(matchmakingService)
+			Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.MATCHMAKING_LEFT)
+			}).Should(Equal(2))
+			payload := eventCatcher.LastEventByVariant(matchmaking.MATCHMAKING_LEFT).Payload().(*matchmaking.MatchmakingLeftEventPayload)
+			Expect(payload.Reason).To(Equal(models.MATCHMAKING_LEFT_REASON_MATCHED))
+		})
+	})
+	Describe("::MatchClient across several pools", func() {
+		var clientA, clientB, clientC *models.ClientProfile
+		var blitz, bullet *models.TimeControl
+		BeforeEach(func() {
+			clientA = models.NewClientProfile("client-a", 1000)
+			clientB = models.NewClientProfile("client-b", 1000)
+			clientC = models.NewClientProfile("client-c", 1000)
+			blitz = builders.NewBlitzTimeControl()
+			bullet = builders.NewBulletTimeControl()
+			matchServiceMock := matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(nil).AnyTimes()
+			Expect(matchmakingService.AddClientToQueues(clientA, []*models.MatchmakingQueue{
+				models.NewMatchmakingQueue(blitz, models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(bullet, models.VARIANT_STANDARD),
+			})).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, blitz, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientC, bullet, models.VARIANT_STANDARD)).To(Succeed())
+		})
+		It("removes the paired client from the other pools", func() {
+			Expect(matchmakingService.MatchClient(clientA, clientB, blitz, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.GetClientCountByTimeControl(blitz, models.VARIANT_STANDARD)).To(Equal(0))
+			Expect(matchmakingService.GetClientCountByTimeControl(bullet, models.VARIANT_STANDARD)).To(Equal(1))
+			_, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+			Expect(err).To(HaveOccurred())
+		})
+		It("refuses to pair a client that has already been paired", func() {
+			Expect(matchmakingService.MatchClient(clientA, clientB, blitz, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.MatchClient(clientA, clientC, bullet, models.VARIANT_STANDARD)).To(HaveOccurred())
+		})
+		It("pairs the client only once when both pools pair them at the same time", func() {
+			results := make(chan error, 2)
+			go func() { results <- matchmakingService.MatchClient(clientA, clientB, blitz, models.VARIANT_STANDARD) }()
+			go func() { results <- matchmakingService.MatchClient(clientA, clientC, bullet, models.VARIANT_STANDARD) }()
+			errs := []error{<-results, <-results}
+			successCount := 0
+			for _, err := range errs {
+				if err == nil {
+					successCount++
+				}
+			}
+			Expect(successCount).To(Equal(1))
+		})
+	})
+	Describe("::RunMatchmakingRound", func() {
+		var matchedKeys chan models.Key
+		BeforeEach(func() {
+			matchedKeys = make(chan models.Key, 1000)
+			matchServiceMock := matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).DoAndReturn(func(match *models.Match) error {
+				matchedKeys <- match.WhiteClientKey
+				matchedKeys <- match.BlackClientKey
+				return nil
+			}).AnyTimes()
+		})
+		It("pairs matchable clients and leaves the rest queued", func() {
+			timeControl := builders.NewBlitzTimeControl()
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-a", 1000), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-b", 1600), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1020), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			matchmakingService.RunMatchmakingRound()
+			Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(1))
+			Expect([]models.Key{<-matchedKeys, <-matchedKeys}).To(ConsistOf(models.Key("client-a"), models.Key("client-c")))
+		})
+		It("pairs a client queued in several pools with its closest partner across all of them", func() {
+			blitz := builders.NewBlitzTimeControl()
+			bullet := builders.NewBulletTimeControl()
+			Expect(matchmakingService.AddClientToQueues(models.NewClientProfile("client-a", 1000), []*models.MatchmakingQueue{
+				models.NewMatchmakingQueue(blitz, models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(bullet, models.VARIANT_STANDARD),
+			})).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-b", 1040), blitz, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1010), bullet, models.VARIANT_STANDARD)).To(Succeed())
+			matchmakingService.RunMatchmakingRound()
+			Expect([]models.Key{<-matchedKeys, <-matchedKeys}).To(ConsistOf(models.Key("client-a"), models.Key("client-c")))
+			Expect(matchmakingService.GetClientCountByTimeControl(blitz, models.VARIANT_STANDARD)).To(Equal(1))
+			Expect(matchmakingService.GetClientCountByTimeControl(bullet, models.VARIANT_STANDARD)).To(Equal(0))
+		})
+		It("never pairs a client twice while clients join, leave and get paired concurrently", func() {
+			queues := []*models.MatchmakingQueue{
+				models.NewMatchmakingQueue(builders.NewBlitzTimeControl(), models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(builders.NewBulletTimeControl(), models.VARIANT_STANDARD),
+				models.NewMatchmakingQueue(builders.NewBlitzTimeControl(), models.VARIANT_CHESS960),
+			}
+			var wg sync.WaitGroup
+			for worker := 0; worker < 4; worker++ {
+				worker := worker
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						clientKey := models.Key(fmt.Sprintf("client-%d-%d", worker, i))
+						client := models.NewClientProfile(clientKey, 1000+(i%5)*10)
+						_ = matchmakingService.AddClientToQueues(client, queues[:1+i%len(queues)])
+						if i%7 == 0 {
+							_ = matchmakingService.RemoveClient(clientKey, models.MATCHMAKING_LEFT_REASON_REQUESTED)
+						}
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 20; i++ {
+						matchmakingService.RunMatchmakingRound()
+					}
+				}()
+			}
+			wg.Wait()
+			matchmakingService.RunMatchmakingRound()
+			close(matchedKeys)
+
+			isMatched := make(map[models.Key]bool)
+			for clientKey := range matchedKeys {
+				Expect(isMatched[clientKey]).To(BeFalse(), "client %s paired twice", clientKey)
+				isMatched[clientKey] = true
+			}
+			Expect(isMatched).ToNot(BeEmpty())
+		})
+	})
+	Describe("::SetAvoidList", func() {
+		var clientA, clientB *models.ClientProfile
+		var timeControl *models.TimeControl
+		BeforeEach(func() {
+			clientA = models.NewClientProfile("client-a", 1000)
+			clientB = models.NewClientProfile("client-b", 1000)
+			timeControl = builders.NewBlitzTimeControl()
+			matchmakingService.SetAvoidList(clientB.ClientKey, []models.Key{clientA.ClientKey})
+			Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+		})
+		It("keeps the avoided client out of matchmaking rounds", func() {
+			matchmakingService.RunMatchmakingRound()
+			Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(2))
+		})
+		It("refuses to match the clients directly", func() {
+			Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
+			Expect(matchmakingService.GetClientCountByTimeControl(timeControl, models.VARIANT_STANDARD)).To(Equal(2))
+		})
+		It("reports the avoid list", func() {
+			Expect(matchmakingService.AvoidList(clientB.ClientKey)).To(Equal([]models.Key{clientA.ClientKey}))
+		})
+	})
+	Describe("::ForgetClient", func() {
+		var clientA, clientB *models.ClientProfile
+		var timeControl *models.TimeControl
+		BeforeEach(func() {
+			clientA = models.NewClientProfile("client-a", 1000)
+			clientB = models.NewClientProfile("client-b", 1000)
+			timeControl = builders.NewBlitzTimeControl()
+			matchServiceMock := matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(nil).AnyTimes()
+			Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			matchmakingService.SetAvoidList(clientA.ClientKey, []models.Key{"client-c"})
+		})
+		It("drops the client's avoid list and colour history", func() {
+			matchmakingService.ForgetClient(clientA.ClientKey)
+			Expect(matchmakingService.AvoidList(clientA.ClientKey)).To(BeEmpty())
+			Expect(matchmakingService.ColourHistory(clientA.ClientKey)).To(BeEmpty())
+		})
+		It("leaves other clients' colour history alone", func() {
+			matchmakingService.ForgetClient(clientA.ClientKey)
+			Expect(matchmakingService.ColourHistory(clientB.ClientKey)).To(HaveLen(1))
+		})
+	})
+	Describe("::ClientStatuses", func() {
+		var clientA, clientB *models.ClientProfile
+		var timeControl *models.TimeControl
+		BeforeEach(func() {
+			clientA = models.NewClientProfile("client-a", 1000)
+			clientB = models.NewClientProfile("client-b", 1000)
+			timeControl = builders.NewBlitzTimeControl()
+			Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+		})
+		It("reports the client's place in the queue and the pool size", func() {
+			statuses, err := matchmakingService.ClientStatuses(clientB.ClientKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			status := statuses[0]
+			Expect(status.QueuePosition).To(Equal(2))
+			Expect(status.PoolSize).To(Equal(2))
+		})
+		It("reports the current elo window", func() {
+			statuses, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			status := statuses[0]
+			Expect(status.MinElo).To(Equal(950))
+			Expect(status.MaxElo).To(Equal(1050))
+		})
+		When("no pairings have been made in the pool", func() {
+			It("has no wait estimate", func() {
+				statuses, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveLen(1))
+				status := statuses[0]
+				Expect(status.EstimatedWaitSec).To(BeNil())
+			})
+		})
+		When("a pairing has been made in the pool", func() {
+			BeforeEach(func() {
+				matchServiceMock := matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+				matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(nil).AnyTimes()
+				Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+				Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1000), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			})
+			It("estimates the wait from recent pairings", func() {
+				statuses, err := matchmakingService.ClientStatuses("client-c")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveLen(1))
+				status := statuses[0]
+				Expect(status.QueuePosition).To(Equal(1))
+				Expect(status.EstimatedWaitSec).ToNot(BeNil())
+			})
+		})
+		When("the client is not queued", func() {
+			It("returns an error", func() {
+				_, err := matchmakingService.ClientStatuses("some-other-client")
+				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
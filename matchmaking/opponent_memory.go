@@ -0,0 +1,175 @@
+package matchmaking
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"sync"
+	"time"
+)
+
+// PairingRestrictions vetoes or penalises pairings on grounds other than rating
+type PairingRestrictions interface {
+	// PairingPenalty is the extra cost of pairing the two clients, or false if they must not be paired yet
+	PairingPenalty(clientAKey models.Key, clientBKey models.Key, longestWaitSecs int64) (float64, bool)
+}
+
+type recentOpponent struct {
+	clientKey models.Key
+	pairedAt  time.Time
+}
+
+// OpponentMemory remembers each client's recent opponents and the clients they never want to be paired with.
+// Recent opponents are only re-paired once one of them has waited RepeatWaitSecs, and even then other candidates
+// are preferred. Avoided clients are never paired, whichever side of the pair did the avoiding. Clients are forgotten
+// once their last opponent ages out of the window, and their avoid list once they disconnect.
+type OpponentMemory struct {
+	config                     *OpponentMemoryConfig
+	recentOpponentsByClientKey map[models.Key][]recentOpponent
+	avoidedKeysByClientKey     map[models.Key]map[models.Key]bool
+	mu                         sync.Mutex
+}
+
+type OpponentMemoryConfig struct {
+	// number of recent opponents remembered per client
+	RecentOpponentsCount int
+	// opponents older than this are forgotten
+	RecentOpponentsWindow time.Duration
+	// how long the longest waiting client must have waited before a recent opponent is allowed again
+	RepeatWaitSecs int64
+	// extra pairing cost (in elo, under the default policy) of a repeat pairing
+	RepeatPenalty float64
+}
+
+func NewOpponentMemoryConfig() *OpponentMemoryConfig {
+	return &OpponentMemoryConfig{
+		RecentOpponentsCount:  3,
+		RecentOpponentsWindow: 30 * time.Minute,
+		RepeatWaitSecs:        60,
+		RepeatPenalty:         100,
+	}
+}
+
+func NewOpponentMemory(config *OpponentMemoryConfig) *OpponentMemory {
+	return &OpponentMemory{
+		config:                     config,
+		recentOpponentsByClientKey: make(map[models.Key][]recentOpponent),
+		avoidedKeysByClientKey:     make(map[models.Key]map[models.Key]bool),
+	}
+}
+
+func (om *OpponentMemory) RecordPairing(clientAKey models.Key, clientBKey models.Key, pairedAt time.Time) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	om.forgetExpired()
+	om.recordOpponent(clientAKey, clientBKey, pairedAt)
+	om.recordOpponent(clientBKey, clientAKey, pairedAt)
+}
+
+func (om *OpponentMemory) recordOpponent(clientKey models.Key, opponentKey models.Key, pairedAt time.Time) {
+	opponents := append(om.recentOpponentsByClientKey[clientKey], recentOpponent{clientKey: opponentKey, pairedAt: pairedAt})
+	if len(opponents) > om.config.RecentOpponentsCount {
+		opponents = opponents[len(opponents)-om.config.RecentOpponentsCount:]
+	}
+	om.recentOpponentsByClientKey[clientKey] = opponents
+}
+
+// forgetExpired drops every client whose recent opponents have all aged out of the window, so clients that never
+// come back aren't remembered forever. Callers must hold om.mu.
+func (om *OpponentMemory) forgetExpired() {
+	cutoff := time.Now().Add(-om.config.RecentOpponentsWindow)
+	for clientKey := range om.recentOpponentsByClientKey {
+		if len(om.unexpiredOpponents(clientKey, cutoff)) == 0 {
+			delete(om.recentOpponentsByClientKey, clientKey)
+		}
+	}
+}
+
+// unexpiredOpponents callers must hold om.mu
+func (om *OpponentMemory) unexpiredOpponents(clientKey models.Key, cutoff time.Time) []models.Key {
+	opponentKeys := make([]models.Key, 0)
+	for _, opponent := range om.recentOpponentsByClientKey[clientKey] {
+		if opponent.pairedAt.After(cutoff) {
+			opponentKeys = append(opponentKeys, opponent.clientKey)
+		}
+	}
+	return opponentKeys
+}
+
+// RecentOpponents lists the client's remembered opponents, oldest first
+func (om *OpponentMemory) RecentOpponents(clientKey models.Key) []models.Key {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	opponentKeys := om.unexpiredOpponents(clientKey, time.Now().Add(-om.config.RecentOpponentsWindow))
+	if len(opponentKeys) == 0 {
+		delete(om.recentOpponentsByClientKey, clientKey)
+	}
+	return opponentKeys
+}
+
+// RememberedClientCount is how many clients have recent opponents on record
+func (om *OpponentMemory) RememberedClientCount() int {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	return len(om.recentOpponentsByClientKey)
+}
+
+func (om *OpponentMemory) IsRecentOpponent(clientKey models.Key, opponentKey models.Key) bool {
+	for _, recentKey := range om.RecentOpponents(clientKey) {
+		if recentKey == opponentKey {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAvoidList replaces the clients the client never wants to be paired with
+func (om *OpponentMemory) SetAvoidList(clientKey models.Key, avoidedKeys []models.Key) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	if len(avoidedKeys) == 0 {
+		delete(om.avoidedKeysByClientKey, clientKey)
+		return
+	}
+	avoidedKeySet := make(map[models.Key]bool)
+	for _, avoidedKey := range avoidedKeys {
+		if avoidedKey != clientKey {
+			avoidedKeySet[avoidedKey] = true
+		}
+	}
+	om.avoidedKeysByClientKey[clientKey] = avoidedKeySet
+}
+
+// ForgetAvoidList drops the client's avoid list, for clients that have disconnected
+func (om *OpponentMemory) ForgetAvoidList(clientKey models.Key) {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	delete(om.avoidedKeysByClientKey, clientKey)
+}
+
+func (om *OpponentMemory) AvoidList(clientKey models.Key) []models.Key {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	avoidedKeys := make([]models.Key, 0, len(om.avoidedKeysByClientKey[clientKey]))
+	for avoidedKey := range om.avoidedKeysByClientKey[clientKey] {
+		avoidedKeys = append(avoidedKeys, avoidedKey)
+	}
+	return avoidedKeys
+}
+
+func (om *OpponentMemory) IsBlocked(clientAKey models.Key, clientBKey models.Key) bool {
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	return om.avoidedKeysByClientKey[clientAKey][clientBKey] || om.avoidedKeysByClientKey[clientBKey][clientAKey]
+}
+
+func (om *OpponentMemory) PairingPenalty(clientAKey models.Key, clientBKey models.Key, longestWaitSecs int64) (float64, bool) {
+	if om.IsBlocked(clientAKey, clientBKey) {
+		return 0, false
+	}
+	if !om.IsRecentOpponent(clientAKey, clientBKey) {
+		return 0, true
+	}
+	if longestWaitSecs < om.config.RepeatWaitSecs {
+		return 0, false
+	}
+	return om.config.RepeatPenalty, true
+}
@@ -0,0 +1,126 @@
+package matchmaking_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("OpponentMemory", func() {
+	var config *matchmaking.OpponentMemoryConfig
+	var memory *matchmaking.OpponentMemory
+	BeforeEach(func() {
+		config = matchmaking.NewOpponentMemoryConfig()
+		memory = matchmaking.NewOpponentMemory(config)
+	})
+	Describe("::RecentOpponents", func() {
+		It("remembers opponents from both sides", func() {
+			memory.RecordPairing("client-a", "client-b", time.Now())
+			Expect(memory.RecentOpponents("client-a")).To(Equal([]models.Key{"client-b"}))
+			Expect(memory.RecentOpponents("client-b")).To(Equal([]models.Key{"client-a"}))
+		})
+		It("forgets all but the most recent opponents", func() {
+			for _, opponentKey := range []models.Key{"client-b", "client-c", "client-d", "client-e"} {
+				memory.RecordPairing("client-a", opponentKey, time.Now())
+			}
+			Expect(memory.RecentOpponents("client-a")).To(Equal([]models.Key{"client-c", "client-d", "client-e"}))
+		})
+		It("forgets opponents paired before the window", func() {
+			memory.RecordPairing("client-a", "client-b", time.Now().Add(-2*config.RecentOpponentsWindow))
+			Expect(memory.RecentOpponents("client-a")).To(BeEmpty())
+		})
+		It("forgets clients whose opponents have all aged out, even if they never come back", func() {
+			memory.RecordPairing("client-a", "client-b", time.Now().Add(-2*config.RecentOpponentsWindow))
+			Expect(memory.RememberedClientCount()).To(Equal(2))
+			memory.RecordPairing("client-c", "client-d", time.Now())
+			Expect(memory.RememberedClientCount()).To(Equal(2))
+			Expect(memory.RecentOpponents("client-c")).To(Equal([]models.Key{"client-d"}))
+		})
+	})
+	Describe("::ForgetAvoidList", func() {
+		It("allows the pairing again", func() {
+			memory.SetAvoidList("client-a", []models.Key{"client-b"})
+			memory.ForgetAvoidList("client-a")
+			Expect(memory.AvoidList("client-a")).To(BeEmpty())
+			Expect(memory.IsBlocked("client-a", "client-b")).To(BeFalse())
+		})
+	})
+	Describe("::PairingPenalty", func() {
+		When("the clients have not played recently", func() {
+			It("allows the pairing without a penalty", func() {
+				penalty, isAllowed := memory.PairingPenalty("client-a", "client-b", 0)
+				Expect(isAllowed).To(BeTrue())
+				Expect(penalty).To(BeZero())
+			})
+		})
+		When("the clients have played recently", func() {
+			BeforeEach(func() {
+				memory.RecordPairing("client-a", "client-b", time.Now())
+			})
+			It("disallows the pairing until the repeat wait has passed", func() {
+				_, isAllowed := memory.PairingPenalty("client-a", "client-b", config.RepeatWaitSecs-1)
+				Expect(isAllowed).To(BeFalse())
+			})
+			It("allows the pairing with a penalty after the repeat wait", func() {
+				penalty, isAllowed := memory.PairingPenalty("client-b", "client-a", config.RepeatWaitSecs)
+				Expect(isAllowed).To(BeTrue())
+				Expect(penalty).To(Equal(config.RepeatPenalty))
+			})
+		})
+		When("one client avoids the other", func() {
+			BeforeEach(func() {
+				memory.SetAvoidList("client-a", []models.Key{"client-b"})
+			})
+			It("never allows the pairing, from either side", func() {
+				_, isAllowed := memory.PairingPenalty("client-a", "client-b", 100000)
+				Expect(isAllowed).To(BeFalse())
+				_, isAllowed = memory.PairingPenalty("client-b", "client-a", 100000)
+				Expect(isAllowed).To(BeFalse())
+			})
+			It("allows the pairing again once the avoid list is cleared", func() {
+				memory.SetAvoidList("client-a", nil)
+				_, isAllowed := memory.PairingPenalty("client-a", "client-b", 0)
+				Expect(isAllowed).To(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("PairGreedily with restrictions", func() {
+	var policy *matchmaking.WindowPolicy
+	var memory *matchmaking.OpponentMemory
+	BeforeEach(func() {
+		policy = matchmaking.NewDefaultWindowPolicy()
+		memory = matchmaking.NewOpponentMemory(matchmaking.NewOpponentMemoryConfig())
+		memory.RecordPairing("client-a", "client-b", time.Now())
+	})
+	It("prefers a new opponent over a recent one", func() {
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("client-a", 1000, 120),
+			newCandidate("client-b", 1000, 120),
+			newCandidate("client-c", 1040, 120),
+		}
+		pairings := matchmaking.PairGreedily(candidates, policy, memory)
+		Expect(pairings).To(HaveLen(1))
+		Expect(pairings[0].ClientB.ClientKey).To(Equal(models.Key("client-c")))
+	})
+	It("pairs recent opponents again after a longer wait when no one else is available", func() {
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("client-a", 1000, 5),
+			newCandidate("client-b", 1000, 5),
+		}
+		Expect(matchmaking.PairGreedily(candidates, policy, memory)).To(BeEmpty())
+		candidates[0].WaitSecs = 120
+		Expect(matchmaking.PairGreedily(candidates, policy, memory)).To(HaveLen(1))
+	})
+	It("never pairs blocked clients", func() {
+		memory.SetAvoidList("client-c", []models.Key{"client-d"})
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("client-c", 1000, 100000),
+			newCandidate("client-d", 1000, 100000),
+		}
+		Expect(matchmaking.PairGreedily(candidates, policy, memory)).To(BeEmpty())
+	})
+})
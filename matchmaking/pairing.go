@@ -0,0 +1,93 @@
+package matchmaking
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"sort"
+)
+
+// PairingCandidate is a client as they stood in a pool when the pool was snapshotted
+type PairingCandidate struct {
+	ClientProfile *models.ClientProfile
+	TimeControl   *models.TimeControl
+	Variant       models.Variant
+	WaitSecs      int64
+}
+
+func (c *PairingCandidate) isSamePool(other *PairingCandidate) bool {
+	return queueKey(c.TimeControl, c.Variant.OrStandard()) == queueKey(other.TimeControl, other.Variant.OrStandard())
+}
+
+type Pairing struct {
+	ClientA     *models.ClientProfile
+	ClientB     *models.ClientProfile
+	TimeControl *models.TimeControl
+	Variant     models.Variant
+}
+
+type candidateEdge struct {
+	a, b     int
+	cost     float64
+	waitSecs int64
+}
+
+// PairGreedily pairs candidates drawn from any number of pools, aiming to minimise the total pairing cost (the elo gap,
+// under the default policy) across every pairing. Only candidates from the same pool are paired, but a client queued
+// in several pools competes for a partner in all of them at once and is paired at most once, wherever its cheapest
+// pairing lies. Matchable pairs are taken cheapest first, with ties going to whoever has waited longest. Each pool's
+// candidates are expected oldest first. Restrictions may be nil.
+func PairGreedily(candidates []*PairingCandidate, policy MatchmakingPolicy, restrictions PairingRestrictions) []*Pairing {
+	edges := make([]candidateEdge, 0)
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			a, b := candidates[i], candidates[j]
+			if a.ClientProfile.ClientKey == b.ClientProfile.ClientKey || !a.isSamePool(b) {
+				continue
+			}
+			longestWaitSecs := a.WaitSecs
+			if b.WaitSecs > longestWaitSecs {
+				longestWaitSecs = b.WaitSecs
+			}
+			if !policy.IsMatchable(a.ClientProfile, b.ClientProfile, longestWaitSecs) {
+				continue
+			}
+			var penalty float64
+			if restrictions != nil {
+				var isAllowed bool
+				penalty, isAllowed = restrictions.PairingPenalty(a.ClientProfile.ClientKey, b.ClientProfile.ClientKey, longestWaitSecs)
+				if !isAllowed {
+					continue
+				}
+			}
+			edges = append(edges, candidateEdge{
+				a:        i,
+				b:        j,
+				cost:     policy.PairingCost(a.ClientProfile, b.ClientProfile) + penalty,
+				waitSecs: longestWaitSecs,
+			})
+		}
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].cost != edges[j].cost {
+			return edges[i].cost < edges[j].cost
+		}
+		return edges[i].waitSecs > edges[j].waitSecs
+	})
+
+	isPaired := make(map[models.Key]bool)
+	pairings := make([]*Pairing, 0)
+	for _, edge := range edges {
+		a, b := candidates[edge.a], candidates[edge.b]
+		if isPaired[a.ClientProfile.ClientKey] || isPaired[b.ClientProfile.ClientKey] {
+			continue
+		}
+		isPaired[a.ClientProfile.ClientKey] = true
+		isPaired[b.ClientProfile.ClientKey] = true
+		pairings = append(pairings, &Pairing{
+			ClientA:     a.ClientProfile,
+			ClientB:     b.ClientProfile,
+			TimeControl: a.TimeControl,
+			Variant:     a.Variant,
+		})
+	}
+	return pairings
+}
@@ -0,0 +1,160 @@
+package matchmaking_test
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"math/rand"
+)
+
+func newCandidate(clientKey models.Key, elo int, waitSecs int64) *matchmaking.PairingCandidate {
+	return &matchmaking.PairingCandidate{
+		ClientProfile: models.NewClientProfile(clientKey, elo),
+		TimeControl:   builders.NewBlitzTimeControl(),
+		Variant:       models.VARIANT_STANDARD,
+		WaitSecs:      waitSecs,
+	}
+}
+
+func totalEloGap(pairings []*matchmaking.Pairing) int {
+	total := 0
+	for _, pairing := range pairings {
+		gap := pairing.ClientA.Elo - pairing.ClientB.Elo
+		if gap < 0 {
+			gap = -gap
+		}
+		total += gap
+	}
+	return total
+}
+
+var _ = Describe("PairGreedily", func() {
+	var policy *matchmaking.WindowPolicy
+	BeforeEach(func() {
+		policy = matchmaking.NewDefaultWindowPolicy()
+	})
+	When("there are fewer than two candidates", func() {
+		It("pairs no one", func() {
+			Expect(matchmaking.PairGreedily(nil, policy, nil)).To(BeEmpty())
+			Expect(matchmaking.PairGreedily([]*matchmaking.PairingCandidate{newCandidate("a", 1000, 0)}, policy, nil)).To(BeEmpty())
+		})
+	})
+	When("no candidates are matchable", func() {
+		It("pairs no one", func() {
+			candidates := []*matchmaking.PairingCandidate{newCandidate("a", 1000, 0), newCandidate("b", 1500, 0)}
+			Expect(matchmaking.PairGreedily(candidates, policy, nil)).To(BeEmpty())
+		})
+	})
+	It("pairs the closest ratings rather than the longest waiting client's nearest partner", func() {
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("a", 1000, 10),
+			newCandidate("b", 1030, 5),
+			newCandidate("c", 1040, 0),
+		}
+		pairings := matchmaking.PairGreedily(candidates, policy, nil)
+		Expect(pairings).To(HaveLen(1))
+		Expect([]models.Key{pairings[0].ClientA.ClientKey, pairings[0].ClientB.ClientKey}).To(ConsistOf(models.Key("b"), models.Key("c")))
+	})
+	It("pairs everyone it can across the pool", func() {
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("a", 1000, 0),
+			newCandidate("b", 1100, 0),
+			newCandidate("c", 1040, 0),
+			newCandidate("d", 1150, 0),
+		}
+		pairings := matchmaking.PairGreedily(candidates, policy, nil)
+		Expect(pairings).To(HaveLen(2))
+		Expect(totalEloGap(pairings)).To(Equal(90))
+	})
+	It("only pairs candidates from the same pool", func() {
+		bulletCandidate := newCandidate("b", 1000, 0)
+		bulletCandidate.TimeControl = builders.NewBulletTimeControl()
+		candidates := []*matchmaking.PairingCandidate{newCandidate("a", 1000, 0), bulletCandidate}
+		Expect(matchmaking.PairGreedily(candidates, policy, nil)).To(BeEmpty())
+	})
+	It("pairs a client queued in several pools once, in the pool with its cheapest pairing", func() {
+		bulletCandidate := newCandidate("a", 1000, 0)
+		bulletCandidate.TimeControl = builders.NewBulletTimeControl()
+		bulletPartner := newCandidate("c", 1010, 0)
+		bulletPartner.TimeControl = builders.NewBulletTimeControl()
+		candidates := []*matchmaking.PairingCandidate{
+			newCandidate("a", 1000, 0),
+			newCandidate("b", 1040, 0),
+			bulletCandidate,
+			bulletPartner,
+		}
+		pairings := matchmaking.PairGreedily(candidates, policy, nil)
+		Expect(pairings).To(HaveLen(1))
+		Expect([]models.Key{pairings[0].ClientA.ClientKey, pairings[0].ClientB.ClientKey}).To(ConsistOf(models.Key("a"), models.Key("c")))
+		Expect(pairings[0].TimeControl).To(Equal(builders.NewBulletTimeControl()))
+	})
+	Describe("properties", func() {
+		for seed := int64(0); seed < 50; seed++ {
+			seed := seed
+			It(fmt.Sprintf("holds for random pool %d", seed), func() {
+				rng := rand.New(rand.NewSource(seed))
+				timeControls := []*models.TimeControl{builders.NewBlitzTimeControl(), builders.NewBulletTimeControl()}
+				candidates := make([]*matchmaking.PairingCandidate, 0)
+				for i := rng.Intn(40); i > 0; i-- {
+					candidate := newCandidate(models.Key(fmt.Sprintf("client-%d", i)), 800+rng.Intn(800), int64(rng.Intn(300)))
+					candidate.TimeControl = timeControls[rng.Intn(len(timeControls))]
+					candidates = append(candidates, candidate)
+					if rng.Intn(3) == 0 {
+						// also queued in the other pool
+						otherPoolCandidate := *candidate
+						otherPoolCandidate.TimeControl = builders.NewBulletTimeControl()
+						if candidate.TimeControl.Hash() == otherPoolCandidate.TimeControl.Hash() {
+							otherPoolCandidate.TimeControl = builders.NewBlitzTimeControl()
+						}
+						candidates = append(candidates, &otherPoolCandidate)
+					}
+				}
+				waitByKey := make(map[models.Key]int64)
+				for _, candidate := range candidates {
+					waitByKey[candidate.ClientProfile.ClientKey] = candidate.WaitSecs
+				}
+
+				pairings := matchmaking.PairGreedily(candidates, policy, nil)
+
+				By("pairing no client twice")
+				isPaired := make(map[models.Key]bool)
+				for _, pairing := range pairings {
+					Expect(pairing.ClientA.ClientKey).ToNot(Equal(pairing.ClientB.ClientKey))
+					for _, clientKey := range []models.Key{pairing.ClientA.ClientKey, pairing.ClientB.ClientKey} {
+						Expect(isPaired[clientKey]).To(BeFalse(), "client %s paired twice", clientKey)
+						isPaired[clientKey] = true
+					}
+				}
+
+				By("only pairing matchable clients")
+				for _, pairing := range pairings {
+					longestWaitSecs := waitByKey[pairing.ClientA.ClientKey]
+					if waitByKey[pairing.ClientB.ClientKey] > longestWaitSecs {
+						longestWaitSecs = waitByKey[pairing.ClientB.ClientKey]
+					}
+					Expect(policy.IsMatchable(pairing.ClientA, pairing.ClientB, longestWaitSecs)).To(BeTrue())
+				}
+
+				By("leaving no two matchable clients unpaired")
+				for i, a := range candidates {
+					for _, b := range candidates[i+1:] {
+						if isPaired[a.ClientProfile.ClientKey] || isPaired[b.ClientProfile.ClientKey] {
+							continue
+						}
+						if a.ClientProfile.ClientKey == b.ClientProfile.ClientKey || a.TimeControl.Hash() != b.TimeControl.Hash() {
+							continue
+						}
+						longestWaitSecs := a.WaitSecs
+						if b.WaitSecs > longestWaitSecs {
+							longestWaitSecs = b.WaitSecs
+						}
+						Expect(policy.IsMatchable(a.ClientProfile, b.ClientProfile, longestWaitSecs)).To(BeFalse())
+					}
+				}
+			})
+		}
+	})
+})
@@ -0,0 +1,308 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"time"
+)
+
+const PARTY_CODE_LENGTH = 6
+
+type partyState struct {
+	party *models.Party
+	// games played so far by each pair of members, keyed by pairKey
+	gamesByPairKey map[string]int
+}
+
+type partyPairing struct {
+	clientAKey models.Key
+	clientBKey models.Key
+}
+
+// CreateParty opens a party hosted by the client. Members queue with the party's code and are only ever paired
+// within the party.
+func (mm *MatchmakingService) CreateParty(hostKey models.Key, mode models.PartyMode, timeControl *models.TimeControl, variant models.Variant, isRated bool) (*models.Party, error) {
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("unknown party mode %s", mode)
+	}
+	if timeControl == nil {
+		return nil, fmt.Errorf("party is missing a time control")
+	}
+	variant = variant.OrStandard()
+	if !variant.IsValid() {
+		return nil, fmt.Errorf("unknown variant %s", variant)
+	}
+
+	mm.mu.Lock()
+	if code, ok := mm.partyCodeByClientKey[hostKey]; ok {
+		mm.mu.Unlock()
+		return nil, fmt.Errorf("client %s is already in party %s", hostKey, code)
+	}
+	code := helpers.RandomCode(PARTY_CODE_LENGTH)
+	for mm.partiesByCode[code] != nil {
+		code = helpers.RandomCode(PARTY_CODE_LENGTH)
+	}
+	state := &partyState{
+		party: &models.Party{
+			Code:        code,
+			HostKey:     hostKey,
+			Mode:        mode,
+			TimeControl: timeControl,
+			Variant:     variant,
+			IsRated:     isRated,
+			MemberKeys:  []models.Key{hostKey},
+			WaitingKeys: make([]models.Key, 0),
+		},
+		gamesByPairKey: make(map[string]int),
+	}
+	mm.partiesByCode[code] = state
+	mm.partyCodeByClientKey[hostKey] = code
+	party := copyParty(state.party)
+	mm.mu.Unlock()
+
+	mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("client %s created %s party %s", hostKey, mode, code))
+	go mm.Dispatch(NewPartyUpdatedEvent(hostKey, party))
+	return party, nil
+}
+
+func (mm *MatchmakingService) Party(code string) (*models.Party, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	state, ok := mm.partiesByCode[code]
+	if !ok {
+		return nil, fmt.Errorf("no party with code %s", code)
+	}
+	return copyParty(state.party), nil
+}
+
+// QueueInParty joins the client to the party if they aren't a member yet, then queues them for their next game
+// within it. Pairings are made as soon as the party queue allows.
+func (mm *MatchmakingService) QueueInParty(clientKey models.Key, code string) error {
+	if mm.isInMatch(clientKey) {
+		return fmt.Errorf("client %s is already in a match", clientKey)
+	}
+	mm.mu.Lock()
+	state, ok := mm.partiesByCode[code]
+	if !ok {
+		mm.mu.Unlock()
+		return fmt.Errorf("no party with code %s", code)
+	}
+	if otherCode, ok := mm.partyCodeByClientKey[clientKey]; ok && otherCode != code {
+		mm.mu.Unlock()
+		return fmt.Errorf("client %s is already in party %s", clientKey, otherCode)
+	}
+	if _, ok := mm.poolsByClientKey[clientKey]; ok {
+		mm.mu.Unlock()
+		return fmt.Errorf("client %s is already in matchmaking", clientKey)
+	}
+	if indexOfKey(state.party.WaitingKeys, clientKey) >= 0 {
+		mm.mu.Unlock()
+		return fmt.Errorf("client %s is already queued in party %s", clientKey, code)
+	}
+	if vetErr := mm.vetQueueing(clientKey); vetErr != nil {
+		mm.mu.Unlock()
+		return vetErr
+	}
+	if indexOfKey(state.party.MemberKeys, clientKey) < 0 {
+		state.party.MemberKeys = append(state.party.MemberKeys, clientKey)
+		mm.partyCodeByClientKey[clientKey] = code
+	}
+	state.party.WaitingKeys = append(state.party.WaitingKeys, clientKey)
+	pairings := state.claimPairings()
+	party := copyParty(state.party)
+	mm.mu.Unlock()
+
+	mm.dispatchPartyUpdated(party)
+	mm.startPartyMatches(party, pairings)
+	return nil
+}
+
+// LeaveParty takes the client out of their party. A host leaving disbands the party.
+func (mm *MatchmakingService) LeaveParty(clientKey models.Key) error {
+	mm.mu.Lock()
+	code, ok := mm.partyCodeByClientKey[clientKey]
+	if !ok {
+		mm.mu.Unlock()
+		return fmt.Errorf("client %s is not in a party", clientKey)
+	}
+	state := mm.partiesByCode[code]
+	if state.party.HostKey == clientKey {
+		delete(mm.partiesByCode, code)
+		for _, memberKey := range state.party.MemberKeys {
+			delete(mm.partyCodeByClientKey, memberKey)
+		}
+		memberKeys := state.party.MemberKeys
+		mm.mu.Unlock()
+
+		mm.LogService.Log(models.ENV_MATCHMAKING, fmt.Sprintf("party %s disbanded", code))
+		for _, memberKey := range memberKeys {
+			go mm.Dispatch(NewPartyDisbandedEvent(memberKey, code, models.PARTY_DISBANDED_REASON_HOST_LEFT))
+		}
+		return nil
+	}
+	delete(mm.partyCodeByClientKey, clientKey)
+	state.party.MemberKeys = removeKey(state.party.MemberKeys, clientKey)
+	state.party.WaitingKeys = removeKey(state.party.WaitingKeys, clientKey)
+	party := copyParty(state.party)
+	mm.mu.Unlock()
+
+	mm.dispatchPartyUpdated(party)
+	return nil
+}
+
+// removeFromPartyQueue takes the client out of their party's queue without leaving the party. Callers must hold mm.mu.
+func (mm *MatchmakingService) removeFromPartyQueue(clientKey models.Key) (*models.Party, bool) {
+	code, ok := mm.partyCodeByClientKey[clientKey]
+	if !ok {
+		return nil, false
+	}
+	state := mm.partiesByCode[code]
+	if indexOfKey(state.party.WaitingKeys, clientKey) < 0 {
+		return nil, false
+	}
+	state.party.WaitingKeys = removeKey(state.party.WaitingKeys, clientKey)
+	return copyParty(state.party), true
+}
+
+// isInPartyQueue callers must hold mm.mu
+func (mm *MatchmakingService) isInPartyQueue(clientKey models.Key) bool {
+	code, ok := mm.partyCodeByClientKey[clientKey]
+	return ok && indexOfKey(mm.partiesByCode[code].party.WaitingKeys, clientKey) >= 0
+}
+
+// claimPairings takes every pairing the party's mode allows out of the queue. The games are only counted once they
+// start. Callers must hold mm.mu.
+func (ps *partyState) claimPairings() []*partyPairing {
+	pairings := make([]*partyPairing, 0)
+	for {
+		pairing := ps.nextPairing()
+		if pairing == nil {
+			return pairings
+		}
+		ps.party.WaitingKeys = removeKey(removeKey(ps.party.WaitingKeys, pairing.clientAKey), pairing.clientBKey)
+		pairings = append(pairings, pairing)
+	}
+}
+
+// returnPairing puts a pairing whose match could not start back at the front of the queue, leaving out anyone who has
+// since left the party. Callers must hold mm.mu.
+func (ps *partyState) returnPairing(pairing *partyPairing) {
+	for _, clientKey := range []models.Key{pairing.clientBKey, pairing.clientAKey} {
+		if indexOfKey(ps.party.MemberKeys, clientKey) >= 0 && indexOfKey(ps.party.WaitingKeys, clientKey) < 0 {
+			ps.party.WaitingKeys = append([]models.Key{clientKey}, ps.party.WaitingKeys...)
+		}
+	}
+}
+
+func (ps *partyState) nextPairing() *partyPairing {
+	waitingKeys := ps.party.WaitingKeys
+	if ps.party.Mode == models.PARTY_MODE_VS_HOST {
+		if indexOfKey(waitingKeys, ps.party.HostKey) < 0 {
+			return nil
+		}
+		for _, waitingKey := range waitingKeys {
+			if waitingKey != ps.party.HostKey {
+				return &partyPairing{clientAKey: ps.party.HostKey, clientBKey: waitingKey}
+			}
+		}
+		return nil
+	}
+
+	var best *partyPairing
+	bestGames := 0
+	for i := range waitingKeys {
+		for j := i + 1; j < len(waitingKeys); j++ {
+			games := ps.gamesByPairKey[pairKey(waitingKeys[i], waitingKeys[j])]
+			if best == nil || games < bestGames {
+				best = &partyPairing{clientAKey: waitingKeys[i], clientBKey: waitingKeys[j]}
+				bestGames = games
+			}
+		}
+	}
+	return best
+}
+
+// startPartyMatches starts a match for each pairing, counting the games that start and returning the pairings that
+// can't to the party queue
+func (mm *MatchmakingService) startPartyMatches(party *models.Party, pairings []*partyPairing) {
+	for _, pairing := range pairings {
+		matchErr := mm.startPartyMatch(party, pairing)
+
+		mm.mu.Lock()
+		state, ok := mm.partiesByCode[party.Code]
+		if ok && matchErr == nil {
+			state.gamesByPairKey[pairKey(pairing.clientAKey, pairing.clientBKey)]++
+		} else if ok {
+			state.returnPairing(pairing)
+			party = copyParty(state.party)
+		}
+		mm.mu.Unlock()
+
+		if matchErr == nil {
+			mm.LogService.LogGreen(models.ENV_MATCHMAKING, fmt.Sprintf("matched clients %s and %s in party %s", pairing.clientAKey, pairing.clientBKey, party.Code))
+			continue
+		}
+		mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("error matching clients %s and %s in party %s: %s", pairing.clientAKey, pairing.clientBKey, party.Code, matchErr))
+		if ok {
+			mm.dispatchPartyUpdated(party)
+		}
+	}
+}
+
+func (mm *MatchmakingService) startPartyMatch(party *models.Party, pairing *partyPairing) error {
+	clientAKey, clientBKey := pairing.clientAKey, pairing.clientBKey
+	allocation := AllocateColours(models.NewClientProfile(clientAKey, 0), models.NewClientProfile(clientBKey, 0), mm.ColourHistory(clientAKey), mm.ColourHistory(clientBKey))
+	match := builders.NewMatchBuilder().
+		FromMatch(builders.NewMatch(allocation.WhiteClientKey, allocation.BlackClientKey, party.TimeControl, models.MATCH_RESULT_IN_PROGRESS)).
+		WithVariant(party.Variant).
+		WithIsRated(party.IsRated).
+		Build()
+	if addMatchErr := mm.MatchService.AddMatch(match); addMatchErr != nil {
+		return fmt.Errorf("error adding match %s: %s", match.Uuid, addMatchErr)
+	}
+	mm.recordColour(allocation.WhiteClientKey, models.COLOUR_WHITE)
+	mm.recordColour(allocation.BlackClientKey, models.COLOUR_BLACK)
+	mm.opponentMemory.RecordPairing(clientAKey, clientBKey, time.Now())
+	return nil
+}
+
+func (mm *MatchmakingService) dispatchPartyUpdated(party *models.Party) {
+	for _, memberKey := range party.MemberKeys {
+		go mm.Dispatch(NewPartyUpdatedEvent(memberKey, party))
+	}
+}
+
+func copyParty(party *models.Party) *models.Party {
+	partyCopy := *party
+	partyCopy.MemberKeys = append(make([]models.Key, 0, len(party.MemberKeys)), party.MemberKeys...)
+	partyCopy.WaitingKeys = append(make([]models.Key, 0, len(party.WaitingKeys)), party.WaitingKeys...)
+	return &partyCopy
+}
+
+func pairKey(clientAKey, clientBKey models.Key) string {
+	if clientBKey < clientAKey {
+		clientAKey, clientBKey = clientBKey, clientAKey
+	}
+	return string(clientAKey) + ":" + string(clientBKey)
+}
+
+func indexOfKey(keys []models.Key, key models.Key) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeKey(keys []models.Key, key models.Key) []models.Key {
+	filtered := make([]models.Key, 0, len(keys))
+	for _, k := range keys {
+		if k != key {
+			filtered = append(filtered, k)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,180 @@
+package matchmaking_test
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/CameronHonis/service/test_helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+var _ = Describe("MatchmakingService parties", func() {
+	var matchmakingService *matchmaking.MatchmakingService
+	var matchServiceMock *mocks.MockMatcherServiceI
+	var eventCatcher *EventCatcher
+	var matches []*models.Match
+	var timeControl *models.TimeControl
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		matchmakingService = CreateServices(ctrl)
+		matchServiceMock = matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+		matches = make([]*models.Match, 0)
+		matchServiceMock.EXPECT().AddMatch(gomock.Any()).DoAndReturn(func(match *models.Match) error {
+			matches = append(matches, match)
+			return nil
+		}).AnyTimes()
+		eventCatcher = NewEventCatcher()
+		eventCatcher.AddDependency(matchmakingService)
+		timeControl = builders.NewBlitzTimeControl()
+	})
+	playerKeys := func(match *models.Match) []models.Key {
+		return []models.Key{match.WhiteClientKey, match.BlackClientKey}
+	}
+	Describe("::CreateParty", func() {
+		It("gives the party a code and the host as its first member", func() {
+			party, err := matchmakingService.CreateParty("host", models.PARTY_MODE_VS_HOST, timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(party.Code).To(HaveLen(matchmaking.PARTY_CODE_LENGTH))
+			Expect(party.MemberKeys).To(Equal([]models.Key{"host"}))
+		})
+		It("rejects unknown modes", func() {
+			_, err := matchmakingService.CreateParty("host", "free_for_all", timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).To(HaveOccurred())
+		})
+		It("rejects hosts already in a party", func() {
+			_, err := matchmakingService.CreateParty("host", models.PARTY_MODE_VS_HOST, timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = matchmakingService.CreateParty("host", models.PARTY_MODE_ROUND_ROBIN, timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	When("the party plays against the host", func() {
+		var party *models.Party
+		BeforeEach(func() {
+			var err error
+			party, err = matchmakingService.CreateParty("host", models.PARTY_MODE_VS_HOST, timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("pairs the host with the first member in line", func() {
+			Expect(matchmakingService.QueueInParty("viewer-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("viewer-b", party.Code)).To(Succeed())
+			Expect(matches).To(BeEmpty())
+
+			Expect(matchmakingService.QueueInParty("host", party.Code)).To(Succeed())
+			Expect(matches).To(HaveLen(1))
+			Expect(playerKeys(matches[0])).To(ConsistOf(models.Key("host"), models.Key("viewer-a")))
+			Expect(matches[0].IsRated).To(BeFalse())
+			Expect(matches[0].TimeControl).To(Equal(timeControl))
+
+			updatedParty, _ := matchmakingService.Party(party.Code)
+			Expect(updatedParty.WaitingKeys).To(Equal([]models.Key{"viewer-b"}))
+		})
+		It("never pairs members with each other", func() {
+			Expect(matchmakingService.QueueInParty("viewer-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("viewer-b", party.Code)).To(Succeed())
+			Expect(matches).To(BeEmpty())
+		})
+		It("disbands the party when the host leaves", func() {
+			Expect(matchmakingService.QueueInParty("viewer-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.LeaveParty("host")).To(Succeed())
+			_, err := matchmakingService.Party(party.Code)
+			Expect(err).To(HaveOccurred())
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.PARTY_DISBANDED)
+			}).Should(Equal(2))
+			Expect(matchmakingService.LeaveParty("viewer-a")).ToNot(Succeed())
+		})
+	})
+	When("the party plays round robin", func() {
+		var party *models.Party
+		BeforeEach(func() {
+			var err error
+			party, err = matchmakingService.CreateParty("host", models.PARTY_MODE_ROUND_ROBIN, timeControl, models.VARIANT_STANDARD, true)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("pairs members with each other as soon as two are waiting", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-b", party.Code)).To(Succeed())
+			Expect(matches).To(HaveLen(1))
+			Expect(playerKeys(matches[0])).To(ConsistOf(models.Key("friend-a"), models.Key("friend-b")))
+			Expect(matches[0].IsRated).To(BeTrue())
+		})
+		It("favours members who have not played each other yet", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-b", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-c", party.Code)).To(Succeed())
+
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-b", party.Code)).To(Succeed())
+			Expect(matches).To(HaveLen(2))
+			Expect(playerKeys(matches[1])).To(ConsistOf(models.Key("friend-c"), models.Key("friend-a")))
+		})
+		It("keeps party members out of regular matchmaking while they wait", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.AddClient(models.NewClientProfile("friend-a", 1000), timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
+		})
+		It("lets a waiting member step out of the queue without leaving the party", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.RemoveClient("friend-a", models.MATCHMAKING_LEFT_REASON_REQUESTED)).To(Succeed())
+			updatedParty, _ := matchmakingService.Party(party.Code)
+			Expect(updatedParty.WaitingKeys).To(BeEmpty())
+			Expect(updatedParty.MemberKeys).To(ContainElement(models.Key("friend-a")))
+		})
+	})
+	When("a match can't be started", func() {
+		var party *models.Party
+		BeforeEach(func() {
+			var err error
+			party, err = matchmakingService.CreateParty("host", models.PARTY_MODE_ROUND_ROBIN, timeControl, models.VARIANT_STANDARD, false)
+			Expect(err).ToNot(HaveOccurred())
+			isFirstAttempt := true
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).DoAndReturn(func(match *models.Match) error {
+				if isFirstAttempt {
+					isFirstAttempt = false
+					return fmt.Errorf("client already in a match")
+				}
+				matches = append(matches, match)
+				return nil
+			}).AnyTimes()
+		})
+		It("puts the pair back in the party queue and tells the party", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-b", party.Code)).To(Succeed())
+			Expect(matches).To(BeEmpty())
+			updatedParty, _ := matchmakingService.Party(party.Code)
+			Expect(updatedParty.WaitingKeys).To(ConsistOf(models.Key("friend-a"), models.Key("friend-b")))
+			By("updating every member once for the party's creation and each join, then again for the failed match")
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.PARTY_UPDATED)
+			}).Should(Equal(1 + 2 + 3 + 3))
+		})
+		It("does not count the game towards the round robin", func() {
+			Expect(matchmakingService.QueueInParty("friend-a", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-b", party.Code)).To(Succeed())
+			Expect(matchmakingService.QueueInParty("friend-c", party.Code)).To(Succeed())
+			Expect(matches).To(HaveLen(1))
+			Expect(playerKeys(matches[0])).To(ConsistOf(models.Key("friend-a"), models.Key("friend-b")))
+		})
+	})
+	It("keeps clients already in a match out of the party queue", func() {
+		party, err := matchmakingService.CreateParty("host", models.PARTY_MODE_ROUND_ROBIN, timeControl, models.VARIANT_STANDARD, false)
+		Expect(err).ToNot(HaveOccurred())
+		matchServiceMock.EXPECT().MatchByClientKey(models.Key("friend-a")).Return(builders.NewMatchBuilder().Build(), nil).AnyTimes()
+		Expect(matchmakingService.QueueInParty("friend-a", party.Code)).ToNot(Succeed())
+	})
+	It("keeps clients on a cooldown out of the party queue", func() {
+		party, err := matchmakingService.CreateParty("host", models.PARTY_MODE_ROUND_ROBIN, timeControl, models.VARIANT_STANDARD, false)
+		Expect(err).ToNot(HaveOccurred())
+		penaltyServiceMock := matchmakingService.PenaltyService.(*mocks.MockPenaltyServiceI)
+		penaltyServiceMock.EXPECT().CooldownRemaining(models.Key("friend-a")).Return(time.Minute).AnyTimes()
+		Expect(matchmakingService.QueueInParty("friend-a", party.Code)).ToNot(Succeed())
+	})
+	It("rejects unknown party codes", func() {
+		Expect(matchmakingService.QueueInParty("friend-a", "NOPE42")).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,159 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+type ReadyCheckConfig struct {
+	// when disabled, matchmade clients go straight into a match
+	IsEnabled bool
+	// how long both clients have to confirm a found match
+	Timeout time.Duration
+}
+
+func NewReadyCheckConfig() *ReadyCheckConfig {
+	return &ReadyCheckConfig{
+		IsEnabled: false,
+		Timeout:   15 * time.Second,
+	}
+}
+
+type pendingReadyCheck struct {
+	readyCheck  *models.ReadyCheck
+	clients     []*queuedClient
+	isConfirmed map[models.Key]bool
+	timer       *time.Timer
+}
+
+func (mm *MatchmakingService) readyCheckConfig() *ReadyCheckConfig {
+	config := mm.Config().(*MatchmakingConfig)
+	if config.ReadyCheck == nil {
+		return NewReadyCheckConfig()
+	}
+	return config.ReadyCheck
+}
+
+func (mm *MatchmakingService) startReadyCheck(clients []*queuedClient, timeControl *models.TimeControl, variant models.Variant) {
+	config := mm.readyCheckConfig()
+	expiresAt := time.Now().Add(config.Timeout)
+	readyCheck := &models.ReadyCheck{
+		Uuid:        uuid.New().String(),
+		ClientKeys:  []models.Key{clients[0].profile.ClientKey, clients[1].profile.ClientKey},
+		TimeControl: timeControl,
+		Variant:     variant,
+		ExpiresAt:   &expiresAt,
+	}
+	pending := &pendingReadyCheck{
+		readyCheck:  readyCheck,
+		clients:     clients,
+		isConfirmed: make(map[models.Key]bool),
+	}
+
+	mm.mu.Lock()
+	mm.readyChecksByUuid[readyCheck.Uuid] = pending
+	for _, clientKey := range readyCheck.ClientKeys {
+		mm.readyCheckIdByClientKey[clientKey] = readyCheck.Uuid
+	}
+	pending.timer = time.AfterFunc(config.Timeout, func() {
+		mm.expireReadyCheck(readyCheck.Uuid)
+	})
+	mm.mu.Unlock()
+
+	for _, clientKey := range readyCheck.ClientKeys {
+		go mm.Dispatch(NewMatchFoundEvent(clientKey, readyCheck))
+	}
+}
+
+// ConfirmMatch marks the client as ready, starting the match once both clients are. If the match can't start, the
+// ready check is cancelled for both clients.
+func (mm *MatchmakingService) ConfirmMatch(clientKey models.Key, readyCheckId string) error {
+	mm.mu.Lock()
+	pending, ok := mm.readyChecksByUuid[readyCheckId]
+	if !ok || mm.readyCheckIdByClientKey[clientKey] != readyCheckId {
+		mm.mu.Unlock()
+		return fmt.Errorf("no ready check %s pending for client %s", readyCheckId, clientKey)
+	}
+	pending.isConfirmed[clientKey] = true
+	if len(pending.isConfirmed) < len(pending.clients) {
+		mm.mu.Unlock()
+		return nil
+	}
+	pending.timer.Stop()
+	mm.removeReadyCheck(pending)
+	mm.mu.Unlock()
+
+	readyCheck := pending.readyCheck
+	if matchErr := mm.startClaimedMatch(pending.clients, readyCheck.TimeControl, readyCheck.Variant); matchErr != nil {
+		mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not start match for ready check %s: %s", readyCheck.Uuid, matchErr))
+		for _, readyClientKey := range readyCheck.ClientKeys {
+			go mm.Dispatch(NewMatchCancelledEvent(readyClientKey, readyCheck.Uuid, models.READY_CHECK_CANCELLED_REASON_START_FAILED))
+		}
+	}
+	return nil
+}
+
+// expireReadyCheck cancels a ready check that was not confirmed in time. Clients that confirmed go back to the front
+// of the queues they were in, and the rest are charged a no-show.
+func (mm *MatchmakingService) expireReadyCheck(readyCheckId string) {
+	mm.mu.Lock()
+	pending, ok := mm.readyChecksByUuid[readyCheckId]
+	if !ok {
+		mm.mu.Unlock()
+		return
+	}
+	mm.removeReadyCheck(pending)
+	reasonByClientKey := make(map[models.Key]models.ReadyCheckCancelledReason)
+	for _, client := range pending.clients {
+		clientKey := client.profile.ClientKey
+		if !pending.isConfirmed[clientKey] {
+			mm.PenaltyService.RecordOffence(clientKey, models.OFFENCE_KIND_READY_CHECK_NO_SHOW, "")
+			reasonByClientKey[clientKey] = models.READY_CHECK_CANCELLED_REASON_NO_SHOW
+			continue
+		}
+		reasonByClientKey[clientKey] = models.READY_CHECK_CANCELLED_REASON_OPPONENT_NO_SHOW
+		if requeueErr := mm.enqueue(client.profile, client.queues, client.timeJoined, true); requeueErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not requeue client %s: %s", clientKey, requeueErr))
+		}
+	}
+	mm.mu.Unlock()
+
+	for clientKey, reason := range reasonByClientKey {
+		go mm.Dispatch(NewMatchCancelledEvent(clientKey, readyCheckId, reason))
+	}
+}
+
+// cancelReadyCheckFor cancels the ready check pending for a client leaving matchmaking, returning whether there was
+// one. Their opponent goes back to the front of the queues they were in, and nobody is charged a no-show. Callers must
+// hold mm.mu.
+func (mm *MatchmakingService) cancelReadyCheckFor(clientKey models.Key) bool {
+	readyCheckId, ok := mm.readyCheckIdByClientKey[clientKey]
+	if !ok {
+		return false
+	}
+	pending := mm.readyChecksByUuid[readyCheckId]
+	pending.timer.Stop()
+	mm.removeReadyCheck(pending)
+	for _, client := range pending.clients {
+		readyClientKey := client.profile.ClientKey
+		if readyClientKey == clientKey {
+			go mm.Dispatch(NewMatchCancelledEvent(readyClientKey, readyCheckId, models.READY_CHECK_CANCELLED_REASON_LEFT))
+			continue
+		}
+		if requeueErr := mm.enqueue(client.profile, client.queues, client.timeJoined, true); requeueErr != nil {
+			mm.LogService.LogRed(models.ENV_MATCHMAKING, fmt.Sprintf("could not requeue client %s: %s", readyClientKey, requeueErr))
+		}
+		go mm.Dispatch(NewMatchCancelledEvent(readyClientKey, readyCheckId, models.READY_CHECK_CANCELLED_REASON_OPPONENT_LEFT))
+	}
+	return true
+}
+
+// removeReadyCheck callers must hold mm.mu
+func (mm *MatchmakingService) removeReadyCheck(pending *pendingReadyCheck) {
+	delete(mm.readyChecksByUuid, pending.readyCheck.Uuid)
+	for _, clientKey := range pending.readyCheck.ClientKeys {
+		delete(mm.readyCheckIdByClientKey, clientKey)
+	}
+}
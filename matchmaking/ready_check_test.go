@@ -0,0 +1,165 @@
+package matchmaking_test
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/CameronHonis/service/test_helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+var _ = Describe("MatchmakingService ready check", func() {
+	var matchmakingService *matchmaking.MatchmakingService
+	var matchServiceMock *mocks.MockMatcherServiceI
+	var penaltyServiceMock *mocks.MockPenaltyServiceI
+	var eventCatcher *EventCatcher
+	var readyCheckConfig *matchmaking.ReadyCheckConfig
+	var clientA, clientB *models.ClientProfile
+	var timeControl *models.TimeControl
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		matchmakingService = CreateServices(ctrl)
+		readyCheckConfig = matchmakingService.Config().(*matchmaking.MatchmakingConfig).ReadyCheck
+		readyCheckConfig.IsEnabled = true
+		readyCheckConfig.Timeout = 50 * time.Millisecond
+		matchServiceMock = matchmakingService.MatchService.(*mocks.MockMatcherServiceI)
+		penaltyServiceMock = matchmakingService.PenaltyService.(*mocks.MockPenaltyServiceI)
+		eventCatcher = NewEventCatcher()
+		eventCatcher.AddDependency(matchmakingService)
+
+		clientA = models.NewClientProfile("client-a", 1000)
+		clientB = models.NewClientProfile("client-b", 1000)
+		timeControl = builders.NewBlitzTimeControl()
+		Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+		Expect(matchmakingService.AddClient(clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+	})
+	matchFound := func() *models.ReadyCheck {
+		Expect(matchmakingService.MatchClient(clientA, clientB, timeControl, models.VARIANT_STANDARD)).To(Succeed())
+		Eventually(func() int {
+			return eventCatcher.EventsByVariantCount(matchmaking.MATCH_FOUND)
+		}).Should(Equal(2))
+		return eventCatcher.LastEventByVariant(matchmaking.MATCH_FOUND).Payload().(*matchmaking.MatchFoundEventPayload).ReadyCheck
+	}
+	It("holds the match until both clients confirm", func() {
+		matchServiceMock.EXPECT().AddMatch(gomock.Any()).Times(0)
+		readyCheck := matchFound()
+		Expect(readyCheck.ClientKeys).To(ConsistOf(clientA.ClientKey, clientB.ClientKey))
+		Expect(matchmakingService.ConfirmMatch(clientA.ClientKey, readyCheck.Uuid)).To(Succeed())
+
+		matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(nil).Times(1)
+		Expect(matchmakingService.ConfirmMatch(clientB.ClientKey, readyCheck.Uuid)).To(Succeed())
+	})
+	It("rejects confirmations for other ready checks", func() {
+		readyCheck := matchFound()
+		Expect(matchmakingService.ConfirmMatch("client-c", readyCheck.Uuid)).To(HaveOccurred())
+		Expect(matchmakingService.ConfirmMatch(clientA.ClientKey, "some-other-uuid")).To(HaveOccurred())
+	})
+	It("keeps clients with a pending ready check out of matchmaking", func() {
+		matchFound()
+		Expect(matchmakingService.AddClient(clientA, timeControl, models.VARIANT_STANDARD)).To(HaveOccurred())
+	})
+	It("keeps clients with a pending ready check out of party queues", func() {
+		party, partyErr := matchmakingService.CreateParty("host", models.PARTY_MODE_VS_HOST, timeControl, models.VARIANT_STANDARD, false)
+		Expect(partyErr).ToNot(HaveOccurred())
+		matchFound()
+		Expect(matchmakingService.QueueInParty(clientA.ClientKey, party.Code)).To(HaveOccurred())
+	})
+	When("both clients confirm but the match can't start", func() {
+		BeforeEach(func() {
+			matchServiceMock.EXPECT().AddMatch(gomock.Any()).Return(fmt.Errorf("client is already in a match")).AnyTimes()
+			readyCheck := matchFound()
+			Expect(matchmakingService.ConfirmMatch(clientA.ClientKey, readyCheck.Uuid)).To(Succeed())
+			Expect(matchmakingService.ConfirmMatch(clientB.ClientKey, readyCheck.Uuid)).To(Succeed())
+		})
+		It("cancels the ready check for both clients", func() {
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.MATCH_CANCELLED)
+			}).Should(Equal(2))
+			payload := eventCatcher.LastEventByVariant(matchmaking.MATCH_CANCELLED).Payload().(*matchmaking.MatchCancelledEventPayload)
+			Expect(payload.Reason).To(Equal(models.READY_CHECK_CANCELLED_REASON_START_FAILED))
+		})
+		It("returns both clients to the queue", func() {
+			for _, clientKey := range []models.Key{clientA.ClientKey, clientB.ClientKey} {
+				statuses, err := matchmakingService.ClientStatuses(clientKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(statuses).To(HaveLen(1))
+			}
+		})
+	})
+	When("a client disconnects during the ready check", func() {
+		var readyCheck *models.ReadyCheck
+		var offenderKeys chan models.Key
+		BeforeEach(func() {
+			offenderKeys = make(chan models.Key, 2)
+			penaltyServiceMock.EXPECT().RecordOffence(gomock.Any(), gomock.Any(), gomock.Any()).
+				Do(func(clientKey models.Key, _ models.OffenceKind, _ string) {
+					offenderKeys <- clientKey
+				}).AnyTimes()
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1000), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			readyCheck = matchFound()
+			Expect(matchmakingService.RemoveClient(clientB.ClientKey, models.MATCHMAKING_LEFT_REASON_DISCONNECTED)).To(Succeed())
+		})
+		It("tells both clients the match is off without waiting for the timeout", func() {
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.MATCH_CANCELLED)
+			}, readyCheckConfig.Timeout/2).Should(Equal(2))
+			payload := eventCatcher.LastEventByVariant(matchmaking.MATCH_CANCELLED).Payload().(*matchmaking.MatchCancelledEventPayload)
+			if payload.ClientKey == clientA.ClientKey {
+				Expect(payload.Reason).To(Equal(models.READY_CHECK_CANCELLED_REASON_OPPONENT_LEFT))
+			} else {
+				Expect(payload.Reason).To(Equal(models.READY_CHECK_CANCELLED_REASON_LEFT))
+			}
+		})
+		It("returns the opponent to the front of the queue", func() {
+			statuses, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].QueuePosition).To(Equal(1))
+		})
+		It("charges nobody with an offence", func() {
+			Consistently(offenderKeys, 2*readyCheckConfig.Timeout).ShouldNot(Receive())
+		})
+		It("no longer accepts confirmations", func() {
+			Expect(matchmakingService.ConfirmMatch(clientA.ClientKey, readyCheck.Uuid)).To(HaveOccurred())
+		})
+	})
+	When("only one client confirms in time", func() {
+		var readyCheck *models.ReadyCheck
+		var offenderKeys chan models.Key
+		BeforeEach(func() {
+			offenderKeys = make(chan models.Key, 2)
+			penaltyServiceMock.EXPECT().RecordOffence(gomock.Any(), models.OFFENCE_KIND_READY_CHECK_NO_SHOW, "").
+				Do(func(clientKey models.Key, _ models.OffenceKind, _ string) {
+					offenderKeys <- clientKey
+				}).AnyTimes()
+			Expect(matchmakingService.AddClient(models.NewClientProfile("client-c", 1000), timeControl, models.VARIANT_STANDARD)).To(Succeed())
+			readyCheck = matchFound()
+			Expect(matchmakingService.ConfirmMatch(clientA.ClientKey, readyCheck.Uuid)).To(Succeed())
+			Eventually(func() int {
+				return eventCatcher.EventsByVariantCount(matchmaking.MATCH_CANCELLED)
+			}).Should(Equal(2))
+		})
+		It("returns the confirmed client to the front of the queue", func() {
+			statuses, err := matchmakingService.ClientStatuses(clientA.ClientKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].QueuePosition).To(Equal(1))
+		})
+		It("leaves the no-show out of matchmaking", func() {
+			_, err := matchmakingService.ClientStatuses(clientB.ClientKey)
+			Expect(err).To(HaveOccurred())
+		})
+		It("charges only the no-show with an offence", func() {
+			Expect(offenderKeys).To(Receive(Equal(clientB.ClientKey)))
+			Expect(offenderKeys).ToNot(Receive())
+		})
+		It("no longer accepts confirmations", func() {
+			Expect(matchmakingService.ConfirmMatch(clientB.ClientKey, readyCheck.Uuid)).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,158 @@
+package matchmaking
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+type SimulatedArrival struct {
+	ArrivalSecs   int64
+	ClientProfile *models.ClientProfile
+}
+
+// SyntheticArrivals generates a reproducible stream of clients arriving on average every meanGapSecs, with normally
+// distributed elos and the occasional win or loss streak
+func SyntheticArrivals(seed int64, count int, meanGapSecs float64, eloMean float64, eloStdDev float64) []*SimulatedArrival {
+	rng := rand.New(rand.NewSource(seed))
+	arrivals := make([]*SimulatedArrival, 0, count)
+	var nowSecs float64
+	for i := 0; i < count; i++ {
+		nowSecs += rng.ExpFloat64() * meanGapSecs
+		client := models.NewClientProfile(models.Key(fmt.Sprintf("sim-client-%d", i)), int(rng.NormFloat64()*eloStdDev+eloMean))
+		if streak := rng.Intn(9) - 4; streak > 0 {
+			client.WinStreak = streak
+		} else {
+			client.LossStreak = -streak
+		}
+		arrivals = append(arrivals, &SimulatedArrival{
+			ArrivalSecs:   int64(nowSecs),
+			ClientProfile: client,
+		})
+	}
+	return arrivals
+}
+
+type Distribution struct {
+	Count int
+	Mean  float64
+	P50   float64
+	P90   float64
+	P99   float64
+	Max   float64
+}
+
+func NewDistribution(samples []float64) *Distribution {
+	if len(samples) == 0 {
+		return &Distribution{}
+	}
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	var total float64
+	for _, sample := range sorted {
+		total += sample
+	}
+	percentile := func(p float64) float64 {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return &Distribution{
+		Count: len(sorted),
+		Mean:  total / float64(len(sorted)),
+		P50:   percentile(0.5),
+		P90:   percentile(0.9),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+func (d *Distribution) String() string {
+	return fmt.Sprintf("n=%d mean=%.1f p50=%.0f p90=%.0f p99=%.0f max=%.0f", d.Count, d.Mean, d.P50, d.P90, d.P99, d.Max)
+}
+
+type SimulationReport struct {
+	PairedCount   int
+	UnpairedCount int
+	WaitSecs      *Distribution
+	EloGaps       *Distribution
+}
+
+func (r *SimulationReport) String() string {
+	return fmt.Sprintf("paired=%d unpaired=%d\n  wait secs: %s\n  elo gaps:  %s", r.PairedCount, r.UnpairedCount, r.WaitSecs, r.EloGaps)
+}
+
+// Simulate replays the arrivals through a single pool on a virtual clock, running a matchmaking round every policy
+// tick, until runForSecs have passed
+func Simulate(policy MatchmakingPolicy, arrivals []*SimulatedArrival, runForSecs int64) *SimulationReport {
+	tickSecs := int64(policy.TickInterval() / time.Second)
+	if tickSecs < 1 {
+		tickSecs = 1
+	}
+	timeControl := builders.NewBlitzTimeControl()
+	joinedSecsByKey := make(map[models.Key]int64)
+	queued := make([]*models.ClientProfile, 0)
+	waitSecs := make([]float64, 0)
+	eloGaps := make([]float64, 0)
+	nextArrivalIdx := 0
+	for nowSecs := int64(0); nowSecs <= runForSecs; nowSecs += tickSecs {
+		for nextArrivalIdx < len(arrivals) && arrivals[nextArrivalIdx].ArrivalSecs <= nowSecs {
+			arrival := arrivals[nextArrivalIdx]
+			queued = append(queued, arrival.ClientProfile)
+			joinedSecsByKey[arrival.ClientProfile.ClientKey] = arrival.ArrivalSecs
+			nextArrivalIdx++
+		}
+
+		candidates := make([]*PairingCandidate, 0, len(queued))
+		for _, client := range queued {
+			candidates = append(candidates, &PairingCandidate{
+				ClientProfile: client,
+				TimeControl:   timeControl,
+				Variant:       models.VARIANT_STANDARD,
+				WaitSecs:      nowSecs - joinedSecsByKey[client.ClientKey],
+			})
+		}
+		isPaired := make(map[models.Key]bool)
+		for _, pairing := range PairGreedily(candidates, policy, nil) {
+			for _, client := range []*models.ClientProfile{pairing.ClientA, pairing.ClientB} {
+				isPaired[client.ClientKey] = true
+				waitSecs = append(waitSecs, float64(nowSecs-joinedSecsByKey[client.ClientKey]))
+			}
+			eloGaps = append(eloGaps, math.Abs(float64(pairing.ClientA.Elo-pairing.ClientB.Elo)))
+		}
+		stillQueued := make([]*models.ClientProfile, 0, len(queued))
+		for _, client := range queued {
+			if !isPaired[client.ClientKey] {
+				stillQueued = append(stillQueued, client)
+			}
+		}
+		queued = stillQueued
+	}
+	return &SimulationReport{
+		PairedCount:   len(waitSecs),
+		UnpairedCount: len(queued) + len(arrivals) - nextArrivalIdx,
+		WaitSecs:      NewDistribution(waitSecs),
+		EloGaps:       NewDistribution(eloGaps),
+	}
+}
+
+// SimulatePolicies replays the same arrivals under each named policy and reports on them side by side
+func SimulatePolicies(policyByName map[string]MatchmakingPolicy, arrivals []*SimulatedArrival, runForSecs int64) (string, map[string]*SimulationReport) {
+	names := make([]string, 0, len(policyByName))
+	for name := range policyByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reportByName := make(map[string]*SimulationReport)
+	var sb strings.Builder
+	for _, name := range names {
+		report := Simulate(policyByName[name], arrivals, runForSecs)
+		reportByName[name] = report
+		sb.WriteString(fmt.Sprintf("%s: %s\n", name, report))
+	}
+	return sb.String(), reportByName
+}
@@ -13,6 +13,10 @@ type Challenge struct {
 	IsChallengerBlack bool         `json:"isChallengerBlack"`
 	TimeControl       *TimeControl `json:"timeControl"`
 	BotName           string       `json:"botName"`
+	IsRated           bool         `json:"isRated"`
+	InviteToken       string       `json:"inviteToken"`
+	StartingFEN       string       `json:"startingFen"`
+	Variant           Variant      `json:"variant"`
 	TimeCreated       *time.Time   `json:"timeCreated"`
 	IsActive          bool         `json:"isActive"`
 }
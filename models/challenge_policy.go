@@ -0,0 +1,16 @@
+package models
+
+type ChallengePolicy struct {
+	FriendsOnly bool  `json:"friendsOnly"`
+	FriendKeys  []Key `json:"friendKeys"`
+	RatedOnly   bool  `json:"ratedOnly"`
+}
+
+func (p *ChallengePolicy) IsFriend(clientKey Key) bool {
+	for _, friendKey := range p.FriendKeys {
+		if friendKey == clientKey {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,13 @@
 package models
 
 type ClientProfile struct {
-	ClientKey  Key
-	Elo        int
-	WinStreak  int
-	LossStreak int
+	ClientKey        Key
+	Elo              int
+	WinStreak        int
+	LossStreak       int
+	ColourPreference Colour
+	// play the bot server rather than keep waiting for a human opponent
+	IsBotFallbackAllowed bool
 }
 
 func NewClientProfile(clientKey Key, elo int) *ClientProfile {
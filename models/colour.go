@@ -0,0 +1,19 @@
+package models
+
+type Colour string
+
+const (
+	COLOUR_NONE  Colour = ""
+	COLOUR_WHITE Colour = "white"
+	COLOUR_BLACK Colour = "black"
+)
+
+func (c Colour) Opposite() Colour {
+	switch c {
+	case COLOUR_WHITE:
+		return COLOUR_BLACK
+	case COLOUR_BLACK:
+		return COLOUR_WHITE
+	}
+	return COLOUR_NONE
+}
@@ -0,0 +1,56 @@
+package models
+
+// ContentAdapter rewrites outgoing content of the current protocol version into the shape an older version expects.
+// Adapters must not modify the content they are given, since it may be shared between recipients.
+type ContentAdapter func(content interface{}) interface{}
+
+type contentAdapterKey struct {
+	contentType ContentType
+	version     int
+}
+
+// contentAdapters maps a content type and the newest version an adapter serves to that adapter. Content sent to a
+// client passes through every adapter from the current version down to the client's.
+var contentAdapters = map[contentAdapterKey]ContentAdapter{
+	{CONTENT_TYPE_MATCH_UPDATED, PROTOCOL_VERSION_1}: adaptMatchUpdateToV1,
+}
+
+// featureAdapters maps an optional feature and a content type to the adapter that strips what the feature adds, for
+// clients that did not negotiate the feature
+var featureAdapters = map[Feature]map[ContentType]ContentAdapter{
+	FEATURE_MOVE_HISTORY: {CONTENT_TYPE_MATCH_UPDATED: adaptMatchUpdateToV1},
+}
+
+func AdaptContent(contentType ContentType, content interface{}, version int) interface{} {
+	for v := CURRENT_PROTOCOL_VERSION - 1; v >= version; v-- {
+		if adapter, ok := contentAdapters[contentAdapterKey{contentType, v}]; ok {
+			content = adapter(content)
+		}
+	}
+	return content
+}
+
+// AdaptContentToFeatures strips whatever the features the client did not negotiate would add to the content
+func AdaptContentToFeatures(contentType ContentType, content interface{}, features []Feature) interface{} {
+	hasFeature := make(map[Feature]bool)
+	for _, feature := range features {
+		hasFeature[feature] = true
+	}
+	for feature, adapterByContentType := range featureAdapters {
+		if adapter, ok := adapterByContentType[contentType]; ok && !hasFeature[feature] {
+			content = adapter(content)
+		}
+	}
+	return content
+}
+
+// adaptMatchUpdateToV1 drops the move history, leaving v1 clients with LastMove as before
+func adaptMatchUpdateToV1(content interface{}) interface{} {
+	matchUpdate, ok := content.(*MatchUpdateMessageContent)
+	if !ok || matchUpdate.Match == nil {
+		return content
+	}
+	matchCopy := *matchUpdate.Match
+	matchCopy.MoveHistory = nil
+	return &MatchUpdateMessageContent{Match: &matchCopy}
+}
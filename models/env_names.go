@@ -7,3 +7,5 @@ const ENV_MATCHMAKING = "matchmaking"
 const ENV_MATCHER_SERVICE = "matcher"
 const ENV_TIMER = "timer"
 const SUB_SERVICE = "sub_service"
+const ENV_PENALTY = "penalty"
+const ENV_GRPC = "grpc"
@@ -0,0 +1,16 @@
+package models
+
+// Standing tallies a client's results in rated matches of a single rating category
+type Standing struct {
+	ClientKey Key `json:"clientKey"`
+	Wins      int `json:"wins"`
+	Draws     int `json:"draws"`
+	Losses    int `json:"losses"`
+	// a win is worth a point and a draw half a point
+	Points float64 `json:"points"`
+}
+
+type Leaderboard struct {
+	RatingCategory RatingCategory `json:"ratingCategory"`
+	Standings      []*Standing    `json:"standings"`
+}
@@ -3,39 +3,117 @@ package models
 import (
 	"fmt"
 	"github.com/CameronHonis/chess"
+	"strings"
 	"time"
 )
 
 type MatchResult string
 
 const (
-	MATCH_RESULT_IN_PROGRESS                   MatchResult = "in_progress"
-	MATCH_RESULT_WHITE_WINS_BY_CHECKMATE       MatchResult = "white_wins_by_checkmate"
-	MATCH_RESULT_BLACK_WINS_BY_CHECKMATE       MatchResult = "black_wins_by_checkmate"
-	MATCH_RESULT_WHITE_WINS_BY_RESIGNATION     MatchResult = "white_wins_by_resignation"
-	MATCH_RESULT_BLACK_WINS_BY_RESIGNATION     MatchResult = "black_wins_by_resignation"
-	MATCH_RESULT_WHITE_WINS_BY_TIMEOUT         MatchResult = "white_wins_by_timeout"
-	MATCH_RESULT_BLACK_WINS_BY_TIMEOUT         MatchResult = "black_wins_by_timeout"
-	MATCH_RESULT_DRAW_BY_STALEMATE             MatchResult = "draw_by_stalemate"
-	MATCH_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL MatchResult = "draw_by_insufficient_material"
-	MATCH_RESULT_DRAW_BY_THREEFOLD_REPETITION  MatchResult = "draw_by_threefold_repetition"
-	MATCH_RESULT_DRAW_BY_FIFTY_MOVE_RULE       MatchResult = "draw_by_fifty_move_rule"
+	MATCH_RESULT_IN_PROGRESS                    MatchResult = "in_progress"
+	MATCH_RESULT_WHITE_WINS_BY_CHECKMATE        MatchResult = "white_wins_by_checkmate"
+	MATCH_RESULT_BLACK_WINS_BY_CHECKMATE        MatchResult = "black_wins_by_checkmate"
+	MATCH_RESULT_WHITE_WINS_BY_RESIGNATION      MatchResult = "white_wins_by_resignation"
+	MATCH_RESULT_BLACK_WINS_BY_RESIGNATION      MatchResult = "black_wins_by_resignation"
+	MATCH_RESULT_WHITE_WINS_BY_TIMEOUT          MatchResult = "white_wins_by_timeout"
+	MATCH_RESULT_BLACK_WINS_BY_TIMEOUT          MatchResult = "black_wins_by_timeout"
+	MATCH_RESULT_DRAW_BY_STALEMATE              MatchResult = "draw_by_stalemate"
+	MATCH_RESULT_DRAW_BY_INSUFFICIENT_MATERIAL  MatchResult = "draw_by_insufficient_material"
+	MATCH_RESULT_DRAW_BY_THREEFOLD_REPETITION   MatchResult = "draw_by_threefold_repetition"
+	MATCH_RESULT_DRAW_BY_FIFTY_MOVE_RULE        MatchResult = "draw_by_fifty_move_rule"
+	MATCH_RESULT_WHITE_WINS_BY_KING_OF_THE_HILL MatchResult = "white_wins_by_king_of_the_hill"
+	MATCH_RESULT_BLACK_WINS_BY_KING_OF_THE_HILL MatchResult = "black_wins_by_king_of_the_hill"
+	MATCH_RESULT_WHITE_WINS_BY_THREE_CHECK      MatchResult = "white_wins_by_three_check"
+	MATCH_RESULT_BLACK_WINS_BY_THREE_CHECK      MatchResult = "black_wins_by_three_check"
+	MATCH_RESULT_BLACK_WINS_BY_HORDE_CAPTURED   MatchResult = "black_wins_by_horde_captured"
 )
 
 type Match struct {
-	Uuid                  string       `json:"uuid"`
-	Board                 *chess.Board `json:"board"`
-	WhiteClientKey        Key          `json:"whiteClientKey"`
-	WhiteTimeRemainingSec float64      `json:"whiteTimeRemainingSec"`
-	BlackClientKey        Key          `json:"blackClientKey"`
-	BlackTimeRemainingSec float64      `json:"blackTimeRemainingSec"`
-	TimeControl           *TimeControl `json:"timeControl"`
-	BotName               string       `json:"botName"`
-	LastMove              *chess.Move  `json:"lastMove"`
-	LastMoveTime          *time.Time   `json:"-"`
-	Result                MatchResult  `json:"result"`
+	Uuid                  string        `json:"uuid"`
+	Board                 *chess.Board  `json:"board"`
+	WhiteClientKey        Key           `json:"whiteClientKey"`
+	WhiteTimeRemainingSec float64       `json:"whiteTimeRemainingSec"`
+	BlackClientKey        Key           `json:"blackClientKey"`
+	BlackTimeRemainingSec float64       `json:"blackTimeRemainingSec"`
+	TimeControl           *TimeControl  `json:"timeControl"`
+	BotName               string        `json:"botName"`
+	StartingFEN           string        `json:"startingFen"`
+	IsRated               bool          `json:"isRated"`
+	Variant               Variant       `json:"variant"`
+	Chess960Position      int           `json:"chess960Position"`
+	CurrentFEN            string        `json:"currentFen"`
+	WhiteCheckCount       int           `json:"whiteCheckCount"`
+	BlackCheckCount       int           `json:"blackCheckCount"`
+	LastMove              *chess.Move   `json:"lastMove"`
+	MoveHistory           []*chess.Move `json:"moveHistory,omitempty"`
+	LastMoveTime          *time.Time    `json:"-"`
+	PlyCount              int           `json:"plyCount"`
+	Result                MatchResult   `json:"result"`
+}
+
+func (m *Match) RatingCategory() RatingCategory {
+	return NewRatingCategory(m.Variant, m.TimeControl)
+}
+
+func (m *Match) IsWhiteTurn() bool {
+	if m.CurrentFEN != "" {
+		fields := strings.Fields(m.CurrentFEN)
+		return len(fields) < 2 || fields[1] == "w"
+	}
+	if m.Board == nil {
+		return true
+	}
+	return m.Board.IsWhiteTurn
 }
 
 func (m *Match) Topic() MessageTopic {
 	return MessageTopic(fmt.Sprintf("match-%s", m.Uuid))
 }
+
+// LoserKey is the client that lost the match, or empty if the match is still in progress or drawn
+func (m *Match) LoserKey() Key {
+	result := string(m.Result)
+	if strings.HasPrefix(result, "white_wins") {
+		return m.BlackClientKey
+	}
+	if strings.HasPrefix(result, "black_wins") {
+		return m.WhiteClientKey
+	}
+	return ""
+}
+
+// WinnerKey is the client that won the match, or empty if the match is still in progress or drawn
+func (m *Match) WinnerKey() Key {
+	result := string(m.Result)
+	if strings.HasPrefix(result, "white_wins") {
+		return m.WhiteClientKey
+	}
+	if strings.HasPrefix(result, "black_wins") {
+		return m.BlackClientKey
+	}
+	return ""
+}
+
+func (m *Match) IsDraw() bool {
+	return strings.HasPrefix(string(m.Result), "draw")
+}
+
+func (m *Match) IsTimeout() bool {
+	return m.Result == MATCH_RESULT_WHITE_WINS_BY_TIMEOUT || m.Result == MATCH_RESULT_BLACK_WINS_BY_TIMEOUT
+}
+
+// HasMoved reports whether the client has made a move in the match yet. Custom positions may start with black to move,
+// in which case black makes the first ply.
+func (m *Match) HasMoved(clientKey Key) bool {
+	isFirstToMove := (clientKey == m.WhiteClientKey) == m.IsWhiteFirstToMove()
+	if isFirstToMove {
+		return m.PlyCount >= 1
+	}
+	return m.PlyCount >= 2
+}
+
+// IsWhiteFirstToMove reads the side to move from the starting fen, matches without one start with white to move
+func (m *Match) IsWhiteFirstToMove() bool {
+	fields := strings.Fields(m.StartingFEN)
+	return len(fields) < 2 || fields[1] != "b"
+}
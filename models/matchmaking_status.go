@@ -0,0 +1,36 @@
+package models
+
+type MatchmakingLeftReason string
+
+const (
+	MATCHMAKING_LEFT_REASON_REQUESTED MatchmakingLeftReason = "requested"
+	MATCHMAKING_LEFT_REASON_MATCHED   MatchmakingLeftReason = "matched"
+	// the client's connection dropped while it was queued
+	MATCHMAKING_LEFT_REASON_DISCONNECTED MatchmakingLeftReason = "disconnected"
+	// the client waited too long and was handed to the bot server instead
+	MATCHMAKING_LEFT_REASON_BOT_FALLBACK MatchmakingLeftReason = "bot_fallback"
+)
+
+type MatchmakingQueue struct {
+	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+}
+
+func NewMatchmakingQueue(timeControl *TimeControl, variant Variant) *MatchmakingQueue {
+	return &MatchmakingQueue{
+		TimeControl: timeControl,
+		Variant:     variant,
+	}
+}
+
+type MatchmakingStatus struct {
+	TimeControl   *TimeControl `json:"timeControl"`
+	Variant       Variant      `json:"variant"`
+	QueuePosition int          `json:"queuePosition"`
+	PoolSize      int          `json:"poolSize"`
+	MinElo        int          `json:"minElo"`
+	MaxElo        int          `json:"maxElo"`
+	WaitedSec     int64        `json:"waitedSec"`
+	// nil until a pairing has been made in the pool
+	EstimatedWaitSec *int64 `json:"estimatedWaitSec"`
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/CameronHonis/chess"
+	"sort"
 )
 
 type MessageTopic string
@@ -14,6 +15,8 @@ type Message struct {
 	Topic       MessageTopic `json:"topic"`
 	ContentType ContentType  `json:"contentType"`
 	Content     interface{}  `json:"content"`
+	// set by the client to tie responses to the request, and echoed on every direct response to it
+	RequestId string `json:"requestId,omitempty"`
 }
 
 func (m *Message) Marshal() ([]byte, error) {
@@ -50,8 +53,50 @@ func UnmarshalToMessage(msgJson []byte) (*Message, error) {
 	return &msg, nil
 }
 
+// RequestIdFromJson recovers the request id from a message that could not be unmarshalled in full
+func RequestIdFromJson(msgJson []byte) string {
+	var msg struct {
+		RequestId string `json:"requestId"`
+	}
+	_ = json.Unmarshal(msgJson, &msg)
+	return msg.RequestId
+}
+
 func UnmarshalMessageContent(contentType ContentType, contentJson []byte) (interface{}, error) {
-	contentStructMap := map[ContentType]interface{}{
+	msgContent, err := NewMessageContent(contentType)
+	if err != nil {
+		return nil, err
+	}
+	contentJsonParseErr := json.Unmarshal(contentJson, msgContent)
+	if contentJsonParseErr != nil {
+		return nil, contentJsonParseErr
+	}
+	return msgContent, nil
+}
+
+// NewMessageContent returns a pointer to the zero value of the content struct registered for the content type
+func NewMessageContent(contentType ContentType) (interface{}, error) {
+	msgContent, ok := newContentStructMap()[contentType]
+	if !ok {
+		return nil, fmt.Errorf("contentStructMap does not specify map between content type %s and existing struct", contentType)
+	}
+	return msgContent, nil
+}
+
+// ContentTypes lists every content type with a registered content struct, sorted by name
+func ContentTypes() []ContentType {
+	contentTypes := make([]ContentType, 0)
+	for contentType := range newContentStructMap() {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Slice(contentTypes, func(i, j int) bool {
+		return contentTypes[i] < contentTypes[j]
+	})
+	return contentTypes
+}
+
+func newContentStructMap() map[ContentType]interface{} {
+	return map[ContentType]interface{}{
 		CONTENT_TYPE_AUTH:                      &AuthMessageContent{},
 		CONTENT_TYPE_REFRESH_AUTH:              &RefreshAuthMessageContent{},
 		CONTENT_TYPE_INVALID_AUTH:              &NoMessageContent{},
@@ -75,16 +120,29 @@ func UnmarshalMessageContent(contentType ContentType, contentJson []byte) (inter
 		CONTENT_TYPE_CHALLENGE_UPDATED:         &ChallengeUpdatedMessageContent{},
 		CONTENT_TYPE_MATCH_CREATION_FAILED:     &MatchCreationFailedMessageContent{},
 		CONTENT_TYPE_MOVE_FAILED:               &MoveFailedMessageContent{},
+		CONTENT_TYPE_SET_CHALLENGE_POLICY:      &SetChallengePolicyMessageContent{},
+		CONTENT_TYPE_INVITE_CHALLENGE_REQUEST:  &InviteChallengeRequestMessageContent{},
+		CONTENT_TYPE_ACCEPT_INVITE_CHALLENGE:   &AcceptInviteChallengeMessageContent{},
+		CONTENT_TYPE_REVOKE_INVITE_CHALLENGE:   &RevokeInviteChallengeMessageContent{},
+		CONTENT_TYPE_MATCHMAKING_JOINED:        &MatchmakingJoinedMessageContent{},
+		CONTENT_TYPE_MATCHMAKING_JOIN_FAILED:   &MatchmakingJoinFailedMessageContent{},
+		CONTENT_TYPE_MATCHMAKING_LEFT:          &MatchmakingLeftMessageContent{},
+		CONTENT_TYPE_MATCHMAKING_STATUS:        &MatchmakingStatusMessageContent{},
+		CONTENT_TYPE_SET_AVOID_LIST:            &SetAvoidListMessageContent{},
+		CONTENT_TYPE_MATCH_FOUND:               &MatchFoundMessageContent{},
+		CONTENT_TYPE_MATCH_CANCELLED:           &MatchCancelledMessageContent{},
+		CONTENT_TYPE_CONFIRM_MATCH:             &ConfirmMatchMessageContent{},
+		CONTENT_TYPE_PENALTIES:                 &PenaltiesMessageContent{},
+		CONTENT_TYPE_GET_PENALTIES:             &GetPenaltiesMessageContent{},
+		CONTENT_TYPE_CREATE_PARTY:              &CreatePartyMessageContent{},
+		CONTENT_TYPE_LEAVE_PARTY:               &NoMessageContent{},
+		CONTENT_TYPE_PARTY_UPDATED:             &PartyUpdatedMessageContent{},
+		CONTENT_TYPE_PARTY_DISBANDED:           &PartyDisbandedMessageContent{},
+		CONTENT_TYPE_ERROR:                     &ErrorMessageContent{},
+		CONTENT_TYPE_ACK:                       &AckMessageContent{},
+		CONTENT_TYPE_HELLO:                     &HelloMessageContent{},
+		CONTENT_TYPE_WELCOME:                   &WelcomeMessageContent{},
 	}
-	msgContent, ok := contentStructMap[contentType]
-	if !ok {
-		return nil, fmt.Errorf("contentStructMap does not specify map between content type %s and existing struct", contentType)
-	}
-	contentJsonParseErr := json.Unmarshal(contentJson, msgContent)
-	if contentJsonParseErr != nil {
-		return nil, contentJsonParseErr
-	}
-	return msgContent, nil
 }
 
 type ContentType string
@@ -102,25 +160,113 @@ const (
 	CONTENT_TYPE_UPGRADE_AUTH_DENIED       ContentType = "UPGRADE_AUTH_DENIED"
 	CONTENT_TYPE_CHALLENGE_REQUEST_FAILED  ContentType = "CHALLENGE_REQUEST_FAILED"
 	CONTENT_TYPE_MATCH_CREATION_FAILED     ContentType = "MATCH_CREATION_FAILED"
+	CONTENT_TYPE_MATCHMAKING_JOINED        ContentType = "MATCHMAKING_JOINED"
+	CONTENT_TYPE_MATCHMAKING_JOIN_FAILED   ContentType = "MATCHMAKING_JOIN_FAILED"
+	CONTENT_TYPE_MATCHMAKING_LEFT          ContentType = "MATCHMAKING_LEFT"
+	CONTENT_TYPE_MATCHMAKING_STATUS        ContentType = "MATCHMAKING_STATUS"
+	CONTENT_TYPE_MATCH_FOUND               ContentType = "MATCH_FOUND"
+	CONTENT_TYPE_MATCH_CANCELLED           ContentType = "MATCH_CANCELLED"
+	CONTENT_TYPE_PENALTIES                 ContentType = "PENALTIES"
+	CONTENT_TYPE_PARTY_UPDATED             ContentType = "PARTY_UPDATED"
+	CONTENT_TYPE_PARTY_DISBANDED           ContentType = "PARTY_DISBANDED"
+	CONTENT_TYPE_ERROR                     ContentType = "ERROR"
+	CONTENT_TYPE_ACK                       ContentType = "ACK"
+	CONTENT_TYPE_WELCOME                   ContentType = "WELCOME"
 
 	// client requests
-	CONTENT_TYPE_REFRESH_AUTH         ContentType = "REFRESH_AUTH"
-	CONTENT_TYPE_EMPTY                ContentType = "EMPTY"
-	CONTENT_TYPE_ECHO                 ContentType = "ECHO"
-	CONTENT_TYPE_JOIN_MATCHMAKING     ContentType = "JOIN_MATCHMAKING"
-	CONTENT_TYPE_LEAVE_MATCHMAKING    ContentType = "LEAVE_MATCHMAKING"
-	CONTENT_TYPE_MOVE                 ContentType = "MOVE"
-	CONTENT_TYPE_RESIGN_MATCH         ContentType = "RESIGN_MATCH"
-	CONTENT_TYPE_SUBSCRIBE_REQUEST    ContentType = "SUBSCRIBE_REQUEST"
-	CONTENT_TYPE_UPGRADE_AUTH_REQUEST ContentType = "UPGRADE_AUTH_REQUEST"
-	CONTENT_TYPE_CHALLENGE_REQUEST    ContentType = "CHALLENGE_REQUEST"
-	CONTENT_TYPE_ACCEPT_CHALLENGE     ContentType = "ACCEPT_CHALLENGE"
-	CONTENT_TYPE_DECLINE_CHALLENGE    ContentType = "DECLINE_CHALLENGE"
-	CONTENT_TYPE_REVOKE_CHALLENGE     ContentType = "REVOKE_CHALLENGE"
+	CONTENT_TYPE_REFRESH_AUTH             ContentType = "REFRESH_AUTH"
+	CONTENT_TYPE_EMPTY                    ContentType = "EMPTY"
+	CONTENT_TYPE_ECHO                     ContentType = "ECHO"
+	CONTENT_TYPE_JOIN_MATCHMAKING         ContentType = "JOIN_MATCHMAKING"
+	CONTENT_TYPE_LEAVE_MATCHMAKING        ContentType = "LEAVE_MATCHMAKING"
+	CONTENT_TYPE_MOVE                     ContentType = "MOVE"
+	CONTENT_TYPE_RESIGN_MATCH             ContentType = "RESIGN_MATCH"
+	CONTENT_TYPE_SUBSCRIBE_REQUEST        ContentType = "SUBSCRIBE_REQUEST"
+	CONTENT_TYPE_UPGRADE_AUTH_REQUEST     ContentType = "UPGRADE_AUTH_REQUEST"
+	CONTENT_TYPE_CHALLENGE_REQUEST        ContentType = "CHALLENGE_REQUEST"
+	CONTENT_TYPE_ACCEPT_CHALLENGE         ContentType = "ACCEPT_CHALLENGE"
+	CONTENT_TYPE_DECLINE_CHALLENGE        ContentType = "DECLINE_CHALLENGE"
+	CONTENT_TYPE_REVOKE_CHALLENGE         ContentType = "REVOKE_CHALLENGE"
+	CONTENT_TYPE_SET_CHALLENGE_POLICY     ContentType = "SET_CHALLENGE_POLICY"
+	CONTENT_TYPE_INVITE_CHALLENGE_REQUEST ContentType = "INVITE_CHALLENGE_REQUEST"
+	CONTENT_TYPE_ACCEPT_INVITE_CHALLENGE  ContentType = "ACCEPT_INVITE_CHALLENGE"
+	CONTENT_TYPE_REVOKE_INVITE_CHALLENGE  ContentType = "REVOKE_INVITE_CHALLENGE"
+	CONTENT_TYPE_SET_AVOID_LIST           ContentType = "SET_AVOID_LIST"
+	CONTENT_TYPE_CONFIRM_MATCH            ContentType = "CONFIRM_MATCH"
+	CONTENT_TYPE_GET_PENALTIES            ContentType = "GET_PENALTIES"
+	CONTENT_TYPE_CREATE_PARTY             ContentType = "CREATE_PARTY"
+	CONTENT_TYPE_LEAVE_PARTY              ContentType = "LEAVE_PARTY"
+	CONTENT_TYPE_HELLO                    ContentType = "HELLO"
+)
+
+type MessageDirection string
+
+const (
+	MESSAGE_DIRECTION_SERVER_TO_CLIENT MessageDirection = "server_to_client"
+	MESSAGE_DIRECTION_CLIENT_TO_SERVER MessageDirection = "client_to_server"
 )
 
+var clientRequestContentTypes = map[ContentType]bool{
+	CONTENT_TYPE_REFRESH_AUTH:             true,
+	CONTENT_TYPE_EMPTY:                    true,
+	CONTENT_TYPE_ECHO:                     true,
+	CONTENT_TYPE_JOIN_MATCHMAKING:         true,
+	CONTENT_TYPE_LEAVE_MATCHMAKING:        true,
+	CONTENT_TYPE_MOVE:                     true,
+	CONTENT_TYPE_RESIGN_MATCH:             true,
+	CONTENT_TYPE_SUBSCRIBE_REQUEST:        true,
+	CONTENT_TYPE_UPGRADE_AUTH_REQUEST:     true,
+	CONTENT_TYPE_CHALLENGE_REQUEST:        true,
+	CONTENT_TYPE_ACCEPT_CHALLENGE:         true,
+	CONTENT_TYPE_DECLINE_CHALLENGE:        true,
+	CONTENT_TYPE_REVOKE_CHALLENGE:         true,
+	CONTENT_TYPE_SET_CHALLENGE_POLICY:     true,
+	CONTENT_TYPE_INVITE_CHALLENGE_REQUEST: true,
+	CONTENT_TYPE_ACCEPT_INVITE_CHALLENGE:  true,
+	CONTENT_TYPE_REVOKE_INVITE_CHALLENGE:  true,
+	CONTENT_TYPE_SET_AVOID_LIST:           true,
+	CONTENT_TYPE_CONFIRM_MATCH:            true,
+	CONTENT_TYPE_GET_PENALTIES:            true,
+	CONTENT_TYPE_CREATE_PARTY:             true,
+	CONTENT_TYPE_LEAVE_PARTY:              true,
+	CONTENT_TYPE_HELLO:                    true,
+}
+
+// Direction reports who sends messages of this content type, which is the arbitrator for any type not listed as a
+// client request
+func (ct ContentType) Direction() MessageDirection {
+	if clientRequestContentTypes[ct] {
+		return MESSAGE_DIRECTION_CLIENT_TO_SERVER
+	}
+	return MESSAGE_DIRECTION_SERVER_TO_CLIENT
+}
+
 type NoMessageContent struct{}
 
+type ErrorMessageContent struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// the content type of the request that failed
+	RequestContentType ContentType `json:"requestContentType"`
+}
+
+type AckMessageContent struct {
+	// the content type of the request that succeeded
+	RequestContentType ContentType `json:"requestContentType"`
+}
+
+type HelloMessageContent struct {
+	ProtocolVersion int       `json:"protocolVersion"`
+	Features        []Feature `json:"features"`
+}
+
+type WelcomeMessageContent struct {
+	// the version both sides have settled on, which the server speaks for the rest of the connection
+	ProtocolVersion int `json:"protocolVersion"`
+	// the client's features that the server has enabled
+	Features []Feature `json:"features"`
+}
+
 type AuthMessageContent struct {
 	PublicKey  Key `json:"publicKey"`
 	PrivateKey Key `json:"privateKey"`
@@ -132,6 +278,22 @@ type RefreshAuthMessageContent struct {
 
 type FindMatchMessageContent struct {
 	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+	// additional queues to wait in alongside TimeControl and Variant
+	Queues           []*MatchmakingQueue `json:"queues"`
+	ColourPreference Colour              `json:"colourPreference"`
+	// opt in to a bot match if no human opponent is found in time
+	BotFallback bool `json:"botFallback"`
+	// queue within this party instead, using the party's time control and variant
+	PartyCode string `json:"partyCode"`
+}
+
+func (c *FindMatchMessageContent) AllQueues() []*MatchmakingQueue {
+	queues := make([]*MatchmakingQueue, 0, len(c.Queues)+1)
+	if c.TimeControl != nil {
+		queues = append(queues, NewMatchmakingQueue(c.TimeControl, c.Variant))
+	}
+	return append(queues, c.Queues...)
 }
 
 type MatchUpdateMessageContent struct {
@@ -212,3 +374,79 @@ type MoveFailedMessageContent struct {
 	Move   *chess.Move `json:"move"`
 	Reason string      `json:"reason"`
 }
+
+type SetChallengePolicyMessageContent struct {
+	Policy *ChallengePolicy `json:"policy"`
+}
+
+type MatchFoundMessageContent struct {
+	ReadyCheck *ReadyCheck `json:"readyCheck"`
+}
+
+type MatchCancelledMessageContent struct {
+	ReadyCheckId string                    `json:"readyCheckId"`
+	Reason       ReadyCheckCancelledReason `json:"reason"`
+}
+
+type ConfirmMatchMessageContent struct {
+	ReadyCheckId string `json:"readyCheckId"`
+}
+
+type SetAvoidListMessageContent struct {
+	// clients that matchmaking must never pair the sender with, until the sender disconnects
+	AvoidedKeys []Key `json:"avoidedKeys"`
+}
+
+type InviteChallengeRequestMessageContent struct {
+	Challenge *Challenge `json:"challenge"`
+}
+
+type AcceptInviteChallengeMessageContent struct {
+	InviteToken string `json:"inviteToken"`
+}
+
+type RevokeInviteChallengeMessageContent struct {
+	InviteToken string `json:"inviteToken"`
+}
+
+type MatchmakingJoinedMessageContent struct {
+	Queues []*MatchmakingQueue `json:"queues"`
+}
+
+type MatchmakingJoinFailedMessageContent struct {
+	Reason string `json:"reason"`
+}
+
+type MatchmakingLeftMessageContent struct {
+	Reason MatchmakingLeftReason `json:"reason"`
+}
+
+type MatchmakingStatusMessageContent struct {
+	Statuses []*MatchmakingStatus `json:"statuses"`
+}
+
+type GetPenaltiesMessageContent struct {
+	// only report this client's penalty, or every penalised client if empty
+	ClientKey Key `json:"clientKey"`
+}
+
+type PenaltiesMessageContent struct {
+	Penalties []*Penalty `json:"penalties"`
+}
+
+type CreatePartyMessageContent struct {
+	Mode        PartyMode    `json:"mode"`
+	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+	// party games are unrated unless the host opts in
+	IsRated bool `json:"isRated"`
+}
+
+type PartyUpdatedMessageContent struct {
+	Party *Party `json:"party"`
+}
+
+type PartyDisbandedMessageContent struct {
+	Code   string               `json:"code"`
+	Reason PartyDisbandedReason `json:"reason"`
+}
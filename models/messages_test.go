@@ -77,5 +77,52 @@ var _ = Describe("Messages", func() {
 				Expect(realMessage).To(Equal(message))
 			})
 		})
+		When("the message has a request id", func() {
+			BeforeEach(func() {
+				messageJson = []byte(`{"topic": "auth", "contentType": "AUTH", "content":{"publicKey":"some-public-key","privateKey":"some-private-key"}, "requestId": "req-1"}`)
+			})
+			It("keeps the request id", func() {
+				realMessage, err := models.UnmarshalToMessage(messageJson)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(realMessage.RequestId).To(Equal("req-1"))
+			})
+		})
+		When("the message type is ERROR", func() {
+			BeforeEach(func() {
+				messageJson = []byte(`{"contentType": "ERROR", "content":{"code":"not_found","message":"no match","requestContentType":"RESIGN_MATCH"}, "requestId": "req-1"}`)
+			})
+			It("returns a message with its Content as an ErrorMessageContent", func() {
+				realMessage, err := models.UnmarshalToMessage(messageJson)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(realMessage.Content).To(Equal(&models.ErrorMessageContent{
+					Code:               models.ERROR_CODE_NOT_FOUND,
+					Message:            "no match",
+					RequestContentType: models.CONTENT_TYPE_RESIGN_MATCH,
+				}))
+			})
+		})
+	})
+})
+
+var _ = Describe("RequestIdFromJson", func() {
+	It("recovers the request id from a message with malformed content", func() {
+		Expect(models.RequestIdFromJson([]byte(`{"contentType": "MOVE", "content": 4, "requestId": "req-1"}`))).To(Equal("req-1"))
+	})
+	It("returns an empty id for malformed json", func() {
+		Expect(models.RequestIdFromJson([]byte(`{"requestId": `))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("AsProtocolError", func() {
+	It("keeps the code of a wrapped protocol error", func() {
+		err := fmt.Errorf("could not resign: %w", models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no match %s", "some-match"))
+		protocolErr := models.AsProtocolError(err, models.ERROR_CODE_INVALID_STATE)
+		Expect(protocolErr.Code).To(Equal(models.ERROR_CODE_NOT_FOUND))
+		Expect(protocolErr.Message).To(Equal("no match some-match"))
+	})
+	It("falls back to the given code for other errors", func() {
+		protocolErr := models.AsProtocolError(fmt.Errorf("client already in matchmaking"), models.ERROR_CODE_INVALID_STATE)
+		Expect(protocolErr.Code).To(Equal(models.ERROR_CODE_INVALID_STATE))
+		Expect(protocolErr.Message).To(Equal("client already in matchmaking"))
 	})
 })
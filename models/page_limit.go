@@ -0,0 +1,17 @@
+package models
+
+const (
+	DEFAULT_PAGE_LIMIT = 20
+	MAX_PAGE_LIMIT     = 100
+)
+
+// VetPageLimit checks how many items a listing was asked for, where 0 asks for DEFAULT_PAGE_LIMIT
+func VetPageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DEFAULT_PAGE_LIMIT, nil
+	}
+	if limit < 1 || limit > MAX_PAGE_LIMIT {
+		return 0, NewProtocolError(ERROR_CODE_BAD_REQUEST, "limit must be between 1 and %d", MAX_PAGE_LIMIT)
+	}
+	return limit, nil
+}
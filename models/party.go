@@ -0,0 +1,32 @@
+package models
+
+type PartyMode string
+
+const (
+	// members are paired with each other, favouring the pairs that have played each other least
+	PARTY_MODE_ROUND_ROBIN PartyMode = "round_robin"
+	// members queue to play the host, first come first served
+	PARTY_MODE_VS_HOST PartyMode = "vs_host"
+)
+
+func (m PartyMode) IsValid() bool {
+	return m == PARTY_MODE_ROUND_ROBIN || m == PARTY_MODE_VS_HOST
+}
+
+type Party struct {
+	Code        string       `json:"code"`
+	HostKey     Key          `json:"hostKey"`
+	Mode        PartyMode    `json:"mode"`
+	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+	IsRated     bool         `json:"isRated"`
+	MemberKeys  []Key        `json:"memberKeys"`
+	// members currently queued for their next game, in the order they queued
+	WaitingKeys []Key `json:"waitingKeys"`
+}
+
+type PartyDisbandedReason string
+
+const (
+	PARTY_DISBANDED_REASON_HOST_LEFT PartyDisbandedReason = "host_left"
+)
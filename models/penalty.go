@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type OffenceKind string
+
+const (
+	OFFENCE_KIND_ABORT               OffenceKind = "abort"
+	OFFENCE_KIND_DISCONNECT_LOSS     OffenceKind = "disconnect_loss"
+	OFFENCE_KIND_CLOCK_STALL         OffenceKind = "clock_stall"
+	OFFENCE_KIND_READY_CHECK_NO_SHOW OffenceKind = "ready_check_no_show"
+)
+
+type Offence struct {
+	Kind OffenceKind `json:"kind"`
+	// the match the offence happened in, if any
+	MatchId    string     `json:"matchId"`
+	OccurredAt *time.Time `json:"occurredAt"`
+}
+
+type Penalty struct {
+	ClientKey Key `json:"clientKey"`
+	// offences that still count towards the next cooldown, oldest first
+	Offences      []*Offence `json:"offences"`
+	CooldownUntil *time.Time `json:"cooldownUntil"`
+}
+
+func (p *Penalty) CooldownRemaining(now time.Time) time.Duration {
+	if p.CooldownUntil == nil || !now.Before(*p.CooldownUntil) {
+		return 0
+	}
+	return p.CooldownUntil.Sub(now)
+}
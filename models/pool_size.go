@@ -0,0 +1,8 @@
+package models
+
+// PoolSize is how many clients are waiting in the matchmaking pool for a time control and variant
+type PoolSize struct {
+	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+	ClientCount int          `json:"clientCount"`
+}
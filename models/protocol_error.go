@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"net/http"
+)
+
+type ErrorCode string
+
+const (
+	ERROR_CODE_UNAUTHORIZED  ErrorCode = "unauthorized"
+	ERROR_CODE_NOT_FOUND     ErrorCode = "not_found"
+	ERROR_CODE_INVALID_STATE ErrorCode = "invalid_state"
+	ERROR_CODE_RATE_LIMITED  ErrorCode = "rate_limited"
+	ERROR_CODE_BAD_REQUEST   ErrorCode = "bad_request"
+)
+
+type apiStatus struct {
+	httpStatus int
+	grpcCode   codes.Code
+}
+
+// apiStatusByErrorCode is how the REST and gRPC APIs answer each error code
+var apiStatusByErrorCode = map[ErrorCode]apiStatus{
+	ERROR_CODE_BAD_REQUEST:   {http.StatusBadRequest, codes.InvalidArgument},
+	ERROR_CODE_UNAUTHORIZED:  {http.StatusUnauthorized, codes.Unauthenticated},
+	ERROR_CODE_NOT_FOUND:     {http.StatusNotFound, codes.NotFound},
+	ERROR_CODE_INVALID_STATE: {http.StatusConflict, codes.FailedPrecondition},
+	ERROR_CODE_RATE_LIMITED:  {http.StatusTooManyRequests, codes.ResourceExhausted},
+}
+
+// ProtocolError is an error reported back to the client, with a code it can act on
+type ProtocolError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func NewProtocolError(code ErrorCode, format string, args ...interface{}) *ProtocolError {
+	return &ProtocolError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+func (e *ProtocolError) HttpStatus() int {
+	if status, ok := apiStatusByErrorCode[e.Code]; ok {
+		return status.httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+func (e *ProtocolError) GrpcCode() codes.Code {
+	if status, ok := apiStatusByErrorCode[e.Code]; ok {
+		return status.grpcCode
+	}
+	return codes.Internal
+}
+
+// AsProtocolError finds the ProtocolError in err's chain, or wraps err in one with the fallback code
+func AsProtocolError(err error, fallbackCode ErrorCode) *ProtocolError {
+	var protocolErr *ProtocolError
+	if errors.As(err, &protocolErr) {
+		return protocolErr
+	}
+	return &ProtocolError{
+		Code:    fallbackCode,
+		Message: err.Error(),
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+const (
+	// clients that never send HELLO are assumed to speak the original protocol
+	PROTOCOL_VERSION_1 = 1
+	// adds the HELLO handshake, request ids and the full move history on matches
+	PROTOCOL_VERSION_2 = 2
+
+	MIN_PROTOCOL_VERSION     = PROTOCOL_VERSION_1
+	CURRENT_PROTOCOL_VERSION = PROTOCOL_VERSION_2
+)
+
+type Feature string
+
+const (
+	FEATURE_REQUEST_IDS  Feature = "request_ids"
+	FEATURE_MOVE_HISTORY Feature = "move_history"
+	FEATURE_READY_CHECK  Feature = "ready_check"
+	FEATURE_PARTIES      Feature = "parties"
+	FEATURE_PENALTIES    Feature = "penalties"
+)
+
+func AllFeatures() []Feature {
+	return []Feature{FEATURE_REQUEST_IDS, FEATURE_MOVE_HISTORY, FEATURE_READY_CHECK, FEATURE_PARTIES, FEATURE_PENALTIES}
+}
+
+// NegotiateProtocolVersion settles on the newest version both sides speak, or false if there is none
+func NegotiateProtocolVersion(clientVersion int) (int, bool) {
+	if clientVersion < MIN_PROTOCOL_VERSION {
+		return 0, false
+	}
+	if clientVersion > CURRENT_PROTOCOL_VERSION {
+		return CURRENT_PROTOCOL_VERSION, true
+	}
+	return clientVersion, true
+}
+
+// NegotiateFeatures keeps the client's features that the server has enabled, in the server's order
+func NegotiateFeatures(clientFeatures []Feature, enabledFeatures []Feature) []Feature {
+	isClientFeature := make(map[Feature]bool)
+	for _, feature := range clientFeatures {
+		isClientFeature[feature] = true
+	}
+	features := make([]Feature, 0)
+	for _, feature := range enabledFeatures {
+		if isClientFeature[feature] {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
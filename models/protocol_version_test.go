@@ -0,0 +1,96 @@
+package models_test
+
+import (
+	"github.com/CameronHonis/chess"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("protocol versions", func() {
+	Describe("NegotiateProtocolVersion", func() {
+		When("the client speaks a version the server knows", func() {
+			It("uses the client's version", func() {
+				version, ok := models.NegotiateProtocolVersion(models.PROTOCOL_VERSION_1)
+				Expect(ok).To(BeTrue())
+				Expect(version).To(Equal(models.PROTOCOL_VERSION_1))
+			})
+		})
+		When("the client is newer than the server", func() {
+			It("falls back to the server's version", func() {
+				version, ok := models.NegotiateProtocolVersion(models.CURRENT_PROTOCOL_VERSION + 1)
+				Expect(ok).To(BeTrue())
+				Expect(version).To(Equal(models.CURRENT_PROTOCOL_VERSION))
+			})
+		})
+		When("the client is older than the oldest supported version", func() {
+			It("fails", func() {
+				_, ok := models.NegotiateProtocolVersion(models.MIN_PROTOCOL_VERSION - 1)
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+	Describe("NegotiateFeatures", func() {
+		It("keeps only the features both sides have", func() {
+			clientFeatures := []models.Feature{models.FEATURE_PARTIES, "some-future-feature", models.FEATURE_REQUEST_IDS}
+			enabledFeatures := []models.Feature{models.FEATURE_REQUEST_IDS, models.FEATURE_MOVE_HISTORY, models.FEATURE_PARTIES}
+			Expect(models.NegotiateFeatures(clientFeatures, enabledFeatures)).To(Equal([]models.Feature{models.FEATURE_REQUEST_IDS, models.FEATURE_PARTIES}))
+		})
+	})
+	Describe("AdaptContent", func() {
+		var matchUpdate *models.MatchUpdateMessageContent
+		BeforeEach(func() {
+			move := &chess.Move{}
+			matchUpdate = &models.MatchUpdateMessageContent{
+				Match: &models.Match{
+					Uuid:        "some-match-id",
+					LastMove:    move,
+					MoveHistory: []*chess.Move{move},
+				},
+			}
+		})
+		When("the client speaks the current version", func() {
+			It("leaves the content alone", func() {
+				Expect(models.AdaptContent(models.CONTENT_TYPE_MATCH_UPDATED, matchUpdate, models.CURRENT_PROTOCOL_VERSION)).To(BeIdenticalTo(matchUpdate))
+			})
+		})
+		When("the client speaks version 1", func() {
+			It("drops the move history but keeps the last move", func() {
+				adapted := models.AdaptContent(models.CONTENT_TYPE_MATCH_UPDATED, matchUpdate, models.PROTOCOL_VERSION_1)
+				adaptedMatch := adapted.(*models.MatchUpdateMessageContent).Match
+				Expect(adaptedMatch.MoveHistory).To(BeNil())
+				Expect(adaptedMatch.LastMove).To(Equal(matchUpdate.Match.LastMove))
+				Expect(adaptedMatch.Uuid).To(Equal("some-match-id"))
+			})
+			It("does not modify the original content", func() {
+				_ = models.AdaptContent(models.CONTENT_TYPE_MATCH_UPDATED, matchUpdate, models.PROTOCOL_VERSION_1)
+				Expect(matchUpdate.Match.MoveHistory).To(HaveLen(1))
+			})
+		})
+		When("no adapter exists for the content type", func() {
+			It("leaves the content alone", func() {
+				content := &models.AckMessageContent{RequestContentType: models.CONTENT_TYPE_MOVE}
+				Expect(models.AdaptContent(models.CONTENT_TYPE_ACK, content, models.PROTOCOL_VERSION_1)).To(BeIdenticalTo(content))
+			})
+		})
+	})
+	Describe("AdaptContentToFeatures", func() {
+		var matchUpdate *models.MatchUpdateMessageContent
+		BeforeEach(func() {
+			move := &chess.Move{}
+			matchUpdate = &models.MatchUpdateMessageContent{
+				Match: &models.Match{LastMove: move, MoveHistory: []*chess.Move{move}},
+			}
+		})
+		It("keeps the move history for clients that negotiated it", func() {
+			features := []models.Feature{models.FEATURE_MOVE_HISTORY}
+			Expect(models.AdaptContentToFeatures(models.CONTENT_TYPE_MATCH_UPDATED, matchUpdate, features)).To(BeIdenticalTo(matchUpdate))
+		})
+		It("drops the move history for clients that did not", func() {
+			features := []models.Feature{models.FEATURE_REQUEST_IDS}
+			adapted := models.AdaptContentToFeatures(models.CONTENT_TYPE_MATCH_UPDATED, matchUpdate, features)
+			Expect(adapted.(*models.MatchUpdateMessageContent).Match.MoveHistory).To(BeNil())
+			Expect(matchUpdate.Match.MoveHistory).To(HaveLen(1))
+		})
+	})
+})
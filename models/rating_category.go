@@ -0,0 +1,7 @@
+package models
+
+type RatingCategory string
+
+func NewRatingCategory(variant Variant, timeControl *TimeControl) RatingCategory {
+	return RatingCategory(string(variant.OrStandard()) + "_" + timeControl.Speed())
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type ReadyCheckCancelledReason string
+
+const (
+	// the recipient did not confirm in time
+	READY_CHECK_CANCELLED_REASON_NO_SHOW ReadyCheckCancelledReason = "no_show"
+	// the recipient confirmed but their opponent did not, the recipient is back at the front of the queue
+	READY_CHECK_CANCELLED_REASON_OPPONENT_NO_SHOW ReadyCheckCancelledReason = "opponent_no_show"
+	// the recipient left matchmaking, or disconnected, before the match started
+	READY_CHECK_CANCELLED_REASON_LEFT ReadyCheckCancelledReason = "left"
+	// the recipient's opponent left before the match started, the recipient is back at the front of the queue
+	READY_CHECK_CANCELLED_REASON_OPPONENT_LEFT ReadyCheckCancelledReason = "opponent_left"
+	// both clients confirmed but the match could not start, whoever isn't playing is back at the front of the queue
+	READY_CHECK_CANCELLED_REASON_START_FAILED ReadyCheckCancelledReason = "start_failed"
+)
+
+type ReadyCheck struct {
+	Uuid        string       `json:"uuid"`
+	ClientKeys  []Key        `json:"clientKeys"`
+	TimeControl *TimeControl `json:"timeControl"`
+	Variant     Variant      `json:"variant"`
+	ExpiresAt   *time.Time   `json:"expiresAt"`
+}
@@ -3,6 +3,7 @@ package models
 type RoleName string
 
 const (
-	PLEB RoleName = "PLEB"
-	BOT           = "BOT"
+	PLEB  RoleName = "PLEB"
+	BOT            = "BOT"
+	ADMIN          = "ADMIN"
 )
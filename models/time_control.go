@@ -22,3 +22,16 @@ func (tc *TimeControl) Hash() string {
 		strconv.FormatInt(tc.TimeAfterMovesCount, 10) +
 		strconv.FormatInt(tc.SecAfterMoves, 10)
 }
+
+// Speed buckets the time control by its estimated game duration, assuming 40 moves per side
+func (tc *TimeControl) Speed() string {
+	estimatedSec := tc.InitialTimeSec + 40*tc.IncrementSec
+	if estimatedSec < 180 {
+		return "bullet"
+	} else if estimatedSec < 480 {
+		return "blitz"
+	} else if estimatedSec < 1500 {
+		return "rapid"
+	}
+	return "classical"
+}
@@ -0,0 +1,30 @@
+package models
+
+type Variant string
+
+const (
+	VARIANT_STANDARD         Variant = "standard"
+	VARIANT_CHESS960         Variant = "chess960"
+	VARIANT_KING_OF_THE_HILL Variant = "king_of_the_hill"
+	VARIANT_THREE_CHECK      Variant = "three_check"
+	VARIANT_HORDE            Variant = "horde"
+)
+
+func (v Variant) IsStandard() bool {
+	return v == "" || v == VARIANT_STANDARD
+}
+
+func (v Variant) IsValid() bool {
+	switch v {
+	case VARIANT_CHESS960, VARIANT_KING_OF_THE_HILL, VARIANT_THREE_CHECK, VARIANT_HORDE:
+		return true
+	}
+	return v.IsStandard()
+}
+
+func (v Variant) OrStandard() Variant {
+	if v == "" {
+		return VARIANT_STANDARD
+	}
+	return v
+}
@@ -0,0 +1,162 @@
+package penalty
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/log"
+	"github.com/CameronHonis/marker"
+	"github.com/CameronHonis/service"
+	"sort"
+	"sync"
+	"time"
+)
+
+type PenaltyServiceI interface {
+	service.ServiceI
+	RecordOffence(clientKey models.Key, kind models.OffenceKind, matchId string) *models.Penalty
+	RecordMatchEnded(match *models.Match, isLoserConnected bool) *models.Penalty
+	CooldownRemaining(clientKey models.Key) time.Duration
+	Penalty(clientKey models.Key) *models.Penalty
+	Penalties() []*models.Penalty
+}
+
+// PenaltyService keeps track of clients that abort, abandon or stall their matches, and of the escalating
+// matchmaking cooldowns they earn for it
+type PenaltyService struct {
+	service.Service
+
+	__dependencies__ marker.Marker
+	Logger           log.LoggerServiceI
+
+	__state__          marker.Marker
+	penaltyByClientKey map[models.Key]*models.Penalty
+	mu                 sync.Mutex
+}
+
+func NewPenaltyService(config *PenaltyServiceConfig) *PenaltyService {
+	penaltyService := &PenaltyService{
+		penaltyByClientKey: make(map[models.Key]*models.Penalty),
+	}
+	penaltyService.Service = *service.NewService(penaltyService, config)
+	return penaltyService
+}
+
+// RecordOffence charges the client with an offence, putting them on the cooldown for however many offences they
+// have committed within the window. An active cooldown is never shortened.
+func (p *PenaltyService) RecordOffence(clientKey models.Key, kind models.OffenceKind, matchId string) *models.Penalty {
+	config := p.Config().(*PenaltyServiceConfig)
+	now := time.Now()
+
+	p.mu.Lock()
+	penalty := p.prunedPenalty(clientKey, now)
+	penalty.Offences = append(penalty.Offences, &models.Offence{
+		Kind:       kind,
+		MatchId:    matchId,
+		OccurredAt: &now,
+	})
+	cooldownUntil := now.Add(config.CooldownForOffenceCount(len(penalty.Offences)))
+	if penalty.CooldownUntil == nil || cooldownUntil.After(*penalty.CooldownUntil) {
+		penalty.CooldownUntil = &cooldownUntil
+	}
+	p.penaltyByClientKey[clientKey] = penalty
+	penaltyCopy := copyPenalty(penalty)
+	p.mu.Unlock()
+
+	p.Logger.Log(models.ENV_PENALTY, fmt.Sprintf("client %s committed offence %s (%d in window), cooldown %s", clientKey, kind, len(penaltyCopy.Offences), penaltyCopy.CooldownRemaining(now).Round(time.Second)))
+	return penaltyCopy
+}
+
+// RecordMatchEnded charges the loser of the match if they aborted it before moving, lost it on time while
+// disconnected, or let their clock run out rather than play on. Matches against bots are never penalised.
+func (p *PenaltyService) RecordMatchEnded(match *models.Match, isLoserConnected bool) *models.Penalty {
+	if match.BotName != "" {
+		return nil
+	}
+	loserKey := match.LoserKey()
+	if loserKey == "" {
+		return nil
+	}
+	kind, isOffence := p.offenceForMatch(match, loserKey, isLoserConnected)
+	if !isOffence {
+		return nil
+	}
+	return p.RecordOffence(loserKey, kind, match.Uuid)
+}
+
+func (p *PenaltyService) offenceForMatch(match *models.Match, loserKey models.Key, isLoserConnected bool) (models.OffenceKind, bool) {
+	if !match.HasMoved(loserKey) {
+		return models.OFFENCE_KIND_ABORT, true
+	}
+	if !match.IsTimeout() {
+		return "", false
+	}
+	if !isLoserConnected {
+		return models.OFFENCE_KIND_DISCONNECT_LOSS, true
+	}
+	// NOTE: the loser's clock only runs from the last move, so this is how long they sat on it before flagging
+	config := p.Config().(*PenaltyServiceConfig)
+	stallThreshold := config.StallThreshold(match.TimeControl)
+	if match.LastMoveTime != nil && stallThreshold > 0 && time.Since(*match.LastMoveTime) >= stallThreshold {
+		return models.OFFENCE_KIND_CLOCK_STALL, true
+	}
+	return "", false
+}
+
+func (p *PenaltyService) CooldownRemaining(clientKey models.Key) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	penalty, ok := p.penaltyByClientKey[clientKey]
+	if !ok {
+		return 0
+	}
+	return penalty.CooldownRemaining(time.Now())
+}
+
+func (p *PenaltyService) Penalty(clientKey models.Key) *models.Penalty {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return copyPenalty(p.prunedPenalty(clientKey, time.Now()))
+}
+
+// Penalties lists every client with offences still in the window or a cooldown still running, by client key
+func (p *PenaltyService) Penalties() []*models.Penalty {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	penalties := make([]*models.Penalty, 0)
+	for clientKey := range p.penaltyByClientKey {
+		penalty := p.prunedPenalty(clientKey, now)
+		if len(penalty.Offences) == 0 && penalty.CooldownRemaining(now) == 0 {
+			delete(p.penaltyByClientKey, clientKey)
+			continue
+		}
+		penalties = append(penalties, copyPenalty(penalty))
+	}
+	sort.Slice(penalties, func(i, j int) bool {
+		return penalties[i].ClientKey < penalties[j].ClientKey
+	})
+	return penalties
+}
+
+// prunedPenalty drops offences that have fallen out of the window. Callers must hold p.mu.
+func (p *PenaltyService) prunedPenalty(clientKey models.Key, now time.Time) *models.Penalty {
+	config := p.Config().(*PenaltyServiceConfig)
+	penalty, ok := p.penaltyByClientKey[clientKey]
+	if !ok {
+		return &models.Penalty{ClientKey: clientKey, Offences: make([]*models.Offence, 0)}
+	}
+	offences := make([]*models.Offence, 0, len(penalty.Offences))
+	for _, offence := range penalty.Offences {
+		if now.Sub(*offence.OccurredAt) < config.OffenceWindow {
+			offences = append(offences, offence)
+		}
+	}
+	penalty.Offences = offences
+	return penalty
+}
+
+func copyPenalty(penalty *models.Penalty) *models.Penalty {
+	penaltyCopy := *penalty
+	penaltyCopy.Offences = append(make([]*models.Offence, 0, len(penalty.Offences)), penalty.Offences...)
+	return &penaltyCopy
+}
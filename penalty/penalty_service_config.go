@@ -0,0 +1,46 @@
+package penalty
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/service"
+	"time"
+)
+
+type PenaltyServiceConfig struct {
+	service.ConfigI
+	// matchmaking cooldown after the nth offence within OffenceWindow, the last entry applies to any further offences
+	Cooldowns []time.Duration
+	// offences older than this no longer count towards the next cooldown
+	OffenceWindow time.Duration
+	// a timeout loss counts as stalling when the loser stopped moving with at least this fraction of the time control's
+	// initial time left on their clock
+	StallFraction float64
+}
+
+func NewPenaltyServiceConfig() *PenaltyServiceConfig {
+	return &PenaltyServiceConfig{
+		Cooldowns:     []time.Duration{0, time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour},
+		OffenceWindow: 2 * time.Hour,
+		StallFraction: 0.25,
+	}
+}
+
+// CooldownForOffenceCount is the cooldown applied on the client's offenceCount'th offence within the window
+func (c *PenaltyServiceConfig) CooldownForOffenceCount(offenceCount int) time.Duration {
+	if len(c.Cooldowns) == 0 || offenceCount < 1 {
+		return 0
+	}
+	if offenceCount > len(c.Cooldowns) {
+		return c.Cooldowns[len(c.Cooldowns)-1]
+	}
+	return c.Cooldowns[offenceCount-1]
+}
+
+// StallThreshold is how long the loser must have sat on their clock before flagging for the loss to count as stalling,
+// or 0 if the match has no time control to measure it against
+func (c *PenaltyServiceConfig) StallThreshold(timeControl *models.TimeControl) time.Duration {
+	if timeControl == nil {
+		return 0
+	}
+	return time.Duration(c.StallFraction * float64(timeControl.InitialTimeSec) * float64(time.Second))
+}
@@ -0,0 +1,146 @@
+package penalty_test
+
+import (
+	"github.com/CameronHonis/chess-arbitrator/builders"
+	"github.com/CameronHonis/chess-arbitrator/helpers/mocks"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/chess-arbitrator/penalty"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"time"
+)
+
+func BuildServices(ctrl *gomock.Controller) *penalty.PenaltyService {
+	logServiceMock := mocks.NewMockLoggerServiceI(ctrl)
+	logServiceMock.EXPECT().SetParent(gomock.Any()).AnyTimes()
+	logServiceMock.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+
+	penaltyService := penalty.NewPenaltyService(penalty.NewPenaltyServiceConfig())
+	penaltyService.AddDependency(logServiceMock)
+	return penaltyService
+}
+
+var _ = Describe("PenaltyService", func() {
+	var penaltyService *penalty.PenaltyService
+	var config *penalty.PenaltyServiceConfig
+	BeforeEach(func() {
+		ctrl := gomock.NewController(T, gomock.WithOverridableExpectations())
+		penaltyService = BuildServices(ctrl)
+		config = penaltyService.Config().(*penalty.PenaltyServiceConfig)
+		config.Cooldowns = []time.Duration{0, time.Minute, 5 * time.Minute}
+	})
+	Describe("::RecordOffence", func() {
+		It("lets a first offence go with a warning", func() {
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeZero())
+			Expect(penaltyService.Penalty("client-a").Offences).To(HaveLen(1))
+		})
+		It("escalates the cooldown with each further offence", func() {
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_DISCONNECT_LOSS, "match-2")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeNumerically("~", time.Minute, time.Second))
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_CLOCK_STALL, "match-3")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeNumerically("~", 5*time.Minute, time.Second))
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_READY_CHECK_NO_SHOW, "")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeNumerically("~", 5*time.Minute, time.Second))
+		})
+		It("only counts offences within the window", func() {
+			config.OffenceWindow = 50 * time.Millisecond
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			time.Sleep(100 * time.Millisecond)
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-2")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeZero())
+		})
+		It("never shortens a running cooldown", func() {
+			config.Cooldowns = []time.Duration{time.Hour, time.Minute}
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-2")
+			Expect(penaltyService.CooldownRemaining("client-a")).To(BeNumerically(">", 59*time.Minute))
+		})
+		It("keeps clients' penalties apart", func() {
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-2")
+			Expect(penaltyService.CooldownRemaining("client-b")).To(BeZero())
+		})
+	})
+	Describe("::RecordMatchEnded", func() {
+		var matchBuilder *builders.MatchBuilder
+		BeforeEach(func() {
+			matchBuilder = builders.NewMatchBuilder().
+				WithWhiteClientKey("client-white").
+				WithBlackClientKey("client-black")
+		})
+		It("charges a client that resigns before making a move with an abort", func() {
+			match := matchBuilder.WithPlyCount(1).WithResult(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION).Build()
+			penalty := penaltyService.RecordMatchEnded(match, true)
+			Expect(penalty).ToNot(BeNil())
+			Expect(penalty.ClientKey).To(Equal(models.Key("client-black")))
+			Expect(penalty.Offences[0].Kind).To(Equal(models.OFFENCE_KIND_ABORT))
+			Expect(penalty.Offences[0].MatchId).To(Equal(match.Uuid))
+		})
+		It("charges a client that loses on time while disconnected", func() {
+			match := matchBuilder.WithPlyCount(10).WithResult(models.MATCH_RESULT_BLACK_WINS_BY_TIMEOUT).Build()
+			penalty := penaltyService.RecordMatchEnded(match, false)
+			Expect(penalty).ToNot(BeNil())
+			Expect(penalty.ClientKey).To(Equal(models.Key("client-white")))
+			Expect(penalty.Offences[0].Kind).To(Equal(models.OFFENCE_KIND_DISCONNECT_LOSS))
+		})
+		It("charges black with an abort when black had the first move from a custom position and never made it", func() {
+			match := matchBuilder.WithStartingFEN("4k3/8/8/8/8/8/8/4K3 b - - 0 1").WithPlyCount(1).WithResult(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION).Build()
+			Expect(penaltyService.RecordMatchEnded(match, true)).To(BeNil())
+			match = matchBuilder.WithPlyCount(0).Build()
+			penalty := penaltyService.RecordMatchEnded(match, true)
+			Expect(penalty).ToNot(BeNil())
+			Expect(penalty.Offences[0].Kind).To(Equal(models.OFFENCE_KIND_ABORT))
+		})
+		It("charges a client that lets their clock run down instead of playing on", func() {
+			timeControl := builders.NewBlitzTimeControl()
+			lastMoveTime := time.Now().Add(-2 * config.StallThreshold(timeControl))
+			match := matchBuilder.WithTimeControl(timeControl).WithPlyCount(10).WithLastMoveTime(&lastMoveTime).WithResult(models.MATCH_RESULT_BLACK_WINS_BY_TIMEOUT).Build()
+			penalty := penaltyService.RecordMatchEnded(match, true)
+			Expect(penalty).ToNot(BeNil())
+			Expect(penalty.Offences[0].Kind).To(Equal(models.OFFENCE_KIND_CLOCK_STALL))
+		})
+		It("measures stalling against the time control", func() {
+			lastMoveTime := time.Now().Add(-30 * time.Second)
+			bulletMatch := matchBuilder.WithTimeControl(builders.NewBulletTimeControl()).WithPlyCount(10).WithLastMoveTime(&lastMoveTime).WithResult(models.MATCH_RESULT_BLACK_WINS_BY_TIMEOUT).Build()
+			Expect(penaltyService.RecordMatchEnded(bulletMatch, true)).ToNot(BeNil())
+			rapidMatch := builders.NewMatchBuilder().FromMatch(bulletMatch).WithTimeControl(builders.NewRapidTimeControl()).Build()
+			Expect(penaltyService.RecordMatchEnded(rapidMatch, true)).To(BeNil())
+		})
+		It("does not charge a client that lost on time while playing", func() {
+			lastMoveTime := time.Now().Add(-time.Second)
+			match := matchBuilder.WithPlyCount(10).WithLastMoveTime(&lastMoveTime).WithResult(models.MATCH_RESULT_BLACK_WINS_BY_TIMEOUT).Build()
+			Expect(penaltyService.RecordMatchEnded(match, true)).To(BeNil())
+		})
+		It("does not charge a client that resigned a game in progress", func() {
+			match := matchBuilder.WithPlyCount(10).WithResult(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION).Build()
+			Expect(penaltyService.RecordMatchEnded(match, false)).To(BeNil())
+		})
+		It("does not charge anyone for a draw", func() {
+			match := matchBuilder.WithResult(models.MATCH_RESULT_DRAW_BY_STALEMATE).Build()
+			Expect(penaltyService.RecordMatchEnded(match, false)).To(BeNil())
+		})
+		It("does not charge anyone in a bot match", func() {
+			match := matchBuilder.WithBotName("bot-beginner").WithResult(models.MATCH_RESULT_WHITE_WINS_BY_RESIGNATION).Build()
+			Expect(penaltyService.RecordMatchEnded(match, false)).To(BeNil())
+		})
+	})
+	Describe("::Penalties", func() {
+		It("lists penalised clients by key", func() {
+			penaltyService.RecordOffence("client-b", models.OFFENCE_KIND_ABORT, "match-1")
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-2")
+			penalties := penaltyService.Penalties()
+			Expect(penalties).To(HaveLen(2))
+			Expect(penalties[0].ClientKey).To(Equal(models.Key("client-a")))
+			Expect(penalties[1].ClientKey).To(Equal(models.Key("client-b")))
+		})
+		It("drops clients whose offences and cooldowns have lapsed", func() {
+			config.OffenceWindow = 50 * time.Millisecond
+			penaltyService.RecordOffence("client-a", models.OFFENCE_KIND_ABORT, "match-1")
+			time.Sleep(100 * time.Millisecond)
+			Expect(penaltyService.Penalties()).To(BeEmpty())
+		})
+	})
+})
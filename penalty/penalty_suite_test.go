@@ -0,0 +1,16 @@
+package penalty_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var T *testing.T
+
+func TestPenaltyService(t *testing.T) {
+	T = t
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PenaltyService Suite")
+}
@@ -0,0 +1,99 @@
+package router_service
+
+import (
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HandleEventStream serves GET /events?topic=<topic> as server-sent events, one per broadcast on the topic. Public
+// topics can be followed by anyone, other topics need the creds of a client the topic ACL admits.
+func (rs *RouterService) HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	topic := models.MessageTopic(r.URL.Query().Get("topic"))
+	if topic == "" {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "missing topic"))
+		return
+	}
+	if !rs.ClientsManager.IsPublicTopic(topic) {
+		clientKey, authErr := rs.authenticateStream(r)
+		if authErr != nil {
+			writeApiError(w, authErr)
+			return
+		}
+		if aclErr := rs.AuthService.VetClientForTopic(clientKey, topic); aclErr != nil {
+			writeApiError(w, models.NewProtocolError(models.ERROR_CODE_UNAUTHORIZED, "not allowed to follow %s", topic))
+			return
+		}
+	}
+	lastEventId, idErr := lastEventId(r)
+	if idErr != nil {
+		writeApiError(w, idErr)
+		return
+	}
+
+	ip := remoteIP(r)
+	if !rs.acquireConnSlot(ip) {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_RATE_LIMITED, "too many connections"))
+		return
+	}
+	defer rs.releaseConnSlot(ip)
+
+	stream, streamErr := rs.ClientsManager.OpenStream(topic, lastEventId)
+	if streamErr != nil {
+		writeApiError(w, streamErr)
+		return
+	}
+	defer rs.ClientsManager.CloseStream(stream)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	config := rs.Config().(*RouterServiceConfig)
+	keepAlive := time.NewTicker(config.StreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-stream.Done():
+			return
+		case event := <-stream.Events():
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.ContentType, event.Data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// lastEventId reads the id browsers send when an EventSource reconnects, or the lastEventId query param for the
+// first connection of a consumer resuming by hand
+func lastEventId(r *http.Request) (uint64, error) {
+	idParam := r.Header.Get("Last-Event-ID")
+	if idParam == "" {
+		idParam = r.URL.Query().Get("lastEventId")
+	}
+	if idParam == "" {
+		return 0, nil
+	}
+	id, parseErr := strconv.ParseUint(idParam, 10, 64)
+	if parseErr != nil {
+		return 0, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "malformed last event id %s", idParam)
+	}
+	return id, nil
+}
@@ -0,0 +1,234 @@
+package router_service
+
+import (
+	"encoding/json"
+	"github.com/CameronHonis/chess-arbitrator/auth"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (rs *RouterService) HandleGetInviteChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	inviteToken := strings.TrimPrefix(r.URL.Path, "/invites/")
+	challenge, challengeErr := rs.MatcherService.InviteChallenge(inviteToken)
+	if challengeErr != nil {
+		http.Error(w, challengeErr.Error(), http.StatusNotFound)
+		return
+	}
+	writeJson(w, challenge)
+}
+
+// HandleMatches serves GET /api/matches/live, GET /api/matches/{id} and POST /api/matches/{id}/resign
+func (rs *RouterService) HandleMatches(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/matches/"), "/")
+	switch {
+	case len(pathParts) == 1 && pathParts[0] == "live":
+		if requireMethod(w, r, http.MethodGet) {
+			writeJson(w, rs.MatcherService.LiveMatches())
+		}
+	case len(pathParts) == 1 && pathParts[0] != "":
+		if requireMethod(w, r, http.MethodGet) {
+			rs.handleGetMatch(w, pathParts[0])
+		}
+	case len(pathParts) == 2 && pathParts[1] == "resign":
+		if requireMethod(w, r, http.MethodPost) {
+			rs.handlePostResign(w, r, pathParts[0])
+		}
+	default:
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no route for %s", r.URL.Path))
+	}
+}
+
+func (rs *RouterService) handleGetMatch(w http.ResponseWriter, matchId string) {
+	if match, err := rs.MatcherService.MatchById(matchId); err == nil {
+		writeJson(w, match)
+		return
+	}
+	match, err := rs.MatcherService.EndedMatchById(matchId)
+	if err != nil {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no match with id %s", matchId))
+		return
+	}
+	writeJson(w, match)
+}
+
+func (rs *RouterService) handlePostResign(w http.ResponseWriter, r *http.Request, matchId string) {
+	clientKey, authErr := rs.authenticate(r)
+	if authErr != nil {
+		writeApiError(w, authErr)
+		return
+	}
+	if _, err := rs.MatcherService.MatchById(matchId); err != nil {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no live match with id %s", matchId))
+		return
+	}
+	if err := rs.MatcherService.ResignMatch(matchId, clientKey); err != nil {
+		writeApiError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetPlayerMatches serves GET /api/players/{clientKey}/matches
+func (rs *RouterService) HandleGetPlayerMatches(w http.ResponseWriter, r *http.Request) {
+	subPath := strings.TrimPrefix(r.URL.Path, "/api/players/")
+	clientKey := strings.TrimSuffix(subPath, "/matches")
+	if clientKey == subPath || clientKey == "" || strings.Contains(clientKey, "/") {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no route for %s", r.URL.Path))
+		return
+	}
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	limit, limitErr := pageLimit(r)
+	if limitErr != nil {
+		writeApiError(w, limitErr)
+		return
+	}
+	writeJson(w, rs.MatcherService.RecentMatches(models.Key(clientKey), limit))
+}
+
+// HandleGetPools serves GET /api/pools
+func (rs *RouterService) HandleGetPools(w http.ResponseWriter, r *http.Request) {
+	if requireMethod(w, r, http.MethodGet) {
+		writeJson(w, rs.MatchmakingService.PoolSizes())
+	}
+}
+
+// HandleGetLeaderboard serves GET /api/leaderboards/{ratingCategory}
+func (rs *RouterService) HandleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	category := strings.TrimPrefix(r.URL.Path, "/api/leaderboards/")
+	if category == "" || strings.Contains(category, "/") {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_NOT_FOUND, "no route for %s", r.URL.Path))
+		return
+	}
+	limit, limitErr := pageLimit(r)
+	if limitErr != nil {
+		writeApiError(w, limitErr)
+		return
+	}
+	writeJson(w, rs.MatcherService.Leaderboard(models.RatingCategory(category), limit))
+}
+
+// HandlePostChallenge serves POST /api/challenges, with the challenge in the body. The challenger is always the
+// authenticated client.
+func (rs *RouterService) HandlePostChallenge(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	clientKey, authErr := rs.authenticate(r)
+	if authErr != nil {
+		writeApiError(w, authErr)
+		return
+	}
+	challenge := &models.Challenge{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(challenge); decodeErr != nil {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "malformed challenge: %s", decodeErr))
+		return
+	}
+	challenge.ChallengerKey = clientKey
+	if err := rs.MatcherService.RequestChallenge(challenge); err != nil {
+		writeApiError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandlePostAcceptChallenge serves POST /api/challenges/accept, accepting the challenge from the challenger in the body
+func (rs *RouterService) HandlePostAcceptChallenge(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	clientKey, authErr := rs.authenticate(r)
+	if authErr != nil {
+		writeApiError(w, authErr)
+		return
+	}
+	accept := &models.AcceptChallengeMessageContent{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(accept); decodeErr != nil {
+		writeApiError(w, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "malformed accept: %s", decodeErr))
+		return
+	}
+	if err := rs.MatcherService.AcceptChallenge(accept.ChallengerClientKey, clientKey); err != nil {
+		writeApiError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authenticate vets the creds a client received over the websocket, sent as the X-Client-Key header and a bearer token
+// holding the private key
+func (rs *RouterService) authenticate(r *http.Request) (models.Key, error) {
+	clientKey := models.Key(r.Header.Get("X-Client-Key"))
+	if err := auth.VetCreds(rs.AuthService, clientKey, auth.BearerToken(r.Header.Get("Authorization"))); err != nil {
+		return "", err
+	}
+	return clientKey, nil
+}
+
+// authenticateStream is authenticate for GET /events. Browsers can't set headers on an EventSource, so the clientKey
+// and token query params are accepted there, and only there. NOTE: query params end up in access logs, browser
+// history and proxies, exposing the private key until the client next refreshes it, so clients that can set headers
+// should.
+func (rs *RouterService) authenticateStream(r *http.Request) (models.Key, error) {
+	if r.Header.Get("X-Client-Key") != "" || r.Header.Get("Authorization") != "" {
+		return rs.authenticate(r)
+	}
+	clientKey := models.Key(r.URL.Query().Get("clientKey"))
+	if err := auth.VetCreds(rs.AuthService, clientKey, models.Key(r.URL.Query().Get("token"))); err != nil {
+		return "", err
+	}
+	return clientKey, nil
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeErrorBody(w, http.StatusMethodNotAllowed, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "method %s not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+func pageLimit(r *http.Request) (int, error) {
+	limitParam := r.URL.Query().Get("limit")
+	if limitParam == "" {
+		return models.DEFAULT_PAGE_LIMIT, nil
+	}
+	limit, parseErr := strconv.Atoi(limitParam)
+	if parseErr != nil || limit == 0 {
+		return 0, models.NewProtocolError(models.ERROR_CODE_BAD_REQUEST, "limit must be between 1 and %d", models.MAX_PAGE_LIMIT)
+	}
+	return models.VetPageLimit(limit)
+}
+
+func writeApiError(w http.ResponseWriter, err error) {
+	protocolErr := models.AsProtocolError(err, models.ERROR_CODE_INVALID_STATE)
+	writeErrorBody(w, protocolErr.HttpStatus(), protocolErr)
+}
+
+func writeErrorBody(w http.ResponseWriter, status int, protocolErr *models.ProtocolError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&models.ErrorMessageContent{
+		Code:    protocolErr.Code,
+		Message: protocolErr.Message,
+	})
+}
+
+func writeJson(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,12 +2,17 @@ package router_service
 
 import (
 	"context"
+	"github.com/CameronHonis/chess-arbitrator/auth"
 	"github.com/CameronHonis/chess-arbitrator/clients_manager"
+	"github.com/CameronHonis/chess-arbitrator/matcher"
+	"github.com/CameronHonis/chess-arbitrator/matchmaking"
 	"github.com/CameronHonis/chess-arbitrator/models"
 	"github.com/CameronHonis/log"
 	"github.com/CameronHonis/marker"
 	"github.com/CameronHonis/service"
+	"net"
 	"net/http"
+	"sync"
 )
 import "fmt"
 import "github.com/gorilla/websocket"
@@ -20,16 +25,23 @@ type RouterServiceI interface {
 type RouterService struct {
 	service.Service
 
-	__dependencies__ marker.Marker
-	ClientsManager   clients_manager.ClientsManagerI
-	Logger           log.LoggerServiceI
+	__dependencies__   marker.Marker
+	ClientsManager     clients_manager.ClientsManagerI
+	MatcherService     matcher.MatcherServiceI
+	MatchmakingService matchmaking.MatchmakingServiceI
+	AuthService        auth.AuthenticationServiceI
+	Logger             log.LoggerServiceI
 
-	__state__ marker.Marker
-	server    *http.Server
+	__state__     marker.Marker
+	server        *http.Server
+	connCountByIP map[string]int
+	mu            sync.Mutex
 }
 
 func NewRouterService(config *RouterServiceConfig) *RouterService {
-	routerService := &RouterService{}
+	routerService := &RouterService{
+		connCountByIP: make(map[string]int),
+	}
 	routerService.Service = *service.NewService(routerService, config)
 	return routerService
 }
@@ -45,14 +57,15 @@ func (rs *RouterService) OnStop() {
 }
 
 func (rs *RouterService) StartWSServer() {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		conn, connErr := upgradeToWSCon(w, r)
-		if connErr != nil {
-			rs.Logger.LogRed(models.ENV_SERVER, "error upgrading to ws conn:", connErr)
-			return
-		}
-		rs.ClientsManager.AddConn(conn)
-	})
+	http.HandleFunc("/", rs.handleWSConn)
+	http.HandleFunc("/invites/", rs.HandleGetInviteChallenge)
+	http.HandleFunc("/api/matches/", rs.HandleMatches)
+	http.HandleFunc("/api/players/", rs.HandleGetPlayerMatches)
+	http.HandleFunc("/api/pools", rs.HandleGetPools)
+	http.HandleFunc("/api/leaderboards/", rs.HandleGetLeaderboard)
+	http.HandleFunc("/api/challenges", rs.HandlePostChallenge)
+	http.HandleFunc("/api/challenges/accept", rs.HandlePostAcceptChallenge)
+	http.HandleFunc("/events", rs.HandleEventStream)
 
 	config := rs.Config().(*RouterServiceConfig)
 	port := config.Port
@@ -67,6 +80,53 @@ func (rs *RouterService) StartWSServer() {
 	}
 }
 
+func (rs *RouterService) handleWSConn(w http.ResponseWriter, r *http.Request) {
+	config := rs.Config().(*RouterServiceConfig)
+	ip := remoteIP(r)
+	if !rs.acquireConnSlot(ip) {
+		rs.Logger.LogRed(models.ENV_SERVER, "too many connections from", ip)
+		http.Error(w, "too many connections", http.StatusTooManyRequests)
+		return
+	}
+	defer rs.releaseConnSlot(ip)
+
+	conn, connErr := upgradeToWSCon(w, r)
+	if connErr != nil {
+		rs.Logger.LogRed(models.ENV_SERVER, "error upgrading to ws conn:", connErr)
+		return
+	}
+	conn.SetReadLimit(config.MaxFrameSize)
+	rs.ClientsManager.ServeConn(conn)
+}
+
+func remoteIP(r *http.Request) string {
+	ip, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+	if splitErr != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+func (rs *RouterService) acquireConnSlot(ip string) bool {
+	config := rs.Config().(*RouterServiceConfig)
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if config.MaxConnsPerIP > 0 && rs.connCountByIP[ip] >= config.MaxConnsPerIP {
+		return false
+	}
+	rs.connCountByIP[ip]++
+	return true
+}
+
+func (rs *RouterService) releaseConnSlot(ip string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.connCountByIP[ip]--
+	if rs.connCountByIP[ip] <= 0 {
+		delete(rs.connCountByIP, ip)
+	}
+}
+
 func upgradeToWSCon(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -4,10 +4,17 @@ import (
 	"github.com/CameronHonis/service"
 	"os"
 	"strconv"
+	"time"
 )
 
 type RouterServiceConfig struct {
 	Port uint
+	// the largest websocket frame a client may send, in bytes
+	MaxFrameSize int64
+	// how many websockets may be open from a single IP, or 0 for no cap
+	MaxConnsPerIP int
+	// how often an idle event stream is sent a comment, so proxies don't time it out
+	StreamKeepAlive time.Duration
 }
 
 func NewRouterServiceConfig() *RouterServiceConfig {
@@ -19,7 +26,10 @@ func NewRouterServiceConfig() *RouterServiceConfig {
 		port = 8080
 	}
 	return &RouterServiceConfig{
-		Port: port,
+		Port:            port,
+		MaxFrameSize:    64 * 1024,
+		MaxConnsPerIP:   32,
+		StreamKeepAlive: 15 * time.Second,
 	}
 }
 
@@ -0,0 +1,162 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	JSON_SCHEMA_DIALECT = "https://json-schema.org/draft/2020-12/schema"
+	MODULE_PATH         = "github.com/CameronHonis/chess-arbitrator"
+)
+
+// Schema is the subset of JSON Schema needed to describe the message protocol. Keywords prefixed with x- are
+// extensions that validators ignore.
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	Direction            string             `json:"x-direction,omitempty"`
+	ProtocolVersion      int                `json:"x-protocolVersion,omitempty"`
+}
+
+// Generate describes every registered content type as a message envelope with its direction and payload
+func Generate() *Schema {
+	g := &generator{defs: make(map[string]*Schema)}
+	root := &Schema{
+		Dialect:         JSON_SCHEMA_DIALECT,
+		Title:           "chess-arbitrator messages",
+		Description:     "Every message exchanged with the arbitrator over the websocket, generated from the Go content structs",
+		ProtocolVersion: models.CURRENT_PROTOCOL_VERSION,
+		OneOf:           make([]*Schema, 0),
+		Defs:            g.defs,
+	}
+	for _, contentType := range models.ContentTypes() {
+		content, _ := models.NewMessageContent(contentType)
+		defName := fmt.Sprintf("%s_MESSAGE", contentType)
+		g.defs[defName] = g.envelope(contentType, g.schemaFor(reflect.TypeOf(content)))
+		root.OneOf = append(root.OneOf, &Schema{Ref: "#/$defs/" + defName})
+	}
+	return root
+}
+
+// Marshal renders the generated schema the way it is checked in
+func Marshal() ([]byte, error) {
+	schemaJson, err := json.MarshalIndent(Generate(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(schemaJson, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+func (g *generator) envelope(contentType models.ContentType, content *Schema) *Schema {
+	envelope := g.structSchema(reflect.TypeOf(models.Message{}))
+	envelope.Properties["contentType"] = &Schema{Type: "string", Const: string(contentType)}
+	envelope.Properties["content"] = content
+	envelope.Direction = string(contentType.Direction())
+	return envelope
+}
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: "integer", Description: "nanoseconds"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structRef points at the struct's definition, adding it on first use. Structs from other modules, like the chess
+// library, are left opaque so the schema only has to track types owned by this repo.
+func (g *generator) structRef(t reflect.Type) *Schema {
+	if !strings.HasPrefix(t.PkgPath(), MODULE_PATH) {
+		return &Schema{Type: "object", Description: fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())}
+	}
+	if _, ok := g.defs[t.Name()]; !ok {
+		// NOTE: reserve the name before recursing so self-referencing structs terminate
+		g.defs[t.Name()] = &Schema{}
+		*g.defs[t.Name()] = *g.structSchema(t)
+	}
+	return &Schema{Ref: "#/$defs/" + t.Name()}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+		Required:   make([]string, 0),
+	}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			for embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				g.addFields(schema, embeddedType)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = g.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/CameronHonis/chess-arbitrator/models"
+	"github.com/CameronHonis/chess-arbitrator/schema"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"os"
+)
+
+var _ = Describe("Generate", func() {
+	It("describes every registered content type", func() {
+		generated := schema.Generate()
+		Expect(generated.OneOf).To(HaveLen(len(models.ContentTypes())))
+		for _, contentType := range models.ContentTypes() {
+			Expect(generated.Defs).To(HaveKey(fmt.Sprintf("%s_MESSAGE", contentType)))
+		}
+	})
+	It("records the direction of each message", func() {
+		generated := schema.Generate()
+		Expect(generated.Defs["MOVE_MESSAGE"].Direction).To(Equal(string(models.MESSAGE_DIRECTION_CLIENT_TO_SERVER)))
+		Expect(generated.Defs["MATCH_UPDATED_MESSAGE"].Direction).To(Equal(string(models.MESSAGE_DIRECTION_SERVER_TO_CLIENT)))
+	})
+	It("describes the payload fields by their json names", func() {
+		moveContent := schema.Generate().Defs["MoveMessageContent"]
+		Expect(moveContent.Properties).To(HaveKey("matchId"))
+		Expect(moveContent.Properties).To(HaveKey("move"))
+	})
+	It("leaves fields hidden from json out", func() {
+		match := schema.Generate().Defs["Match"]
+		Expect(match.Properties).ToNot(HaveKey("LastMoveTime"))
+		Expect(match.Required).ToNot(ContainElement("moveHistory"))
+	})
+	It("matches the checked in spec", func() {
+		checkedIn, readErr := os.ReadFile("messages.schema.json")
+		Expect(readErr).ToNot(HaveOccurred())
+		generated, err := schema.Marshal()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(json.Valid(generated)).To(BeTrue())
+		Expect(string(generated)).To(Equal(string(checkedIn)), "messages.schema.json is out of date, run scripts/generate_schema.sh")
+	})
+})
@@ -19,5 +19,6 @@ $GOPATH/bin/mockgen -source=../clients_manager/clients_manager.go -destination m
 $GOPATH/bin/mockgen -source=../matcher/matcher_service.go -destination mocks/matcher_service_mock.go -package mocks &>> mocks/matcher_service_mock.go
 $GOPATH/bin/mockgen -source=../matchmaking/matchmaking_service.go -destination mocks/matchmaking_service_mock.go -package mocks &>> mocks/matchmaking_service_mock.go
 $GOPATH/bin/mockgen -source=../router_service/router_service.go -destination mocks/router_service_mock.go -package mocks &>> mocks/router_service_mock.go
+$GOPATH/bin/mockgen -source=../penalty/penalty_service.go -destination mocks/penalty_service_mock.go -package mocks &>> mocks/penalty_service_mock.go
 $GOPATH/bin/mockgen -source=../sub_service/sub_service.go -destination mocks/sub_service_mock.go -package mocks &>> mocks/sub_service_mock.go
 $GOPATH/bin/mockgen -source=../../log/logger_service.go -destination mocks/logger_service_mock.go -package mocks &>> mocks/logger_service_mock.go
@@ -0,0 +1,5 @@
+#!/bin/bash
+
+cd .. || exit
+
+go run ./cmd/schemagen schema/messages.schema.json
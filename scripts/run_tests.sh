@@ -1 +1 @@
-./generate_mocks.sh && go test -json ./... -run ./...
\ No newline at end of file
+./generate_mocks.sh && go test -race -json ./... -run ./...
\ No newline at end of file